@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+var ErrNotArchived = errors.New("payload not archived")
+
+// Payload is a raw, gzip-compressed iRacing API response body
+type Payload struct {
+	Endpoint  string // path of the API endpoint, or the full URL without query for data links
+	Params    string // canonically encoded query parameters
+	FetchedAt time.Time
+	Gzip      []byte
+}
+
+// Archive stores raw API responses, Load returns the most recent payload for an endpoint and its parameters
+type Archive interface {
+	Store(context.Context, Payload) error
+	Load(ctx context.Context, endpoint, params string) (Payload, error)
+	List(ctx context.Context, endpoint string) ([]string, error)
+}
+
+// WithArchive makes the client write every response body into the archive
+func WithArchive(archive Archive) Option {
+	return func(c *Client) {
+		c.archive = archive
+	}
+}
+
+// WithReplay makes the client answer all requests from the archive, without touching the network
+func WithReplay(archive Archive) Option {
+	return func(c *Client) {
+		c.replay = archive
+	}
+}
+
+func (c *Client) payloadKey(rawURL string) (string, string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, ""
+	}
+	endpoint := u.Path
+	if !strings.HasPrefix(rawURL, c.baseURL) {
+		endpoint = u.Scheme + "://" + u.Host + u.Path
+	}
+	return endpoint, u.Query().Encode()
+}
+
+func (c *Client) archivePayload(ctx context.Context, rawURL string, data []byte) {
+	if c.archive == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		log.Errorf("could not compress payload of [%s]: %v", rawURL, err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		log.Errorf("could not compress payload of [%s]: %v", rawURL, err)
+		return
+	}
+
+	endpoint, params := c.payloadKey(rawURL)
+	if err := c.archive.Store(ctx, Payload{
+		Endpoint:  endpoint,
+		Params:    params,
+		FetchedAt: time.Now().UTC(),
+		Gzip:      buf.Bytes(),
+	}); err != nil {
+		log.Errorf("could not archive payload of [%s]: %v", rawURL, err)
+	}
+}
+
+func (c *Client) replayPayload(ctx context.Context, rawURL string) ([]byte, error) {
+	endpoint, params := c.payloadKey(rawURL)
+	payload, err := c.replay.Load(ctx, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("replaying payload of [%s?%s] fetched at %v", endpoint, params, payload.FetchedAt)
+
+	zr, err := gzip.NewReader(bytes.NewReader(payload.Gzip))
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	defer zr.Close()
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return data, nil
+}
+
+// DirArchive keeps payloads as <dir>/<endpoint>/<params>/<fetch time>.json.gz files
+type DirArchive struct {
+	Path string
+}
+
+const archiveTimeFormat = "20060102T150405.000000000Z"
+
+func (d DirArchive) dir(endpoint, params string) string {
+	if len(params) == 0 {
+		params = "_"
+	}
+	return filepath.Join(d.Path, url.PathEscape(strings.TrimPrefix(endpoint, "/")), url.PathEscape(params))
+}
+
+func (d DirArchive) Store(_ context.Context, payload Payload) error {
+	dir := d.dir(payload.Endpoint, payload.Params)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, payload.FetchedAt.UTC().Format(archiveTimeFormat)+".json.gz")
+	return os.WriteFile(file, payload.Gzip, 0644)
+}
+
+func (d DirArchive) Load(_ context.Context, endpoint, params string) (Payload, error) {
+	dir := d.dir(endpoint, params)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Payload{}, fmt.Errorf("%w: %s?%s", ErrNotArchived, endpoint, params)
+		}
+		return Payload{}, err
+	}
+
+	// most recent one wins, the file names sort chronologically
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json.gz") {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return Payload{}, fmt.Errorf("%w: %s?%s", ErrNotArchived, endpoint, params)
+	}
+	sort.Strings(files)
+	latest := files[len(files)-1]
+
+	data, err := os.ReadFile(filepath.Join(dir, latest))
+	if err != nil {
+		return Payload{}, err
+	}
+	fetchedAt, _ := time.Parse(archiveTimeFormat, strings.TrimSuffix(latest, ".json.gz"))
+	return Payload{
+		Endpoint:  endpoint,
+		Params:    params,
+		FetchedAt: fetchedAt,
+		Gzip:      data,
+	}, nil
+}
+
+func (d DirArchive) List(_ context.Context, endpoint string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Path, url.PathEscape(strings.TrimPrefix(endpoint, "/"))))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	params := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		p, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		if p == "_" {
+			p = ""
+		}
+		params = append(params, p)
+	}
+	sort.Strings(params)
+	return params, nil
+}
@@ -0,0 +1,45 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Archive_Replay(t *testing.T) {
+	ctx := context.Background()
+	archive := api.DirArchive{Path: t.TempDir()}
+
+	server := apitest.NewServer()
+	client := api.New(append(server.Options(), api.WithArchive(archive))...)
+	seasons, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	results, err := client.GetTimeTrialResults(ctx, 3492, 74, 3)
+	require.NoError(t, err)
+	server.Close()
+
+	params, err := archive.List(ctx, "/data/series/seasons")
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, params)
+	params, err = archive.List(ctx, "/data/stats/season_tt_standings")
+	require.NoError(t, err)
+	require.Len(t, params, 1)
+	assert.Contains(t, params[0], "season_id=3492")
+
+	// the server is gone, everything has to come out of the archive
+	replay := api.New(append(server.Options(), api.WithReplay(archive))...)
+	replayedSeasons, err := replay.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, seasons, replayedSeasons)
+	replayedResults, err := replay.GetTimeTrialResults(ctx, 3492, 74, 3)
+	require.NoError(t, err)
+	assert.Equal(t, results, replayedResults)
+
+	_, err = replay.GetRaceWeekResults(ctx, 3492, 7)
+	assert.True(t, errors.Is(err, api.ErrNotArchived))
+}
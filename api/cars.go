@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -8,9 +9,9 @@ import (
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetCars() ([]Car, error) {
+func (c *Client) GetCars(ctx context.Context) ([]Car, error) {
 	log.Infoln("Get all cars ...")
-	data, err := c.FollowLink("https://members-ng.iracing.com/data/car/get")
+	data, err := c.FollowLink(ctx, c.baseURL+"/data/car/get")
 	if err != nil {
 		return nil, err
 	}
@@ -20,11 +21,11 @@ func (c *Client) GetCars() ([]Car, error) {
 	if err := json.Unmarshal(data, &cars); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal car data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// now the graphical assets
-	data, err = c.FollowLink("https://members-ng.iracing.com/data/car/assets")
+	data, err = c.FollowLink(ctx, c.baseURL+"/data/car/assets")
 	if err != nil {
 		return nil, err
 	}
@@ -32,16 +33,16 @@ func (c *Client) GetCars() ([]Car, error) {
 	if err := json.Unmarshal(data, &carAssets); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal car asset data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// now insert the asset URLs into the car data
 	for idx := range cars {
 		if asset, ok := carAssets[strconv.Itoa(cars[idx].CarID)]; ok {
 			cars[idx].Description = strings.ReplaceAll(strings.ReplaceAll(asset.Description, "\r", ""), "\n", "")
-			cars[idx].LogoImage = "https://images-static.iracing.com" + asset.Logo
-			cars[idx].CarImage = "https://images-static.iracing.com" + asset.Folder + "/" + asset.SmallImage
-			cars[idx].PanelImage = "https://images-static.iracing.com" + asset.Folder + "/" + asset.LargeImage
+			cars[idx].LogoImage = c.imagesURL + asset.Logo
+			cars[idx].CarImage = c.imagesURL + asset.Folder + "/" + asset.SmallImage
+			cars[idx].PanelImage = c.imagesURL + asset.Folder + "/" + asset.LargeImage
 		}
 	}
 	return cars, nil
@@ -2,9 +2,11 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -15,7 +17,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/JamesClonk/iRcollector/env"
 	"github.com/JamesClonk/iRcollector/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -30,18 +31,35 @@ var (
 		Name: "ircollector_api_client_request_error_total",
 		Help: "Total number of iRcollector API client request errors.",
 	})
-	clientRequestTotal = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "ircollector_api_client_request_total",
-		Help: "Total number of iRcollector API client request.",
-	})
+	clientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ircollector_api_client_request_duration_seconds",
+		Help:    "Latency of single iRcollector API client request attempts, by endpoint and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint", "status"})
+	clientRequestAttempts = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ircollector_api_client_request_attempts",
+		Help:    "Number of attempts needed for iRcollector API client requests, by endpoint.",
+		Buckets: []float64{1, 2, 3, 4, 5, 7, 10},
+	}, []string{"endpoint"})
 )
 
 type Client struct {
-	CookieJar   *cookiejar.Jar
-	Token       Token
-	mutex       *sync.Mutex
-	lastLogin   time.Time
-	lastRefresh time.Time
+	CookieJar          *cookiejar.Jar
+	Token              Token
+	mutex              *sync.Mutex
+	lastLogin          time.Time
+	accessTokenExpiry  time.Time
+	refreshTokenExpiry time.Time
+	baseURL            string
+	oauthURL           string
+	imagesURL          string
+	httpClient         *http.Client
+	credentials        CredentialsProvider
+	tokenStore         TokenStore
+	limiter            *RateLimiter
+	retryPolicy        RetryPolicy
+	archive            Archive
+	replay             Archive
 }
 
 type Token struct {
@@ -53,36 +71,56 @@ type Token struct {
 	Scope                 string `json:"scope"`
 }
 
-func New() *Client {
+func New(opts ...Option) *Client {
 	cookieJar, err := cookiejar.New(nil)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
-	return &Client{
+	c := &Client{
 		CookieJar:   cookieJar,
 		mutex:       &sync.Mutex{},
 		lastLogin:   time.Now().Add(-24 * time.Hour),
-		lastRefresh: time.Now().Add(-24 * time.Hour),
-	}
+		baseURL:     DefaultBaseURL,
+		oauthURL:    DefaultOAuthURL,
+		imagesURL:   DefaultImagesURL,
+		httpClient:  defaultHTTPClient(),
+		credentials: EnvCredentials{},
+		limiter:     NewRateLimiter(defaultRatelimit, defaultRatelimitWindow),
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.loadToken()
+	return c
+}
+
+// BaseURL returns the members-ng data API endpoint this client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
 }
 
-func (c *Client) LoginNG() error {
+func (c *Client) LoginNG(ctx context.Context) error {
 	log.Debugf("login to members-ng ...")
 
+	credentials, err := c.credentials.Credentials()
+	if err != nil {
+		return err
+	}
+
 	// https://forums.iracing.com/discussion/22109/login-form-changes
-	hash := sha256.Sum256([]byte(env.MustGet("IR_PASSWORD") + strings.ToLower(env.MustGet("IR_USERNAME"))))
+	hash := sha256.Sum256([]byte(credentials.Password + strings.ToLower(credentials.Username)))
 	password := base64.StdEncoding.EncodeToString(hash[:])
-	data := []byte(fmt.Sprintf(`{"email": "%s", "password": "%s"}`, env.MustGet("IR_USERNAME"), password))
+	data := []byte(fmt.Sprintf(`{"email": "%s", "password": "%s"}`, credentials.Username, password))
 
-	req, err := http.NewRequest("POST", "https://members-ng.iracing.com/auth", bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/auth", bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{
-		Jar: c.CookieJar,
-	}
+	client := *c.httpClient
+	client.Jar = c.CookieJar
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
@@ -90,7 +128,7 @@ func (c *Client) LoginNG() error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		time.Sleep(1 * time.Minute)
+		_ = sleep(ctx, 1*time.Minute)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("login failed with HTTP [%d]", resp.StatusCode)
@@ -98,31 +136,33 @@ func (c *Client) LoginNG() error {
 	return nil
 }
 
-func (c *Client) LoginToken() error {
+func (c *Client) LoginToken(ctx context.Context) error {
 	log.Debugf("login via oauth.iracing.com ...")
 
+	credentials, err := c.credentials.Credentials()
+	if err != nil {
+		return err
+	}
+
 	// https://oauth.iracing.com/oauth2/book/password_limited_flow.html
-	hash := sha256.Sum256([]byte(env.MustGet("IR_PASSWORD") + strings.ToLower(env.MustGet("IR_USERNAME"))))
+	hash := sha256.Sum256([]byte(credentials.Password + strings.ToLower(credentials.Username)))
 	hashedPassword := base64.StdEncoding.EncodeToString(hash[:])
-	hash = sha256.Sum256([]byte(env.MustGet("IR_CLIENT_SECRET") + strings.ToLower(env.MustGet("IR_CLIENT_ID"))))
+	hash = sha256.Sum256([]byte(credentials.ClientSecret + strings.ToLower(credentials.ClientID)))
 	hashedSecret := base64.StdEncoding.EncodeToString(hash[:])
 	data := []byte(fmt.Sprintf(`grant_type=password_limited&client_id=%s&client_secret=%s&username=%s&password=%s&scope=iracing.auth`,
-		url.QueryEscape(env.MustGet("IR_CLIENT_ID")),
+		url.QueryEscape(credentials.ClientID),
 		url.QueryEscape(hashedSecret),
-		url.QueryEscape(env.MustGet("IR_USERNAME")),
+		url.QueryEscape(credentials.Username),
 		url.QueryEscape(hashedPassword),
 	))
 
-	req, err := http.NewRequest("POST", "https://oauth.iracing.com/oauth2/token", bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.oauthURL, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{
-		Timeout: 22 * time.Second, // https://github.com/NickBaileyMA/irplc/blob/main/iracing_oauth_client/oauth.py has 30s as default
-	}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -131,28 +171,30 @@ func (c *Client) LoginToken() error {
 	return c.readToken(resp)
 }
 
-func (c *Client) RefreshToken() error {
+func (c *Client) RefreshToken(ctx context.Context) error {
 	log.Debugf("refreshing token via oauth.iracing.com ...")
 
+	credentials, err := c.credentials.Credentials()
+	if err != nil {
+		return err
+	}
+
 	// https://oauth.iracing.com/oauth2/book/token_endpoint.html#refresh-token-grant
-	hash := sha256.Sum256([]byte(env.MustGet("IR_CLIENT_SECRET") + strings.ToLower(env.MustGet("IR_CLIENT_ID"))))
+	hash := sha256.Sum256([]byte(credentials.ClientSecret + strings.ToLower(credentials.ClientID)))
 	hashedSecret := base64.StdEncoding.EncodeToString(hash[:])
 	data := []byte(fmt.Sprintf(`grant_type=refresh_token&client_id=%s&client_secret=%s&refresh_token=%s`,
-		url.QueryEscape(env.MustGet("IR_CLIENT_ID")),
+		url.QueryEscape(credentials.ClientID),
 		url.QueryEscape(hashedSecret),
 		url.QueryEscape(c.Token.RefreshToken),
 	))
 
-	req, err := http.NewRequest("POST", "https://oauth.iracing.com/oauth2/token", bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.oauthURL, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{
-		Timeout: 22 * time.Second, // https://github.com/NickBaileyMA/irplc/blob/main/iracing_oauth_client/oauth.py has 30s as default
-	}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -161,14 +203,28 @@ func (c *Client) RefreshToken() error {
 	return c.readToken(resp)
 }
 
+func (c *Client) login(ctx context.Context) error {
+	if err := c.LoginToken(ctx); err != nil {
+		clientLoginError.Inc()
+		if !errors.Is(err, ErrRateLimited) {
+			_ = sleep(ctx, 3*time.Second) // safety sleep
+		}
+		return err
+	}
+	c.lastLogin = time.Now()
+	c.saveToken()
+	return nil
+}
+
 func (c *Client) readToken(resp *http.Response) error {
 	log.Debugf("reading tokens from oauth.iracing.com response ...")
 
+	// don't wait here while holding the token mutex, the limiter and the retry backoff take care of that
 	if resp.StatusCode == http.StatusTooManyRequests {
-		time.Sleep(1 * time.Minute)
+		c.limiter.UpdateFromHeaders(resp.Header)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("login failed with HTTP [%d]", resp.StatusCode)
+		return fmt.Errorf("login failed: %w", &StatusError{StatusCode: resp.StatusCode, URL: resp.Request.URL.String()})
 	}
 
 	// read oauth token
@@ -181,7 +237,7 @@ func (c *Client) readToken(resp *http.Response) error {
 	if err := json.Unmarshal(data, &c.Token); err != nil {
 		clientLoginError.Inc()
 		log.Errorf("could not unmarshal oauth token response: %s", data)
-		return err
+		return decodeError(err)
 	}
 
 	// default values
@@ -189,7 +245,7 @@ func (c *Client) readToken(resp *http.Response) error {
 		c.Token.ExpiresIn = 555
 	}
 	if c.Token.RefreshTokenExpiresIn == 0 {
-		c.Token.ExpiresIn = 3456
+		c.Token.RefreshTokenExpiresIn = 3456
 	}
 
 	// if we have no refresh-token, then set its expiry time to same as normal token, to force relogin before normal token expires
@@ -200,17 +256,23 @@ func (c *Client) readToken(resp *http.Response) error {
 	if len(c.Token.AccessToken) == 0 {
 		return fmt.Errorf("getting access-token failed, no access-token in response")
 	}
+	c.accessTokenExpiry = time.Now().Add(time.Duration(c.Token.ExpiresIn) * time.Second)
+	c.refreshTokenExpiry = time.Now().Add(time.Duration(c.Token.RefreshTokenExpiresIn) * time.Second)
 	return nil
 }
 
-func (c *Client) FollowLink(url string) ([]byte, error) {
+func (c *Client) FollowLink(ctx context.Context, url string) ([]byte, error) {
+	if c.replay != nil {
+		return c.replayPayload(ctx, url)
+	}
+
 	// get target link for caching first
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		clientRequestError.Inc()
 		return nil, err
 	}
-	data, err := c.doRequest(req, true)
+	data, err := c.doRequest(ctx, req, true)
 	if err != nil {
 		clientRequestError.Inc()
 		return nil, err
@@ -220,135 +282,179 @@ func (c *Client) FollowLink(url string) ([]byte, error) {
 	if err := json.Unmarshal(data, &link); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal cache link: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// now get the actual data
-	req, err = http.NewRequest("GET", link.Target, nil)
+	req, err = http.NewRequestWithContext(ctx, "GET", link.Target, nil)
 	if err != nil {
 		clientRequestError.Inc()
 		return nil, err
 	}
-	return c.doRequest(req, false)
+	data, err = c.doRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	c.archivePayload(ctx, url, data)
+	return data, nil
 }
 
-func (c *Client) Get(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	if c.replay != nil {
+		return c.replayPayload(ctx, url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		clientRequestError.Inc()
 		return nil, err
 	}
-	return c.doRequest(req, false)
+	data, err := c.doRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	c.archivePayload(ctx, url, data)
+	return data, nil
 }
 
-func (c *Client) Post(url string, values url.Values) ([]byte, error) {
-	req, err := http.NewRequest("POST", url, strings.NewReader(values.Encode()))
+func (c *Client) Post(ctx context.Context, url string, values url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(values.Encode()))
 	if err != nil {
 		clientRequestError.Inc()
 		return nil, err
 	}
-	return c.doRequest(req, false)
+	return c.doRequest(ctx, req, false)
 }
 
-func (c *Client) doRequest(req *http.Request, addToken bool) ([]byte, error) {
+// authorize makes sure there is a valid access token, logging in or refreshing it if necessary.
+// Only this is serialized, the requests themselves run concurrently and are throttled by the rate limiter.
+func (c *Client) authorize(ctx context.Context) (string, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	// relogin after a long time, or if refresh token is about to expire
-	if c.lastLogin.Before(time.Now().Add(-2*time.Hour)) ||
-		c.lastLogin.Before(time.Now().Add(-1*time.Duration(c.Token.RefreshTokenExpiresIn)*time.Second).Add(60*time.Second)) {
-		if err := c.LoginToken(); err != nil {
-			clientLoginError.Inc()
-			time.Sleep(3 * time.Second) // safety sleep
-			return nil, err
+	// relogin if refresh token is about to expire
+	if time.Now().Add(60 * time.Second).After(c.refreshTokenExpiry) {
+		if err := c.login(ctx); err != nil {
+			return "", err
 		}
-		c.lastLogin = time.Now()
-		c.lastRefresh = c.lastLogin
 	}
-	// refresh token if needed
-	if c.lastRefresh.Before(time.Now().Add(-1 * time.Duration(c.Token.ExpiresIn) * time.Second).Add(60 * time.Second)) {
-		if err := c.RefreshToken(); err != nil {
+	// refresh token if needed, and fall back to a full login if the refresh token got rejected
+	if time.Now().Add(60 * time.Second).After(c.accessTokenExpiry) {
+		if err := c.RefreshToken(ctx); err != nil {
+			if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrForbidden) {
+				return "", err // a full login wouldn't fare any better
+			}
 			clientLoginError.Inc()
-			time.Sleep(3 * time.Second) // safety sleep
-			return nil, err
+			log.Warnf("could not refresh token, will do a full login: %v", err)
+			if err := c.login(ctx); err != nil {
+				return "", err
+			}
 		}
-		c.lastRefresh = time.Now()
+		c.saveToken()
 	}
 
 	// safety check
 	if len(c.Token.AccessToken) == 0 {
-		return nil, fmt.Errorf("no access-token found, cannot perform HTTP request against iracing API")
+		return "", fmt.Errorf("no access-token found, cannot perform HTTP request against iracing API")
 	}
+	return c.Token.AccessToken, nil
+}
 
-	// add headers
-	if addToken {
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.Token.AccessToken))
+// invalidateToken forces a new login on the next request, unless the token has already been replaced in the meantime
+func (c *Client) invalidateToken(accessToken string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.Token.AccessToken == accessToken {
+		c.accessTokenExpiry = time.Time{}
+		c.refreshTokenExpiry = time.Time{}
 	}
-	req.Header.Add("User-Agent", "iRcollector")
-	req.Header.Add("Accept", "application/json")
+}
 
-	client := &http.Client{
-		Timeout: 22 * time.Second, // https://github.com/NickBaileyMA/irplc/blob/main/iracing_oauth_client/oauth.py has 30s as default
+func (c *Client) doRequest(ctx context.Context, req *http.Request, addToken bool) ([]byte, error) {
+	endpoint := "link" // cached data links can have any URL, don't let them blow up the metric labels
+	if strings.HasPrefix(req.URL.String(), c.baseURL) {
+		endpoint = req.URL.Path
 	}
-	resp, err := client.Do(req)
-	if err != nil {
+
+	var err error
+	for attempt := 1; attempt <= c.retryPolicy.MaxAttempts || attempt == 1; attempt++ {
+		if attempt > 1 {
+			delay := c.retryPolicy.backoff(attempt - 1)
+			log.Warnf("retrying request to [%s] in %v, attempt %d of %d: %v", endpoint, delay, attempt, c.retryPolicy.MaxAttempts, err)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					break
+				}
+			}
+		}
+
+		var data []byte
+		data, err = c.doAttempt(ctx, req, addToken, endpoint)
+		if err == nil {
+			clientRequestAttempts.WithLabelValues(endpoint).Observe(float64(attempt))
+			return data, nil
+		}
 		clientRequestError.Inc()
-		time.Sleep(2 * time.Second) // safety sleep
-		return nil, fmt.Errorf("failed request: %v", err)
+		if !retryable(err) {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) doAttempt(ctx context.Context, req *http.Request, addToken bool, endpoint string) ([]byte, error) {
+	accessToken, err := c.authorize(ctx)
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			// invalid credentials, no point in retrying this
+			return nil, &permanentError{err}
+		}
+		return nil, err
+	}
+
+	// add headers, only requests against the API itself count towards the ratelimit, not the cached data links
+	if addToken {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
+	req.Header.Set("User-Agent", "iRcollector")
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		clientRequestDuration.WithLabelValues(endpoint, "error").Observe(time.Since(start).Seconds())
+		return nil, fmt.Errorf("failed request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// check ratelimiting values
+	if addToken {
+		c.limiter.UpdateFromHeaders(resp.Header)
+	}
+
 	data, err := ioutil.ReadAll(resp.Body)
+	clientRequestDuration.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
 	if err != nil {
-		clientRequestError.Inc()
-		return nil, fmt.Errorf("read body: %v", err)
+		return nil, fmt.Errorf("read body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Debugf("API call failed, response: %s", data)
-		clientRequestError.Inc()
-		time.Sleep(2 * time.Second) // safety sleep
-		return nil, fmt.Errorf("status code: %v", resp.StatusCode)
-	}
-
-	/*
-		X-Ratelimit-Limit:[240]
-		X-Ratelimit-Remaining:[239]
-		X-Ratelimit-Reset:[1641553935]
-	*/
-	// check ratelimiting values
-	ratelimitRemaining := resp.Header.Get("Ratelimit-Remaining")
-	if len(ratelimitRemaining) == 0 {
-		ratelimitRemaining = resp.Header.Get("X-Ratelimit-Remaining")
-	}
-	ratelimitReset := resp.Header.Get("Ratelimit-Reset")
-	if len(ratelimitReset) == 0 {
-		ratelimitReset = resp.Header.Get("X-Ratelimit-Reset")
-	}
-	// do we have the necessary headers? (is it members-ng?)
-	if len(ratelimitRemaining) > 0 && len(ratelimitReset) > 0 {
-		remaining, err := strconv.Atoi(ratelimitRemaining)
-		if err != nil {
-			remaining = 0
-		}
-		if remaining < 10 {
-			sleepEpoch, err := strconv.ParseInt(ratelimitReset, 10, 64)
-			if err != nil {
-				sleepEpoch = time.Now().Add(1 * time.Minute).Unix()
+		err := &StatusError{StatusCode: resp.StatusCode, URL: req.URL.String(), Body: data}
+		if errors.Is(err, ErrUnauthorized) {
+			if !addToken {
+				return nil, &permanentError{err} // an expired data link won't get any better
 			}
-			log.Debugf("sleeping for ratelimit, until: %v", time.Unix(sleepEpoch, 0))
-			time.Sleep(time.Until(time.Unix(sleepEpoch, 0)))
+			c.invalidateToken(accessToken)
 		}
-	} else if req.URL.Host == "members.iracing.com" {
-		// old API, lets sleep a fixed amount
-		log.Debugf("sleeping for 2s because of old API call to: [%s, %s]", req.URL.Host, req.URL.RequestURI())
-		time.Sleep(2 * time.Second)
-	} else {
-		//log.Debugln("could not determine ratelimit, will do a safety sleep ...")
-		time.Sleep(444 * time.Millisecond) // safety sleep
+		return nil, err
 	}
-	time.Sleep(111 * time.Millisecond) // safety sleep
-
-	clientRequestTotal.Inc()
 	return data, nil
 }
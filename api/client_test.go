@@ -0,0 +1,290 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_LoginAndFollowLink(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+
+	client := api.New(server.Options()...)
+	seasons, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	require.Len(t, seasons, 2)
+	assert.Equal(t, 3492, seasons[0].SeasonID)
+	assert.Equal(t, "Radical Racing Challenge - 2022 Season 1", seasons[0].SeasonName)
+
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+	assert.Equal(t, 1, server.Requests("/data/series/seasons"))
+	assert.Equal(t, 1, server.Requests("/link/data/series/seasons"))
+	assert.NotEmpty(t, client.Token.AccessToken)
+	assert.NotEmpty(t, client.Token.RefreshToken)
+}
+
+func Test_Client_PastSeasons(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+
+	client := api.New(server.Options()...)
+	seasons, err := client.GetPastSeasons(ctx, 74)
+	require.NoError(t, err)
+	require.Len(t, seasons, 4)
+	assert.Equal(t, 3350, seasons[1].SeasonID)
+	assert.Equal(t, 2021, seasons[1].Year)
+	assert.Equal(t, 4, seasons[1].Quarter)
+
+	seasons, err = client.GetSeasonList(ctx, 2021, 4)
+	require.NoError(t, err)
+	require.Len(t, seasons, 2)
+	assert.Equal(t, "Formula Vee - 2021 Season 4", seasons[1].SeasonName)
+}
+
+func Test_Client_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	server.ExpiresIn = 60 // tokens are refreshed when within 60s of expiry, so this forces a refresh on every request
+
+	client := api.New(server.Options()...)
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	refreshToken := client.Token.RefreshToken
+
+	server.RevokeTokens()
+	_, err = client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, refreshToken, client.Token.RefreshToken)
+}
+
+func Test_Client_InvalidCredentials(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+
+	credentials := apitest.Credentials
+	credentials.Password = "wrong"
+	client := api.New(append(server.Options(), api.WithCredentials(credentials))...)
+	_, err := client.GetCurrentSeasons(ctx)
+	assert.True(t, errors.Is(err, api.ErrUnauthorized))
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+	assert.Equal(t, 0, server.Requests("/data/series/seasons"))
+}
+
+func Test_Client_Assets(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+
+	client := api.New(server.Options()...)
+	tracks, err := client.GetTracks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "road", tracks[0].Category)
+	assert.Equal(t, server.URL+"/images/img/logos/tracks/hungaroring-logo.png", tracks[0].LogoImage)
+
+	cars, err := client.GetCars(ctx)
+	require.NoError(t, err)
+	require.Len(t, cars, 2)
+	assert.Equal(t, "The Radical SR8 is a lightweightsports prototype.", cars[0].Description)
+	assert.Equal(t, server.URL+"/images/img/cars/radicalsr8/radicalsr8-small.jpg", cars[0].CarImage)
+}
+
+func Test_Client_TokenStore(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	store := api.FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+
+	client := api.New(append(server.Options(), api.WithTokenStore(store))...)
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+
+	stored, err := store.LoadToken()
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, client.Token.AccessToken, stored.AccessToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.RefreshTokenExpiresAt, time.Minute)
+
+	// a restarted client reuses the stored token without logging in again
+	client = api.New(append(server.Options(), api.WithTokenStore(store))...)
+	_, err = client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+}
+
+func Test_Client_TokenStoreFallback(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	store := api.FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+
+	// expired refresh token, needs a full login
+	require.NoError(t, store.SaveToken(api.StoredToken{
+		Token:                 api.Token{AccessToken: "old", RefreshToken: "old"},
+		AccessTokenExpiresAt:  time.Now().Add(-time.Hour),
+		RefreshTokenExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	client := api.New(append(server.Options(), api.WithTokenStore(store))...)
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+
+	// refresh token unknown to the server, gets rejected and falls back to a full login
+	require.NoError(t, store.SaveToken(api.StoredToken{
+		Token:                 api.Token{AccessToken: "old", RefreshToken: "rejected"},
+		AccessTokenExpiresAt:  time.Now().Add(-time.Minute),
+		RefreshTokenExpiresAt: time.Now().Add(time.Hour),
+	}))
+	client = api.New(append(server.Options(), api.WithTokenStore(store))...)
+	_, err = client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.Requests("/oauth2/token"))
+
+	stored, err := store.LoadToken()
+	require.NoError(t, err)
+	assert.Equal(t, client.Token.RefreshToken, stored.RefreshToken)
+}
+
+func Test_Client_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+
+	client := api.New(server.Options()...)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetSessionResult(ctx, 43774896)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// only a single login, even though all requests started at the same time
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+	assert.Equal(t, 10, server.Requests("/data/results/get"))
+}
+
+func Test_Client_Retry(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	client := api.New(server.Options()...)
+
+	// server errors are retried
+	server.FailNext("/data/series/seasons", http.StatusInternalServerError, 1)
+	server.FailNext("/link/data/series/seasons", http.StatusBadGateway, 1)
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.Requests("/data/series/seasons"))
+	assert.Equal(t, 2, server.Requests("/link/data/series/seasons"))
+
+	// until the retry policy gives up
+	server.FailNext("/data/track/get", http.StatusTooManyRequests, 5)
+	_, err = client.GetTracks(ctx)
+	assert.True(t, errors.Is(err, api.ErrRateLimited))
+	assert.Equal(t, 3, server.Requests("/data/track/get"))
+
+	// not found is not retried
+	server.FailNext("/data/car/get", http.StatusNotFound, 1)
+	_, err = client.GetCars(ctx)
+	assert.True(t, errors.Is(err, api.ErrNotFound))
+	var statusErr *api.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, 1, server.Requests("/data/car/get"))
+
+	// neither is garbage
+	server.SetFixture("/data/results/get", []byte(`{"subsession_id": "garbage"}`))
+	_, err = client.GetSessionResult(ctx, 43774896)
+	assert.True(t, errors.Is(err, api.ErrDecode))
+	assert.Equal(t, 1, server.Requests("/data/results/get"))
+}
+
+func Test_Client_ReloginOnUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	client := api.New(server.Options()...)
+
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+
+	server.RevokeTokens()
+	_, err = client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.Requests("/oauth2/token"))
+	assert.Equal(t, 3, server.Requests("/data/series/seasons"))
+}
+
+func Test_Client_RateLimitedLogin(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	client := api.New(server.Options()...)
+
+	// a ratelimited login doesn't block, the retry backoff takes care of waiting
+	server.FailNext("/oauth2/token", http.StatusTooManyRequests, 1)
+	start := time.Now()
+	_, err := client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, 2, server.Requests("/oauth2/token"))
+	assert.Equal(t, 1, server.Requests("/data/series/seasons"))
+}
+
+func Test_Client_Forbidden(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer()
+	defer server.Close()
+	client := api.New(server.Options()...)
+
+	// forbidden is final, neither retried nor a reason to log in again
+	server.FailNext("/data/track/get", http.StatusForbidden, 1)
+	_, err := client.GetTracks(ctx)
+	assert.True(t, errors.Is(err, api.ErrForbidden))
+	assert.False(t, errors.Is(err, api.ErrUnauthorized))
+	assert.Equal(t, 1, server.Requests("/data/track/get"))
+	assert.Equal(t, 1, server.Requests("/oauth2/token"))
+
+	server.FailNext("/oauth2/token", http.StatusForbidden, 1)
+	client = api.New(server.Options()...)
+	_, err = client.GetCars(ctx)
+	assert.True(t, errors.Is(err, api.ErrForbidden))
+	assert.Equal(t, 2, server.Requests("/oauth2/token"))
+	assert.Equal(t, 0, server.Requests("/data/car/get"))
+}
+
+func Test_Client_Cancel(t *testing.T) {
+	server := apitest.NewServer()
+	defer server.Close()
+	client := api.New(server.Options()...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GetCurrentSeasons(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, server.Requests("/data/series/seasons"))
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	usernameKey     = "IR_USERNAME"
+	passwordKey     = "IR_PASSWORD"
+	clientIDKey     = "IR_CLIENT_ID"
+	clientSecretKey = "IR_CLIENT_SECRET"
+)
+
+type Credentials struct {
+	Username     string
+	Password     string
+	ClientID     string
+	ClientSecret string
+}
+
+func (c Credentials) Validate() error {
+	missing := make([]string, 0)
+	if len(c.Username) == 0 {
+		missing = append(missing, usernameKey)
+	}
+	if len(c.Password) == 0 {
+		missing = append(missing, passwordKey)
+	}
+	if len(c.ClientID) == 0 {
+		missing = append(missing, clientIDKey)
+	}
+	if len(c.ClientSecret) == 0 {
+		missing = append(missing, clientSecretKey)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing credentials: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CredentialsProvider is asked for credentials on every login, so rotated credentials are picked up without a restart
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// EnvCredentials reads IR_USERNAME, IR_PASSWORD, IR_CLIENT_ID and IR_CLIENT_SECRET from the environment
+type EnvCredentials struct{}
+
+func (EnvCredentials) Credentials() (Credentials, error) {
+	c := Credentials{
+		Username:     os.Getenv(usernameKey),
+		Password:     os.Getenv(passwordKey),
+		ClientID:     os.Getenv(clientIDKey),
+		ClientSecret: os.Getenv(clientSecretKey),
+	}
+	return c, c.Validate()
+}
+
+// FileCredentials reads credentials either from a directory containing one file per key,
+// like a mounted Kubernetes secret volume, or from a single file with KEY=value lines
+type FileCredentials struct {
+	Path string
+}
+
+func (f FileCredentials) Credentials() (Credentials, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	values := make(map[string]string)
+	if info.IsDir() {
+		for _, key := range []string{usernameKey, passwordKey, clientIDKey, clientSecretKey} {
+			data, err := os.ReadFile(filepath.Join(f.Path, key))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return Credentials{}, err
+			}
+			values[key] = strings.TrimSpace(string(data))
+		}
+	} else {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return Credentials{}, err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			if !ok {
+				continue
+			}
+			values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+
+	c := Credentials{
+		Username:     values[usernameKey],
+		Password:     values[passwordKey],
+		ClientID:     values[clientIDKey],
+		ClientSecret: values[clientSecretKey],
+	}
+	return c, c.Validate()
+}
+
+// StaticCredentials always returns the same credentials, i.e. for tests
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Credentials() (Credentials, error) {
+	c := Credentials(s)
+	return c, c.Validate()
+}
+
+// WithCredentials sets where the client gets its iRacing login credentials from, defaults to EnvCredentials
+func WithCredentials(provider CredentialsProvider) Option {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Credentials_Env(t *testing.T) {
+	t.Setenv("IR_USERNAME", "driver@example.com")
+	t.Setenv("IR_PASSWORD", "secret")
+	t.Setenv("IR_CLIENT_ID", "")
+	t.Setenv("IR_CLIENT_SECRET", "")
+
+	_, err := EnvCredentials{}.Credentials()
+	assert.EqualError(t, err, "missing credentials: IR_CLIENT_ID, IR_CLIENT_SECRET")
+
+	t.Setenv("IR_CLIENT_ID", "ircollector")
+	t.Setenv("IR_CLIENT_SECRET", "very-secret")
+	c, err := EnvCredentials{}.Credentials()
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{"driver@example.com", "secret", "ircollector", "very-secret"}, c)
+}
+
+func Test_Credentials_SecretVolume(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, value string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(value), 0600))
+	}
+	write("IR_USERNAME", "driver@example.com\n")
+	write("IR_PASSWORD", "secret\n")
+	write("IR_CLIENT_ID", "ircollector")
+
+	provider := FileCredentials{Path: dir}
+	_, err := provider.Credentials()
+	assert.EqualError(t, err, "missing credentials: IR_CLIENT_SECRET")
+
+	write("IR_CLIENT_SECRET", "very-secret")
+	c, err := provider.Credentials()
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{"driver@example.com", "secret", "ircollector", "very-secret"}, c)
+
+	// rotated secrets are picked up on the next call
+	write("IR_PASSWORD", "rotated")
+	c, err = provider.Credentials()
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", c.Password)
+}
+
+func Test_Credentials_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ir.conf")
+	require.NoError(t, os.WriteFile(file, []byte(`# iRacing credentials
+export IR_USERNAME="driver@example.com"
+IR_PASSWORD='secret'
+IR_CLIENT_ID=ircollector
+IR_CLIENT_SECRET=very=secret
+`), 0600))
+
+	c, err := FileCredentials{Path: file}.Credentials()
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{"driver@example.com", "secret", "ircollector", "very=secret"}, c)
+
+	_, err = FileCredentials{Path: filepath.Join(t.TempDir(), "missing")}.Credentials()
+	assert.Error(t, err)
+}
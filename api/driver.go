@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -9,14 +10,14 @@ import (
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetMembers(memberIDs []int) ([]Member, error) {
+func (c *Client) GetMembers(ctx context.Context, memberIDs []int) ([]Member, error) {
 	IDs := make([]string, 0)
 	for _, ID := range memberIDs {
 		IDs = append(IDs, strconv.Itoa(ID))
 	}
 
 	log.Infof("Get members [%s] ...", strings.Join(IDs, ","))
-	data, err := c.FollowLink(fmt.Sprintf("https://members-ng.iracing.com/data/member/get?include_licenses=true&cust_ids=%s", strings.Join(IDs, ",")))
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/member/get?include_licenses=true&cust_ids=%s", c.baseURL, strings.Join(IDs, ",")))
 	if err != nil {
 		return nil, err
 	}
@@ -27,14 +28,14 @@ func (c *Client) GetMembers(memberIDs []int) ([]Member, error) {
 	if err := json.Unmarshal(data, &members); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal member data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return members.Members, nil
 }
 
-func (c *Client) GetMemberStats(memberID int) ([]MemberStats, error) {
+func (c *Client) GetMemberStats(ctx context.Context, memberID int) ([]MemberStats, error) {
 	log.Infof("Get career stats for member [%d] ...", memberID)
-	data, err := c.FollowLink(fmt.Sprintf("https://members-ng.iracing.com/data/stats/member_career?cust_id=%d", memberID))
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/stats/member_career?cust_id=%d", c.baseURL, memberID))
 	if err != nil {
 		return nil, err
 	}
@@ -45,14 +46,14 @@ func (c *Client) GetMemberStats(memberID int) ([]MemberStats, error) {
 	if err := json.Unmarshal(data, &stats); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal members stats data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return stats.Stats, nil
 }
 
-func (c *Client) GetMemberRecentRaces(memberID int) ([]MemberRecentRace, error) {
+func (c *Client) GetMemberRecentRaces(ctx context.Context, memberID int) ([]MemberRecentRace, error) {
 	log.Infof("Get recent races for member [%d] ...", memberID)
-	data, err := c.FollowLink(fmt.Sprintf("https://members-ng.iracing.com/data/stats/member_recent_races?cust_id=%d", memberID))
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/stats/member_recent_races?cust_id=%d", c.baseURL, memberID))
 	if err != nil {
 		return nil, err
 	}
@@ -63,14 +64,14 @@ func (c *Client) GetMemberRecentRaces(memberID int) ([]MemberRecentRace, error)
 	if err := json.Unmarshal(data, &races); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal recent races data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return races.RecentRaces, nil
 }
 
-func (c *Client) GetMemberYearlyStats(memberID int) ([]MemberYearlyStats, error) {
+func (c *Client) GetMemberYearlyStats(ctx context.Context, memberID int) ([]MemberYearlyStats, error) {
 	log.Infof("Get yearly stats for member [%d] ...", memberID)
-	data, err := c.FollowLink(fmt.Sprintf("https://members-ng.iracing.com/data/stats/member_yearly?cust_id=%d", memberID))
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/stats/member_yearly?cust_id=%d", c.baseURL, memberID))
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +82,7 @@ func (c *Client) GetMemberYearlyStats(memberID int) ([]MemberYearlyStats, error)
 	if err := json.Unmarshal(data, &stats); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal yearly stats data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return stats.YearlyStats, nil
 }
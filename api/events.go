@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// GetEventLog returns all events, like incidents, cautions and pit stops, of the race simsession of a subsession
+func (c *Client) GetEventLog(ctx context.Context, subsessionID int) ([]Event, error) {
+	log.Infof("Get event log [subsessionID:%d] ...", subsessionID)
+
+	// get event log struct, containing a list of event chunk files
+	data, err := c.FollowLink(ctx,
+		fmt.Sprintf("%s/data/results/event_log?subsession_id=%d&simsession_number=0", c.baseURL, subsessionID))
+	if err != nil {
+		log.Errorln("could not get event log")
+		return nil, err
+	}
+
+	eventLog := struct {
+		ChunkInfo struct {
+			BaseURL string   `json:"base_download_url"`
+			Chunks  []string `json:"chunk_file_names"`
+		} `json:"chunk_info"`
+	}{}
+	if err := json.Unmarshal(data, &eventLog); err != nil {
+		clientRequestError.Inc()
+		log.Errorf("could not unmarshal event log: %s", data)
+		return nil, decodeError(err)
+	}
+
+	// collect all actual data chunks
+	events := make([]Event, 0)
+	for _, chunkFile := range eventLog.ChunkInfo.Chunks {
+		data, err := c.Get(ctx, fmt.Sprintf("%s%s", eventLog.ChunkInfo.BaseURL, chunkFile))
+		if err != nil {
+			log.Errorf("could not get event log chunk data [%s%s]", eventLog.ChunkInfo.BaseURL, chunkFile)
+			return nil, err
+		}
+
+		chunk := make([]Event, 0)
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			clientRequestError.Inc()
+			log.Errorf("could not unmarshal event log chunk data: %s", data)
+			return nil, decodeError(err)
+		}
+		events = append(events, chunk...)
+	}
+
+	// add subsessionID
+	for idx := range events {
+		events[idx].SubsessionID = subsessionID
+	}
+	return events, nil
+}
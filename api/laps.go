@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// GetLapChartData returns every lap of every driver in the race simsession of a subsession
+func (c *Client) GetLapChartData(ctx context.Context, subsessionID int) ([]Lap, error) {
+	log.Infof("Get lap chart data [subsessionID:%d] ...", subsessionID)
+
+	// get lap chart struct, containing a list of lap chunk files
+	data, err := c.FollowLink(ctx,
+		fmt.Sprintf("%s/data/results/lap_chart_data?subsession_id=%d&simsession_number=0", c.baseURL, subsessionID))
+	if err != nil {
+		log.Errorln("could not get lap chart data")
+		return nil, err
+	}
+
+	lapChart := struct {
+		ChunkInfo struct {
+			BaseURL string   `json:"base_download_url"`
+			Chunks  []string `json:"chunk_file_names"`
+		} `json:"chunk_info"`
+	}{}
+	if err := json.Unmarshal(data, &lapChart); err != nil {
+		clientRequestError.Inc()
+		log.Errorf("could not unmarshal lap chart data: %s", data)
+		return nil, decodeError(err)
+	}
+
+	// collect all actual data chunks
+	laps := make([]Lap, 0)
+	for _, chunkFile := range lapChart.ChunkInfo.Chunks {
+		data, err := c.Get(ctx, fmt.Sprintf("%s%s", lapChart.ChunkInfo.BaseURL, chunkFile))
+		if err != nil {
+			log.Errorf("could not get lap chart chunk data [%s%s]", lapChart.ChunkInfo.BaseURL, chunkFile)
+			return nil, err
+		}
+
+		chunk := make([]Lap, 0)
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			clientRequestError.Inc()
+			log.Errorf("could not unmarshal lap chart chunk data: %s", data)
+			return nil, decodeError(err)
+		}
+		laps = append(laps, chunk...)
+	}
+
+	// add subsessionID
+	for idx := range laps {
+		laps[idx].SubsessionID = subsessionID
+	}
+	return laps, nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -955,6 +956,7 @@ type Season struct {
 	MaxWeeks        int       `json:"max_weeks"`
 	FixedSetup      bool      `json:"fixed_setup"`
 	Official        bool      `json:"official"`
+	LicenseGroup    int       `json:"license_group"` // 1 rookie, 2 D, 3 C, 4 B, 5 A
 	TrackTypes      []struct {
 		TrackType string `json:"track_type"`
 	} `json:"track_types"`
@@ -972,7 +974,7 @@ type Season struct {
 			Name     string `json:"track_name"`
 			Config   string `json:"config_name"`
 			Category string `json:"category"`
-		} `json:"track_types"`
+		} `json:"track"`
 	} `json:"schedules"`
 }
 
@@ -1294,3 +1296,89 @@ type TimeTrialResult struct {
 func (r TimeTrialResult) String() string {
 	return fmt.Sprintf("[ Week: %d, Name: %s, Rank: %d, TT Points: %d ]", r.RaceWeek, r.DriverName, r.Rank, r.Points)
 }
+
+// lap flags, as used by iRacing in lap data
+const (
+	LapFlagInvalid       = 1
+	LapFlagPitted        = 2
+	LapFlagOffTrack      = 4
+	LapFlagBlackFlag     = 8
+	LapFlagCarReset      = 16
+	LapFlagContact       = 32
+	LapFlagCarContact    = 64
+	LapFlagLostControl   = 128
+	LapFlagDiscontinuity = 256
+	LapFlagTow           = 2048
+)
+
+type Lap struct {
+	SubsessionID     int      `json:"subsession_id"` // foreign-key to SessionResult
+	SimsessionNumber int      `json:"simsession_number"`
+	DriverID         int      `json:"cust_id"`
+	DriverName       string   `json:"display_name"`
+	LapNumber        int      `json:"lap_number"`
+	Flags            int      `json:"flags"`
+	Incident         bool     `json:"incident"`
+	SessionTime      int      `json:"session_time"`
+	LapTime          int      `json:"lap_time"` // -1 for laps without a valid time
+	PersonalBestLap  bool     `json:"personal_best_lap"`
+	LapEvents        []string `json:"lap_events"`
+	LapPosition      int      `json:"lap_position"`
+	Interval         int      `json:"interval"`
+	FastestLap       bool     `json:"fastest_lap"`
+}
+
+func (l Lap) String() string {
+	return fmt.Sprintf("[ SubsessionID: %d, Driver: %d, Lap: %d, Time: %d, Position: %d, Flags: %d ]", l.SubsessionID, l.DriverID, l.LapNumber, l.LapTime, l.LapPosition, l.Flags)
+}
+
+func (l Lap) Pitted() bool {
+	return l.Flags&LapFlagPitted != 0
+}
+
+func (l Lap) OffTrack() bool {
+	return l.Flags&LapFlagOffTrack != 0
+}
+
+// event types, derived from the description of an event log entry
+const (
+	EventTypeCaution  = "caution"
+	EventTypeIncident = "incident"
+	EventTypePit      = "pit"
+	EventTypeOther    = "other"
+)
+
+type Event struct {
+	SubsessionID     int    `json:"subsession_id"` // foreign-key to SessionResult
+	SimsessionNumber int    `json:"simsession_number"`
+	SessionTime      int    `json:"session_time"`
+	EventSeq         int    `json:"event_seq"`
+	EventCode        int    `json:"event_code"`
+	GroupID          int    `json:"group_id"`
+	DriverID         int    `json:"cust_id"` // 0 for events without a driver, like the end of a caution
+	DriverName       string `json:"display_name"`
+	LapNumber        int    `json:"lap_number"`
+	Description      string `json:"description"`
+	Message          string `json:"message"`
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("[ SubsessionID: %d, Seq: %d, Lap: %d, Driver: %d, Description: %s ]", e.SubsessionID, e.EventSeq, e.LapNumber, e.DriverID, e.Description)
+}
+
+// Type classifies an event by its description, since event codes are not documented by iRacing
+func (e Event) Type() string {
+	description := strings.ToLower(e.Description)
+	switch {
+	case strings.Contains(description, "caution"):
+		return EventTypeCaution
+	case strings.Contains(description, "pit"):
+		return EventTypePit
+	case strings.Contains(description, "incident"),
+		strings.Contains(description, "contact"),
+		strings.Contains(description, "off track"),
+		strings.Contains(description, "lost control"):
+		return EventTypeIncident
+	}
+	return EventTypeOther
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL   = "https://members-ng.iracing.com"
+	DefaultOAuthURL  = "https://oauth.iracing.com/oauth2/token"
+	DefaultImagesURL = "https://images-static.iracing.com"
+)
+
+type Option func(*Client)
+
+// WithBaseURL sets the members-ng data API endpoint, i.e. "https://members-ng.iracing.com"
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithOAuthURL sets the oauth token endpoint, i.e. "https://oauth.iracing.com/oauth2/token"
+func WithOAuthURL(url string) Option {
+	return func(c *Client) {
+		c.oauthURL = url
+	}
+}
+
+// WithImagesURL sets the static images endpoint that car and track asset paths are relative to
+func WithImagesURL(url string) Option {
+	return func(c *Client) {
+		c.imagesURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sets the http.Client used for all requests against the iRacing API
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+func defaultHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 22 * time.Second, // https://github.com/NickBaileyMA/irplc/blob/main/iracing_oauth_client/oauth.py has 30s as default
+	}
+}
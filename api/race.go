@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,13 +9,13 @@ import (
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetRaceWeekResults(seasonID, raceweek int) ([]RaceWeekResult, error) {
+func (c *Client) GetRaceWeekResults(ctx context.Context, seasonID, raceweek int) ([]RaceWeekResult, error) {
 	log.Infof("Get raceweek [%d] results of season [%d] ...", raceweek, seasonID)
 
-	data, err := c.FollowLink(
+	data, err := c.FollowLink(ctx,
 		// collect only races here, event type 5 = Race
-		fmt.Sprintf("https://members-ng.iracing.com/data/results/season_results?season_id=%d&event_type=5&race_week_num=%d",
-			seasonID, raceweek))
+		fmt.Sprintf("%s/data/results/season_results?season_id=%d&event_type=5&race_week_num=%d",
+			c.baseURL, seasonID, raceweek))
 	if err != nil {
 		return nil, err
 	}
@@ -25,7 +26,7 @@ func (c *Client) GetRaceWeekResults(seasonID, raceweek int) ([]RaceWeekResult, e
 	if err := json.Unmarshal(data, &results); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal season raceweek results data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	// add seasonID
 	for idx := range results.Results {
@@ -34,10 +35,10 @@ func (c *Client) GetRaceWeekResults(seasonID, raceweek int) ([]RaceWeekResult, e
 	return results.Results, nil
 }
 
-func (c *Client) GetSessionResult(subsessionID int) (SessionResult, error) {
+func (c *Client) GetSessionResult(ctx context.Context, subsessionID int) (SessionResult, error) {
 	log.Infof("Get session result [subsessionID:%d] ...", subsessionID)
 
-	data, err := c.FollowLink(fmt.Sprintf("https://members-ng.iracing.com/data/results/get?include_licenses=true&subsession_id=%d", subsessionID))
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/results/get?include_licenses=true&subsession_id=%d", c.baseURL, subsessionID))
 	if err != nil {
 		return SessionResult{}, err
 	}
@@ -50,7 +51,7 @@ func (c *Client) GetSessionResult(subsessionID int) (SessionResult, error) {
 	if err := json.Unmarshal(data, &results); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal subsession data: %s", data)
-		return SessionResult{}, err
+		return SessionResult{}, decodeError(err)
 	}
 	return results, nil
 }
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	clientRatelimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "ircollector_api_client_ratelimit_remaining",
+		Help: "Remaining iRacing API requests in the current ratelimit window, as reported by the server.",
+	})
+)
+
+const (
+	defaultRatelimit       = 240 // requests per window, members-ng default
+	defaultRatelimitWindow = time.Minute
+	ratelimitReserve       = 10 // always keep a few requests in reserve, i.e. for logins
+)
+
+// RateLimiter is a token bucket shared by all requests of a client, refilling continuously at limit/window
+// and clamped to whatever the server reports as remaining in its X-Ratelimit-* headers
+type RateLimiter struct {
+	mutex        *sync.Mutex
+	capacity     float64
+	tokens       float64
+	rate         float64 // tokens per second
+	window       time.Duration
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	r := &RateLimiter{
+		mutex:  &sync.Mutex{},
+		window: window,
+		last:   time.Now(),
+	}
+	r.setLimit(limit)
+	r.tokens = r.capacity
+	return r
+}
+
+func (r *RateLimiter) setLimit(limit int) {
+	r.capacity = float64(limit - ratelimitReserve)
+	if r.capacity < 1 {
+		r.capacity = 1
+	}
+	r.rate = r.capacity / r.window.Seconds()
+}
+
+func (r *RateLimiter) refill(now time.Time) {
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.capacity {
+		r.tokens = r.capacity
+	}
+	r.last = now
+}
+
+// Wait blocks until a request is allowed to be made, or the context is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		r.mutex.Lock()
+		now := time.Now()
+		r.refill(now)
+
+		var delay time.Duration
+		if now.Before(r.blockedUntil) {
+			delay = r.blockedUntil.Sub(now)
+		} else if r.tokens >= 1 {
+			r.tokens--
+			r.mutex.Unlock()
+			return nil
+		} else {
+			delay = time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		}
+		r.mutex.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Update adjusts the bucket to the server reported limit, remaining requests and reset time
+func (r *RateLimiter) Update(limit, remaining int, reset time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	clientRatelimitRemaining.Set(float64(remaining))
+	if limit > 0 && float64(limit-ratelimitReserve) != r.capacity {
+		r.setLimit(limit)
+	}
+
+	r.refill(time.Now())
+	available := float64(remaining - ratelimitReserve)
+	if available < 1 {
+		log.Debugf("ratelimit reached, waiting until: %v", reset)
+		r.tokens = 0
+		r.blockedUntil = reset
+		return
+	}
+	if r.tokens > available {
+		r.tokens = available
+	}
+}
+
+// UpdateFromHeaders reads the ratelimit headers of a response, if it has any
+func (r *RateLimiter) UpdateFromHeaders(header http.Header) {
+	/*
+		X-Ratelimit-Limit:[240]
+		X-Ratelimit-Remaining:[239]
+		X-Ratelimit-Reset:[1641553935]
+	*/
+	ratelimitLimit := headerValue(header, "Ratelimit-Limit")
+	ratelimitRemaining := headerValue(header, "Ratelimit-Remaining")
+	ratelimitReset := headerValue(header, "Ratelimit-Reset")
+	// do we have the necessary headers? (is it members-ng?)
+	if len(ratelimitRemaining) == 0 || len(ratelimitReset) == 0 {
+		return
+	}
+
+	limit, err := strconv.Atoi(ratelimitLimit)
+	if err != nil {
+		limit = 0
+	}
+	remaining, err := strconv.Atoi(ratelimitRemaining)
+	if err != nil {
+		remaining = 0
+	}
+	resetEpoch, err := strconv.ParseInt(ratelimitReset, 10, 64)
+	if err != nil {
+		resetEpoch = time.Now().Add(1 * time.Minute).Unix()
+	}
+	r.Update(limit, remaining, time.Unix(resetEpoch, 0))
+}
+
+func headerValue(header http.Header, key string) string {
+	if value := header.Get(key); len(value) > 0 {
+		return value
+	}
+	return header.Get("X-" + key)
+}
+
+// WithRateLimiter sets the rate limiter, allowing several clients to share one budget
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
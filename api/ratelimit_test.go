@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimiter_ClampsToServerRemaining(t *testing.T) {
+	limiter := NewRateLimiter(240, time.Minute)
+	limiter.Update(240, ratelimitReserve+2, time.Now().Add(time.Minute))
+
+	start := time.Now()
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	// bucket is empty now, next token refills at 230 per minute
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.True(t, time.Since(start) > 200*time.Millisecond)
+}
+
+func Test_RateLimiter_BlocksUntilReset(t *testing.T) {
+	limiter := NewRateLimiter(240, time.Minute)
+
+	header := http.Header{}
+	header.Set("X-Ratelimit-Limit", "240")
+	header.Set("X-Ratelimit-Remaining", "3")
+	header.Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(2*time.Second).Unix(), 10))
+	limiter.UpdateFromHeaders(header)
+
+	start := time.Now()
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.True(t, time.Since(start) > 500*time.Millisecond)
+	assert.True(t, time.Since(start) < 3*time.Second)
+}
+
+func Test_RateLimiter_IgnoresMissingHeaders(t *testing.T) {
+	limiter := NewRateLimiter(240, time.Minute)
+	limiter.UpdateFromHeaders(http.Header{})
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+}
+
+func Test_RateLimiter_Cancel(t *testing.T) {
+	limiter := NewRateLimiter(240, time.Minute)
+	limiter.Update(240, 0, time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(limiter.Wait(ctx), context.DeadlineExceeded))
+}
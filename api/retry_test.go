@@ -0,0 +1,21 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(1)
+		assert.True(t, delay >= 500*time.Millisecond && delay <= time.Second, delay)
+		delay = policy.backoff(2)
+		assert.True(t, delay >= time.Second && delay <= 2*time.Second, delay)
+		delay = policy.backoff(10)
+		assert.True(t, delay >= 2500*time.Millisecond && delay <= 5*time.Second, delay)
+	}
+}
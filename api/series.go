@@ -1,14 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetCurrentSeasons() ([]Season, error) {
+func (c *Client) GetCurrentSeasons(ctx context.Context) ([]Season, error) {
 	log.Infoln("Get current seasons ...")
-	data, err := c.FollowLink("https://members-ng.iracing.com/data/series/seasons")
+	data, err := c.FollowLink(ctx, c.baseURL+"/data/series/seasons")
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +19,47 @@ func (c *Client) GetCurrentSeasons() ([]Season, error) {
 	if err := json.Unmarshal(data, &seasons); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal season data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return seasons, nil
 }
+
+// GetPastSeasons returns all seasons a series ever had, including the current one
+func (c *Client) GetPastSeasons(ctx context.Context, seriesID int) ([]Season, error) {
+	log.Infof("Get past seasons of series [%d] ...", seriesID)
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/series/past_seasons?series_id=%d", c.baseURL, seriesID))
+	if err != nil {
+		return nil, err
+	}
+
+	pastSeasons := struct {
+		Series struct {
+			Seasons []Season `json:"seasons"`
+		} `json:"series"`
+	}{}
+	if err := json.Unmarshal(data, &pastSeasons); err != nil {
+		clientRequestError.Inc()
+		log.Errorf("could not unmarshal past season data: %s", data)
+		return nil, decodeError(err)
+	}
+	return pastSeasons.Series.Seasons, nil
+}
+
+// GetSeasonList returns all seasons of all series that ran in a given year and quarter
+func (c *Client) GetSeasonList(ctx context.Context, year, quarter int) ([]Season, error) {
+	log.Infof("Get season list of %dS%d ...", year, quarter)
+	data, err := c.FollowLink(ctx, fmt.Sprintf("%s/data/season/list?season_year=%d&season_quarter=%d", c.baseURL, year, quarter))
+	if err != nil {
+		return nil, err
+	}
+
+	seasonList := struct {
+		Seasons []Season `json:"seasons"`
+	}{}
+	if err := json.Unmarshal(data, &seasonList); err != nil {
+		clientRequestError.Inc()
+		log.Errorf("could not unmarshal season list data: %s", data)
+		return nil, decodeError(err)
+	}
+	return seasonList.Seasons, nil
+}
@@ -1,19 +1,20 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetTimeTrialResults(seasonID, carClassID, raceweek int) ([]TimeTrialResult, error) {
+func (c *Client) GetTimeTrialResults(ctx context.Context, seasonID, carClassID, raceweek int) ([]TimeTrialResult, error) {
 	log.Infof("Get timetrial standings of season [%d], week [%d] ...", seasonID, raceweek)
 
 	// get tt-standings struct, containing a list of result chunk files
-	data, err := c.FollowLink(
-		fmt.Sprintf("https://members-ng.iracing.com/data/stats/season_tt_standings?season_id=%d&car_class_id=%d&race_week_num=%d",
-			seasonID, carClassID, raceweek))
+	data, err := c.FollowLink(ctx,
+		fmt.Sprintf("%s/data/stats/season_tt_standings?season_id=%d&car_class_id=%d&race_week_num=%d",
+			c.baseURL, seasonID, carClassID, raceweek))
 	if err != nil {
 		log.Errorln("could not get timetrial standings data")
 		return nil, err
@@ -28,13 +29,13 @@ func (c *Client) GetTimeTrialResults(seasonID, carClassID, raceweek int) ([]Time
 	if err := json.Unmarshal(data, &ttResults); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal timetrial standings data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// collect all actual data chunks
 	results := make([]TimeTrialResult, 0)
 	for _, chunkFile := range ttResults.ChunkInfo.Chunks {
-		data, err := c.Get(fmt.Sprintf("%s%s", ttResults.ChunkInfo.BaseURL, chunkFile))
+		data, err := c.Get(ctx, fmt.Sprintf("%s%s", ttResults.ChunkInfo.BaseURL, chunkFile))
 		if err != nil {
 			log.Errorf("could not get timetrial standings chunk data [%s%s]", ttResults.ChunkInfo.BaseURL, chunkFile)
 			return nil, err
@@ -44,7 +45,7 @@ func (c *Client) GetTimeTrialResults(seasonID, carClassID, raceweek int) ([]Time
 		if err := json.Unmarshal(data, &chunk); err != nil {
 			clientRequestError.Inc()
 			log.Errorf("could not unmarshal timetrial standings chunk data: %s", data)
-			return nil, err
+			return nil, decodeError(err)
 		}
 
 		// add chunk data to final results collection
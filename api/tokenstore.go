@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// StoredToken is an oauth token together with its absolute expiry times, so it stays valid across restarts
+type StoredToken struct {
+	Token
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	LastLogin             time.Time `json:"last_login"`
+}
+
+// TokenStore persists oauth tokens, LoadToken returns nil if there is no stored token yet
+type TokenStore interface {
+	LoadToken() (*StoredToken, error)
+	SaveToken(StoredToken) error
+}
+
+// FileTokenStore keeps the oauth token as JSON in a file on disk
+type FileTokenStore struct {
+	Path string
+}
+
+func (f FileTokenStore) LoadToken() (*StoredToken, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	token := &StoredToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (f FileTokenStore) SaveToken(token StoredToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash can never leave a half-written token behind
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// WithTokenStore makes the client persist its oauth tokens and reuse them on startup instead of doing a fresh login
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Client) {
+		c.tokenStore = store
+	}
+}
+
+func (c *Client) loadToken() {
+	if c.tokenStore == nil {
+		return
+	}
+
+	token, err := c.tokenStore.LoadToken()
+	if err != nil {
+		log.Warnf("could not load stored oauth token: %v", err)
+		return
+	}
+	if token == nil || len(token.AccessToken) == 0 {
+		return
+	}
+	if time.Now().Add(60 * time.Second).After(token.RefreshTokenExpiresAt) {
+		log.Infoln("stored oauth refresh token has expired, will do a full login")
+		return
+	}
+
+	log.Infof("using stored oauth token, refresh token valid until %v", token.RefreshTokenExpiresAt)
+	c.Token = token.Token
+	c.accessTokenExpiry = token.AccessTokenExpiresAt
+	c.refreshTokenExpiry = token.RefreshTokenExpiresAt
+	c.lastLogin = token.LastLogin
+}
+
+func (c *Client) saveToken() {
+	if c.tokenStore == nil {
+		return
+	}
+
+	if err := c.tokenStore.SaveToken(StoredToken{
+		Token:                 c.Token,
+		AccessTokenExpiresAt:  c.accessTokenExpiry,
+		RefreshTokenExpiresAt: c.refreshTokenExpiry,
+		LastLogin:             c.lastLogin,
+	}); err != nil {
+		log.Errorf("could not store oauth token: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -8,9 +9,9 @@ import (
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Client) GetTracks() ([]Track, error) {
+func (c *Client) GetTracks(ctx context.Context) ([]Track, error) {
 	log.Infoln("Get all tracks ...")
-	data, err := c.FollowLink("https://members-ng.iracing.com/data/track/get")
+	data, err := c.FollowLink(ctx, c.baseURL+"/data/track/get")
 	if err != nil {
 		return nil, err
 	}
@@ -20,11 +21,11 @@ func (c *Client) GetTracks() ([]Track, error) {
 	if err := json.Unmarshal(data, &tracks); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal track data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// now the graphical assets
-	data, err = c.FollowLink("https://members-ng.iracing.com/data/track/assets")
+	data, err = c.FollowLink(ctx, c.baseURL+"/data/track/assets")
 	if err != nil {
 		return nil, err
 	}
@@ -32,15 +33,15 @@ func (c *Client) GetTracks() ([]Track, error) {
 	if err := json.Unmarshal(data, &trackAssets); err != nil {
 		clientRequestError.Inc()
 		log.Errorf("could not unmarshal track asset data: %s", data)
-		return nil, err
+		return nil, decodeError(err)
 	}
 
 	// now insert the asset URLs into the track data
 	for idx := range tracks {
 		if asset, ok := trackAssets[strconv.Itoa(tracks[idx].TrackID)]; ok {
-			tracks[idx].LogoImage = "https://images-static.iracing.com" + asset.Logo
-			tracks[idx].BannerImage = "https://images-static.iracing.com" + asset.Folder + "/" + asset.SmallImage
-			tracks[idx].PanelImage = "https://images-static.iracing.com" + asset.Folder + "/" + asset.LargeImage
+			tracks[idx].LogoImage = c.imagesURL + asset.Logo
+			tracks[idx].BannerImage = c.imagesURL + asset.Folder + "/" + asset.SmallImage
+			tracks[idx].PanelImage = c.imagesURL + asset.Folder + "/" + asset.LargeImage
 			tracks[idx].MapImage = "-"
 			tracks[idx].ConfigImage = "-"
 		}
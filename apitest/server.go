@@ -0,0 +1,308 @@
+// Package apitest provides a fake iRacing API server for tests, serving recorded fixtures
+// through the same oauth and link-indirection flows the real members-ng API uses.
+package apitest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/api"
+)
+
+const (
+	Username     = "driver@example.com"
+	Password     = "secret-password"
+	ClientID     = "ircollector-test"
+	ClientSecret = "secret-client"
+)
+
+// Credentials are the only ones accepted by the oauth endpoint
+var Credentials = api.StaticCredentials{
+	Username:     Username,
+	Password:     Password,
+	ClientID:     ClientID,
+	ClientSecret: ClientSecret,
+}
+
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// default fixtures, by request path
+var fixtures = map[string]string{
+	"/data/series/seasons":               "series_seasons.json",
+	"/data/series/past_seasons":          "series_past_seasons.json",
+	"/data/season/list":                  "season_list.json",
+	"/data/track/get":                    "track_get.json",
+	"/data/track/assets":                 "track_assets.json",
+	"/data/car/get":                      "car_get.json",
+	"/data/car/assets":                   "car_assets.json",
+	"/data/results/season_results":       "season_results.json",
+	"/data/results/get":                  "results_get.json",
+	"/data/stats/season_tt_results":      "season_tt_results.json",
+	"/data/stats/season_tt_standings":    "season_tt_standings.json",
+	"/chunks/season_tt_results_0.json":   "season_tt_results_0.json",
+	"/chunks/season_tt_standings_0.json": "season_tt_standings_0.json",
+	"/data/results/lap_chart_data":       "lap_chart_data.json",
+	"/chunks/lap_chart_data_0.json":      "lap_chart_data_0.json",
+	"/data/results/event_log":            "event_log.json",
+	"/chunks/event_log_0.json":           "event_log_0.json",
+	"/data/member/get":                   "member_get.json",
+	"/data/stats/member_career":          "member_career.json",
+}
+
+type Server struct {
+	*httptest.Server
+	// ExpiresIn and RefreshTokenExpiresIn are the token lifetimes in seconds handed out by the oauth endpoint
+	ExpiresIn             int
+	RefreshTokenExpiresIn int
+
+	mutex         *sync.Mutex
+	fixtures      map[string][]byte
+	accessTokens  map[string]bool
+	refreshTokens map[string]bool
+	requests      map[string]int
+	failures      map[string][]int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		ExpiresIn:             600,
+		RefreshTokenExpiresIn: 3600,
+		mutex:                 &sync.Mutex{},
+		fixtures:              make(map[string][]byte),
+		accessTokens:          make(map[string]bool),
+		refreshTokens:         make(map[string]bool),
+		requests:              make(map[string]int),
+		failures:              make(map[string][]int),
+	}
+	for p, file := range fixtures {
+		data, err := fixtureFiles.ReadFile(path.Join("fixtures", file))
+		if err != nil {
+			panic(err)
+		}
+		s.fixtures[p] = data
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", s.token)
+	mux.HandleFunc("/data/", s.data)
+	mux.HandleFunc("/link/", s.link)
+	mux.HandleFunc("/chunks/", s.link)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Options returns the api client options needed to talk to this server instead of iRacing, including valid credentials
+func (s *Server) Options() []api.Option {
+	return []api.Option{
+		api.WithCredentials(Credentials),
+		api.WithBaseURL(s.URL),
+		api.WithOAuthURL(s.URL + "/oauth2/token"),
+		api.WithImagesURL(s.URL + "/images"),
+		api.WithHTTPClient(s.Client()),
+		api.WithRetryPolicy(api.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}),
+	}
+}
+
+// FailNext makes the next requests to a path fail with the given HTTP status code
+func (s *Server) FailNext(path string, statusCode, times int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := 0; i < times; i++ {
+		s.failures[path] = append(s.failures[path], statusCode)
+	}
+}
+
+// SetFixture overrides the response served for a path, optionally including a query string for a more specific match,
+// i.e. "/data/results/get?include_licenses=true&subsession_id=43774896"
+func (s *Server) SetFixture(path string, data []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fixtures[path] = data
+}
+
+// Requests returns how many times a path has been requested
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+// RevokeTokens invalidates all access tokens, but keeps refresh tokens valid
+func (s *Server) RevokeTokens() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.accessTokens = make(map[string]bool)
+}
+
+func (s *Server) token(rw http.ResponseWriter, req *http.Request) {
+	s.count(req.URL.Path)
+	if s.fail(rw, req) {
+		return
+	}
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		s.oauthError(rw, "invalid_request")
+		return
+	}
+	if req.PostForm.Get("client_id") != ClientID || req.PostForm.Get("client_secret") != mask(ClientSecret, ClientID) {
+		s.oauthError(rw, "invalid_client")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch req.PostForm.Get("grant_type") {
+	case "password_limited":
+		// https://oauth.iracing.com/oauth2/book/password_limited_flow.html
+		if req.PostForm.Get("username") != Username || req.PostForm.Get("password") != mask(Password, Username) {
+			s.oauthError(rw, "invalid_grant")
+			return
+		}
+	case "refresh_token":
+		// https://oauth.iracing.com/oauth2/book/token_endpoint.html#refresh-token-grant
+		refreshToken := req.PostForm.Get("refresh_token")
+		if !s.refreshTokens[refreshToken] {
+			s.oauthError(rw, "invalid_grant")
+			return
+		}
+		delete(s.refreshTokens, refreshToken) // refresh tokens are single-use
+	default:
+		s.oauthError(rw, "unsupported_grant_type")
+		return
+	}
+
+	token := api.Token{
+		AccessToken:           randomToken(),
+		RefreshToken:          randomToken(),
+		TokenType:             "Bearer",
+		ExpiresIn:             s.ExpiresIn,
+		RefreshTokenExpiresIn: s.RefreshTokenExpiresIn,
+		Scope:                 "iracing.auth",
+	}
+	s.accessTokens[token.AccessToken] = true
+	s.refreshTokens[token.RefreshToken] = true
+	writeJSON(rw, http.StatusOK, token)
+}
+
+func (s *Server) data(rw http.ResponseWriter, req *http.Request) {
+	s.count(req.URL.Path)
+	if s.fail(rw, req) {
+		return
+	}
+
+	s.mutex.Lock()
+	authorized := s.accessTokens[strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")]
+	s.mutex.Unlock()
+	if !authorized {
+		writeJSON(rw, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return
+	}
+	if _, ok := s.fixture(req.URL); !ok {
+		writeJSON(rw, http.StatusNotFound, map[string]string{"error": "Not Found"})
+		return
+	}
+
+	// members-ng never returns data directly, only a link to the cached data
+	target := s.URL + "/link" + req.URL.RequestURI()
+	ratelimit(rw)
+	writeJSON(rw, http.StatusOK, map[string]string{
+		"link":    target,
+		"expires": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) link(rw http.ResponseWriter, req *http.Request) {
+	s.count(req.URL.Path)
+	if s.fail(rw, req) {
+		return
+	}
+
+	u := *req.URL
+	u.Path = strings.TrimPrefix(u.Path, "/link")
+	data, ok := s.fixture(&u)
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte(strings.ReplaceAll(string(data), "{{server}}", s.URL)))
+}
+
+func (s *Server) fixture(u *url.URL) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if data, ok := s.fixtures[u.Path+"?"+u.RawQuery]; ok {
+		return data, true
+	}
+	data, ok := s.fixtures[u.Path]
+	return data, ok
+}
+
+func (s *Server) fail(rw http.ResponseWriter, req *http.Request) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	failures := s.failures[req.URL.Path]
+	if len(failures) == 0 {
+		return false
+	}
+	s.failures[req.URL.Path] = failures[1:]
+	if failures[0] == http.StatusTooManyRequests {
+		rw.Header().Set("X-Ratelimit-Limit", "240")
+		rw.Header().Set("X-Ratelimit-Remaining", "0")
+		rw.Header().Set("X-Ratelimit-Reset", fmt.Sprintf("%d", time.Now().Unix()))
+	}
+	writeJSON(rw, failures[0], map[string]string{"error": http.StatusText(failures[0])})
+	return true
+}
+
+func (s *Server) count(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests[path]++
+}
+
+func (s *Server) oauthError(rw http.ResponseWriter, code string) {
+	writeJSON(rw, http.StatusUnauthorized, map[string]string{"error": code})
+}
+
+func ratelimit(rw http.ResponseWriter) {
+	rw.Header().Set("X-Ratelimit-Limit", "240")
+	rw.Header().Set("X-Ratelimit-Remaining", "239")
+	rw.Header().Set("X-Ratelimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Minute).Unix()))
+}
+
+func writeJSON(rw http.ResponseWriter, code int, v interface{}) {
+	data, _ := json.Marshal(v)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	_, _ = rw.Write(data)
+}
+
+func mask(secret, id string) string {
+	hash := sha256.Sum256([]byte(secret + strings.ToLower(id)))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
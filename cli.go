@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/JamesClonk/iRcollector/collector"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// backfillCommand collects past seasons of a series without starting the HTTP server,
+// i.e. "iRcollector backfill -series 7 -from 2020S1 -to 2021S4"
+func backfillCommand(args []string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	seriesID := flags.Int("series", 0, "series to backfill (pk_series_id)")
+	from := flags.String("from", "", "first season to backfill, i.e. 2020S1")
+	to := flags.String("to", "", "last season to backfill, i.e. 2021S4 (default: same as -from)")
+	_ = flags.Parse(args)
+
+	if *seriesID <= 0 || len(*from) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if len(*to) == 0 {
+		*to = *from
+	}
+	fromYear, fromQuarter, err := parseSeason(*from)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	toYear, toQuarter, err := parseSeason(*to)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := checkSeasonRange(fromYear, fromQuarter, toYear, toQuarter); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	credentials := credentialsProvider()
+	if _, err := credentials.Credentials(); err != nil {
+		log.Errorln("Could not read iRacing credentials")
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	db := setupDatabase()
+	c := collector.New(db, collectorOptions(credentials, db)...)
+	if err := c.Backfill(ctx, *seriesID, fromYear, fromQuarter, toYear, toQuarter); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// parseSeason parses seasons written like "2021S4"
+func parseSeason(season string) (int, int, error) {
+	parts := strings.SplitN(strings.ToUpper(season), "S", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid season [%s], must look like 2021S4", season)
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid season [%s], must look like 2021S4", season)
+	}
+	quarter, err := strconv.Atoi(parts[1])
+	if err != nil || quarter < 1 || quarter > 4 {
+		return 0, 0, fmt.Errorf("invalid season [%s], must look like 2021S4", season)
+	}
+	return year, quarter, nil
+}
+
+// checkSeasonRange makes sure both quarters are 1-4 and the first season is not after the last one
+func checkSeasonRange(fromYear, fromQuarter, toYear, toQuarter int) error {
+	for _, quarter := range []int{fromQuarter, toQuarter} {
+		if quarter < 1 || quarter > 4 {
+			return fmt.Errorf("invalid quarter [%d], must be 1-4", quarter)
+		}
+	}
+	if fromYear*4+fromQuarter > toYear*4+toQuarter {
+		return fmt.Errorf("first season %dS%d is after last season %dS%d", fromYear, fromQuarter, toYear, toQuarter)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+// Package client provides a typed Go client for the iRcollector HTTP API
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+)
+
+type Client struct {
+	BaseURL    string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+func New(baseURL, username, password string) *Client {
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		Username: username,
+		Password: password,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (c *Client) GetHealth() (HealthResponse, error) {
+	var health HealthResponse
+	return health, c.do("GET", "/health", &health)
+}
+
+func (c *Client) GetSeries() (SeriesResponse, error) {
+	var series SeriesResponse
+	return series, c.do("GET", "/series", &series)
+}
+
+func (c *Client) CreateSeries(series database.Series) (SeriesItemResponse, error) {
+	var created SeriesItemResponse
+	return created, c.do("POST", "/series?"+seriesQuery(series).Encode(), &created)
+}
+
+func (c *Client) UpdateSeries(series database.Series) (SeriesItemResponse, error) {
+	var updated SeriesItemResponse
+	return updated, c.do("PUT", fmt.Sprintf("/series/%d?%s", series.SeriesID, seriesQuery(series).Encode()), &updated)
+}
+
+func (c *Client) DeactivateSeries(seriesID int) (SeriesItemResponse, error) {
+	var series SeriesItemResponse
+	return series, c.do("DELETE", fmt.Sprintf("/series/%d", seriesID), &series)
+}
+
+func seriesQuery(series database.Series) url.Values {
+	query := url.Values{}
+	query.Set("name", series.SeriesName)
+	query.Set("short_name", series.SeriesNameShort)
+	query.Set("regex", series.SeriesRegex)
+	query.Set("colorscheme", series.ColorScheme)
+	query.Set("api_series_id", strconv.Itoa(series.APISeriesID))
+	if len(series.Active) > 0 {
+		query.Set("active", series.Active)
+	}
+	return query
+}
+
+func (c *Client) GetSeasons() (SeasonsResponse, error) {
+	var seasons SeasonsResponse
+	return seasons, c.do("GET", "/seasons", &seasons)
+}
+
+func (c *Client) GetWeek(seasonID, week int) (WeekResponse, error) {
+	var w WeekResponse
+	return w, c.do("GET", fmt.Sprintf("/season/%d/week/%d", seasonID, week), &w)
+}
+
+func (c *Client) GetRace(subsessionID int) (RaceResponse, error) {
+	var race RaceResponse
+	return race, c.do("GET", fmt.Sprintf("/race/%d", subsessionID), &race)
+}
+
+func (c *Client) BackfillSeries(seriesID, fromYear, fromQuarter, toYear, toQuarter int) (TaskResponse, error) {
+	var task TaskResponse
+	return task, c.do("POST", fmt.Sprintf("/series/%d/backfill?from_year=%d&from_quarter=%d&to_year=%d&to_quarter=%d",
+		seriesID, fromYear, fromQuarter, toYear, toQuarter), &task)
+}
+
+func (c *Client) GetRaceLaps(subsessionID int) (RaceLapsResponse, error) {
+	var laps RaceLapsResponse
+	return laps, c.do("GET", fmt.Sprintf("/race/%d/laps", subsessionID), &laps)
+}
+
+func (c *Client) SearchDrivers(name string) (DriverSearchResponse, error) {
+	var drivers DriverSearchResponse
+	return drivers, c.do("GET", "/drivers?"+url.Values{"name": {name}}.Encode(), &drivers)
+}
+
+func (c *Client) GetDriver(driverID int) (DriverResponse, error) {
+	var driver DriverResponse
+	return driver, c.do("GET", fmt.Sprintf("/driver/%d", driverID), &driver)
+}
+
+func (c *Client) GetDriverResults(driverID int, filter database.DriverFilter) (DriverResultsResponse, error) {
+	var results DriverResultsResponse
+	return results, c.do("GET", fmt.Sprintf("/driver/%d/results%s", driverID, driverQuery(filter)), &results)
+}
+
+func (c *Client) GetDriverSummary(driverID int, filter database.DriverFilter) (DriverSummaryResponse, error) {
+	var summary DriverSummaryResponse
+	return summary, c.do("GET", fmt.Sprintf("/driver/%d/summary%s", driverID, driverQuery(filter)), &summary)
+}
+
+func (c *Client) GetDriverIRating(driverID int, filter database.DriverFilter) (DriverIRatingResponse, error) {
+	var iratings DriverIRatingResponse
+	return iratings, c.do("GET", fmt.Sprintf("/driver/%d/irating%s", driverID, driverQuery(filter)), &iratings)
+}
+
+func (c *Client) GetRatings(driverIDs []int, interval string, filter database.DriverFilter) (RatingsResponse, error) {
+	ids := make([]string, 0, len(driverIDs))
+	for _, id := range driverIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	query := driverValues(filter)
+	query.Set("drivers", strings.Join(ids, ","))
+	if len(interval) > 0 {
+		query.Set("interval", interval)
+	}
+
+	var ratings RatingsResponse
+	return ratings, c.do("GET", "/ratings?"+query.Encode(), &ratings)
+}
+
+// driverQuery turns a driver filter into query parameters, leaving out anything not set
+func driverQuery(filter database.DriverFilter) string {
+	query := driverValues(filter)
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+func driverValues(filter database.DriverFilter) url.Values {
+	query := url.Values{}
+	for key, value := range map[string]*int{
+		"season":    filter.SeasonID,
+		"week":      filter.Week,
+		"series":    filter.SeriesID,
+		"car_class": filter.CarClassID,
+	} {
+		if value != nil {
+			query.Set(key, strconv.Itoa(*value))
+		}
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	return query
+}
+
+func (c *Client) CollectSeasons() (TaskResponse, error) {
+	var task TaskResponse
+	return task, c.do("POST", "/seasons", &task)
+}
+
+func (c *Client) CollectSeason(seasonID int) (TaskResponse, error) {
+	var task TaskResponse
+	return task, c.do("POST", fmt.Sprintf("/season/%d", seasonID), &task)
+}
+
+func (c *Client) CollectWeek(seasonID, week int) (TaskResponse, error) {
+	var task TaskResponse
+	return task, c.do("POST", fmt.Sprintf("/season/%d/week/%d", seasonID, week), &task)
+}
+
+func (c *Client) GetTeams() (TeamsResponse, error) {
+	var teams TeamsResponse
+	return teams, c.do("GET", "/teams", &teams)
+}
+
+func (c *Client) GetTeam(teamID int) (TeamResponse, error) {
+	var team TeamResponse
+	return team, c.do("GET", fmt.Sprintf("/teams/%d", teamID), &team)
+}
+
+func (c *Client) CreateTeam(name string) (TeamResponse, error) {
+	var team TeamResponse
+	return team, c.do("POST", "/teams?"+url.Values{"name": {name}}.Encode(), &team)
+}
+
+func (c *Client) RenameTeam(teamID int, name string) (TeamResponse, error) {
+	var team TeamResponse
+	return team, c.do("PUT", fmt.Sprintf("/teams/%d?%s", teamID, url.Values{"name": {name}}.Encode()), &team)
+}
+
+func (c *Client) DeleteTeam(teamID int) (TeamResponse, error) {
+	var team TeamResponse
+	return team, c.do("DELETE", fmt.Sprintf("/teams/%d", teamID), &team)
+}
+
+func (c *Client) AddTeamMember(teamID, driverID int, validFrom time.Time, validTo *time.Time) (TeamMembershipResponse, error) {
+	query := membershipQuery(validFrom, validTo)
+	query.Set("driver", strconv.Itoa(driverID))
+
+	var membership TeamMembershipResponse
+	return membership, c.do("POST", fmt.Sprintf("/teams/%d/members?%s", teamID, query.Encode()), &membership)
+}
+
+func (c *Client) UpdateTeamMember(teamID, membershipID int, validFrom time.Time, validTo *time.Time) (TeamMembershipResponse, error) {
+	var membership TeamMembershipResponse
+	return membership, c.do("PUT", fmt.Sprintf("/teams/%d/members/%d?%s",
+		teamID, membershipID, membershipQuery(validFrom, validTo).Encode()), &membership)
+}
+
+func (c *Client) RemoveTeamMember(teamID, membershipID int) (TeamMembershipResponse, error) {
+	var membership TeamMembershipResponse
+	return membership, c.do("DELETE", fmt.Sprintf("/teams/%d/members/%d", teamID, membershipID), &membership)
+}
+
+func membershipQuery(validFrom time.Time, validTo *time.Time) url.Values {
+	query := url.Values{}
+	query.Set("from", validFrom.Format(time.RFC3339))
+	if validTo != nil {
+		query.Set("to", validTo.Format(time.RFC3339))
+	}
+	return query
+}
+
+func (c *Client) GetTeamRules(seriesID int) (TeamRulesResponse, error) {
+	var rules TeamRulesResponse
+	return rules, c.do("GET", fmt.Sprintf("/series/%d/team-rules", seriesID), &rules)
+}
+
+func (c *Client) UpdateTeamRules(rules database.TeamRules) (TeamRulesResponse, error) {
+	var updated TeamRulesResponse
+	return updated, c.do("PUT", fmt.Sprintf("/series/%d/team-rules?top_drivers=%d&drop_weeks=%d&min_weeks=%d",
+		rules.SeriesID, rules.TopDrivers, rules.DropWeeks, rules.MinWeeks), &updated)
+}
+
+func (c *Client) GetTeamStandings(seasonID int) (TeamStandingsResponse, error) {
+	var standings TeamStandingsResponse
+	return standings, c.do("GET", fmt.Sprintf("/season/%d/teams", seasonID), &standings)
+}
+
+func (c *Client) GetSchedule(seasonID int) (ScheduleResponse, error) {
+	var schedule ScheduleResponse
+	return schedule, c.do("GET", fmt.Sprintf("/season/%d/schedule", seasonID), &schedule)
+}
+
+func (c *Client) GetJobs() (JobsResponse, error) {
+	var jobs JobsResponse
+	return jobs, c.do("GET", "/jobs", &jobs)
+}
+
+func (c *Client) GetJob(jobID int) (JobResponse, error) {
+	var job JobResponse
+	return job, c.do("GET", fmt.Sprintf("/jobs/%d", jobID), &job)
+}
+
+func (c *Client) CancelJob(jobID int) (JobResponse, error) {
+	var job JobResponse
+	return job, c.do("DELETE", fmt.Sprintf("/jobs/%d", jobID), &job)
+}
+
+func (c *Client) do(method, path string, target interface{}) error {
+	req, err := http.NewRequest(method, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	if len(c.Username) > 0 {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e ErrorResponse
+		if err := json.Unmarshal(data, &e); err == nil && len(e.Error) > 0 {
+			return fmt.Errorf("%s %s failed with HTTP [%d]: %s", method, path, resp.StatusCode, e.Error)
+		}
+		return fmt.Errorf("%s %s failed with HTTP [%d]", method, path, resp.StatusCode)
+	}
+	return json.Unmarshal(data, target)
+}
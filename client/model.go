@@ -0,0 +1,145 @@
+package client
+
+import (
+	"github.com/JamesClonk/iRcollector/database"
+)
+
+// ErrorResponse is returned by every endpoint in case of failure
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// HealthResponse is returned by GET /health
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// TaskResponse is returned by the POST/PUT endpoints that trigger a collection in the background
+type TaskResponse struct {
+	Task     string `json:"task"`
+	SeriesID *int   `json:"series_id,omitempty"`
+	SeasonID *int   `json:"season_id,omitempty"`
+	Week     *int   `json:"week,omitempty"`
+	JobID    *int   `json:"job_id,omitempty"`
+}
+
+// SeriesResponse is returned by GET /series
+type SeriesResponse struct {
+	Series []database.Series `json:"series"`
+}
+
+// SeriesItemResponse is returned by POST /series and PUT or DELETE /series/{seriesID}
+type SeriesItemResponse struct {
+	Series database.Series `json:"series"`
+}
+
+// SeasonsResponse is returned by GET /seasons
+type SeasonsResponse struct {
+	Seasons []database.Season `json:"seasons"`
+}
+
+// WeekResponse is returned by GET /season/{seasonID}/week/{week}
+type WeekResponse struct {
+	SeasonID  int                       `json:"season_id"`
+	Week      int                       `json:"week"`
+	Results   []database.RaceWeekResult `json:"results"`
+	Rankings  []database.TimeRanking    `json:"rankings"`
+	Summaries []database.Summary        `json:"summaries"`
+}
+
+// RaceResponse is returned by GET /race/{subsessionID}
+type RaceResponse struct {
+	Stats       database.RaceStats    `json:"stats"`
+	Simsessions []database.Simsession `json:"simsessions"`
+	Results     []database.RaceResult `json:"results"` // of all simsessions, feature race first
+}
+
+// RaceLapsResponse is returned by GET /race/{subsessionID}/laps
+type RaceLapsResponse struct {
+	SubsessionID int                `json:"subsession_id"`
+	Laps         []database.RaceLap `json:"laps"`
+}
+
+// DriverSearchResponse is returned by GET /drivers
+type DriverSearchResponse struct {
+	Name    string                     `json:"name"`
+	Drivers []database.DriverNameMatch `json:"drivers"`
+}
+
+// DriverResponse is returned by GET /driver/{driverID}
+type DriverResponse struct {
+	Profile database.DriverProfile `json:"profile"`
+}
+
+// DriverResultsResponse is returned by GET /driver/{driverID}/results
+type DriverResultsResponse struct {
+	DriverID int                         `json:"driver_id"`
+	Results  []database.DriverRaceResult `json:"results"`
+}
+
+// DriverSummaryResponse is returned by GET /driver/{driverID}/summary
+type DriverSummaryResponse struct {
+	DriverID int                            `json:"driver_id"`
+	Seasons  []database.DriverSeasonSummary `json:"seasons"`
+}
+
+// DriverIRatingResponse is returned by GET /driver/{driverID}/irating
+type DriverIRatingResponse struct {
+	DriverID int                    `json:"driver_id"`
+	IRatings []database.RatingPoint `json:"iratings"`
+}
+
+// RatingsResponse is returned by GET /ratings
+type RatingsResponse struct {
+	Interval string          `json:"interval"`
+	Drivers  []DriverRatings `json:"drivers"`
+}
+
+// DriverRatings are the downsampled rating trajectories of a single driver, one per license category
+type DriverRatings struct {
+	Driver     database.Driver             `json:"driver"`
+	Categories []database.RatingTrajectory `json:"categories"`
+}
+
+// TeamsResponse is returned by GET /teams
+type TeamsResponse struct {
+	Teams []database.Team `json:"teams"`
+}
+
+// TeamResponse is returned by GET /teams/{teamID}, POST /teams and PUT or DELETE /teams/{teamID}
+type TeamResponse struct {
+	Team        database.Team             `json:"team"`
+	Memberships []database.TeamMembership `json:"memberships"`
+}
+
+// TeamMembershipResponse is returned by POST /teams/{teamID}/members and PUT or DELETE /teams/{teamID}/members/{membershipID}
+type TeamMembershipResponse struct {
+	Membership database.TeamMembership `json:"membership"`
+}
+
+// TeamRulesResponse is returned by GET and PUT /series/{seriesID}/team-rules
+type TeamRulesResponse struct {
+	Rules database.TeamRules `json:"rules"`
+}
+
+// TeamStandingsResponse is returned by GET /season/{seasonID}/teams
+type TeamStandingsResponse struct {
+	SeasonID  int                     `json:"season_id"`
+	Rules     database.TeamRules      `json:"rules"`
+	Standings []database.TeamStanding `json:"standings"`
+}
+
+// ScheduleResponse is returned by GET /season/{seasonID}/schedule
+type ScheduleResponse struct {
+	Schedule database.SeasonSchedule `json:"schedule"`
+}
+
+// JobsResponse is returned by GET /jobs
+type JobsResponse struct {
+	Jobs []database.Job `json:"jobs"`
+}
+
+// JobResponse is returned by GET /jobs/{jobID} and DELETE /jobs/{jobID}
+type JobResponse struct {
+	Job database.Job `json:"job"`
+}
@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+)
+
+// DatabaseArchive keeps raw iRacing API payloads in the raw_payloads table
+type DatabaseArchive struct {
+	db database.Database
+}
+
+func NewDatabaseArchive(db database.Database) *DatabaseArchive {
+	return &DatabaseArchive{db: db}
+}
+
+func (a *DatabaseArchive) Store(ctx context.Context, payload api.Payload) error {
+	return a.db.InsertRawPayload(ctx, database.RawPayload{
+		Endpoint:  payload.Endpoint,
+		Params:    payload.Params,
+		FetchedAt: payload.FetchedAt,
+		Payload:   payload.Gzip,
+	})
+}
+
+func (a *DatabaseArchive) Load(ctx context.Context, endpoint, params string) (api.Payload, error) {
+	payload, err := a.db.GetLatestRawPayload(ctx, endpoint, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.Payload{}, fmt.Errorf("%w: %s?%s", api.ErrNotArchived, endpoint, params)
+		}
+		return api.Payload{}, err
+	}
+	return api.Payload{
+		Endpoint:  payload.Endpoint,
+		Params:    payload.Params,
+		FetchedAt: payload.FetchedAt,
+		Gzip:      payload.Payload,
+	}, nil
+}
+
+func (a *DatabaseArchive) List(ctx context.Context, endpoint string) ([]string, error) {
+	return a.db.GetRawPayloadParams(ctx, endpoint)
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// Backfill discovers all seasons of a series between two year/quarter pairs (inclusive) and collects all of their weeks.
+// Weeks that have already been collected after they were over are skipped, so an interrupted backfill can just be started again.
+// It returns the error of the first week that could not be collected, or why the series could not be backfilled at all.
+func (c *Collector) Backfill(ctx context.Context, seriesID, fromYear, fromQuarter, toYear, toQuarter int) error {
+	log.Infof("backfilling series [%d] from %dS%d to %dS%d ...", seriesID, fromYear, fromQuarter, toYear, toQuarter)
+
+	series, err := c.db.GetSeries(ctx)
+	if err != nil {
+		return jobError(ctx, "could not read series information from database: %v", err)
+	}
+	var found bool
+	var s database.Series
+	for _, s = range series {
+		if s.SeriesID == seriesID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return jobError(ctx, "series [%d] does not exist", seriesID)
+	}
+
+	seasons := c.discoverSeasons(ctx, s, fromYear, fromQuarter, toYear, toQuarter)
+	var failure error
+	var weeks int
+	for _, season := range seasons {
+		weeks += seasonWeeks(season)
+	}
+	jobTotal(ctx, "weeks", weeks)
+	for _, season := range seasons {
+		if ctx.Err() != nil {
+			return nil // shutting down
+		}
+		log.Infof("Season: %s", season)
+		if season.StartDate.IsZero() {
+			season.StartDate = seasonStartDate(season.Year, season.Quarter)
+		}
+		c.upsertSeason(ctx, s, season)
+
+		for w := 0; w < seasonWeeks(season) && ctx.Err() == nil; w++ {
+			if c.raceWeekComplete(ctx, season, w) {
+				log.Debugf("raceweek [%d] of season [%d] is already complete, skipping it", w, season.SeasonID)
+			} else if err := c.CollectRaceWeek(ctx, season.SeasonID, w, false); err != nil && failure == nil {
+				failure = err
+			}
+			jobStep(ctx, "weeks")
+		}
+	}
+	log.Infof("backfill of series [%d] finished", seriesID)
+	return failure
+}
+
+// discoverSeasons finds past seasons of a series by its API series_id if known, by matching its regex against the season lists of each quarter otherwise
+func (c *Collector) discoverSeasons(ctx context.Context, series database.Series, fromYear, fromQuarter, toYear, toQuarter int) []api.Season {
+	from := fromYear*4 + fromQuarter - 1
+	to := toYear*4 + toQuarter - 1
+
+	seasons := make([]api.Season, 0)
+	if series.APISeriesID > 0 {
+		past, err := c.client.GetPastSeasons(ctx, series.APISeriesID)
+		if err != nil {
+			collectorError(ctx, "could not get past seasons of series [%d]: %v", series.APISeriesID, err)
+			return seasons
+		}
+		for _, season := range past {
+			if key := season.Year*4 + season.Quarter - 1; key >= from && key <= to {
+				seasons = append(seasons, season)
+			}
+		}
+		return seasons
+	}
+
+	namerx, err := regexp.Compile(series.SeriesRegex)
+	if err != nil {
+		collectorError(ctx, "invalid regex [%s] of series [%s]: %v", series.SeriesRegex, series.SeriesName, err)
+		return seasons
+	}
+	for key := from; key <= to && ctx.Err() == nil; key++ {
+		list, err := c.client.GetSeasonList(ctx, key/4, key%4+1)
+		if err != nil {
+			collectorError(ctx, "could not get season list of %dS%d: %v", key/4, key%4+1, err)
+			continue
+		}
+		for _, season := range list {
+			if namerx.MatchString(season.SeasonName) {
+				seasons = append(seasons, season)
+			}
+		}
+	}
+	return seasons
+}
+
+// seasonWeeks returns how many raceweeks a season has according to its max_weeks or schedule, 12 if neither is known.
+// It is capped at the 13 weeks CollectRaceWeek accepts.
+func seasonWeeks(season api.Season) int {
+	weeks := season.MaxWeeks
+	if len(season.Schedule) > weeks {
+		weeks = len(season.Schedule)
+	}
+	if weeks <= 0 {
+		return 12
+	}
+	if weeks > 13 {
+		return 13
+	}
+	return weeks
+}
+
+// raceWeekComplete checks if a raceweek has been collected after it was over
+func (c *Collector) raceWeekComplete(ctx context.Context, season api.Season, week int) bool {
+	raceweek, err := c.db.GetRaceWeekBySeasonIDAndWeek(ctx, season.SeasonID, week)
+	if err != nil || raceweek.RaceWeekID <= 0 {
+		return false
+	}
+	weekEnd := season.StartDate.AddDate(0, 0, 7*(week+1)+1)
+	return raceweek.LastUpdate.After(weekEnd)
+}
+
+// seasonStartDate estimates when a season started, based on 2018S1 which started on 2017-12-12 and 13 weeks per season
+func seasonStartDate(year, quarter int) time.Time {
+	iracingEpoch := time.Date(2017, 12, 12, 0, 0, 0, 0, time.UTC)
+	seasonsSince := (year-2018)*4 + quarter - 1
+	return iracingEpoch.AddDate(0, 0, 7*13*seasonsSince)
+}
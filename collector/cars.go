@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,13 +15,12 @@ var (
 	})
 )
 
-func (c *Collector) CollectCars() {
+func (c *Collector) CollectCars(ctx context.Context) {
 	log.Infof("collecting cars ...")
 
-	cars, err := c.client.GetCars()
+	cars, err := c.client.GetCars(ctx)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("%v", err)
+		collectorError(ctx, "%v", err)
 		return
 	}
 
@@ -42,9 +42,8 @@ func (c *Collector) CollectCars() {
 			Free:         car.Free,
 			Retired:      car.Retired,
 		}
-		if err := c.db.UpsertCar(cr); err != nil {
-			collectorErrors.Inc()
-			log.Errorf("could not store car [%s] in database: %v", car.Name, err)
+		if err := c.db.UpsertCar(ctx, cr); err != nil {
+			collectorError(ctx, "could not store car [%s] in database: %v", car.Name, err)
 			continue
 		}
 	}
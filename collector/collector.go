@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"time"
@@ -20,14 +21,19 @@ var (
 )
 
 type Collector struct {
-	client *api.Client
-	db     database.Database
+	client    *api.Client
+	db        database.Database
+	scheduler *scheduler
+	jobs      *jobs
+	discovery *DiscoveryCriteria // nil unless enabled
 }
 
-func New(db database.Database) *Collector {
+func New(db database.Database, opts ...api.Option) *Collector {
 	return &Collector{
-		client: api.New(),
-		db:     db,
+		client:    api.New(opts...),
+		db:        db,
+		scheduler: newScheduler(),
+		jobs:      newJobs(),
 	}
 }
 
@@ -35,113 +41,87 @@ func (c *Collector) Database() database.Database {
 	return c.db
 }
 
-func (c *Collector) Run() {
-	seasonrx := regexp.MustCompile(`20[1-5][0-9] Season [1-4]`) // "2019 Season 2"
+var seasonrx = regexp.MustCompile(`20[1-5][0-9] Season [1-4]`) // "2019 Season 2"
 
+func (c *Collector) Run(ctx context.Context) {
 	// update tracks
-	c.CollectTracks()
+	c.CollectTracks(ctx)
 
 	// update cars
-	c.CollectCars()
+	c.CollectCars(ctx)
 
 	forceUpdate := false
 	forceUpdateCounter := 0
 	for {
-		series, err := c.db.GetActiveSeries()
+		series, err := c.db.GetActiveSeries(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return // shutting down
+			}
 			log.Errorln("could not read series information from database")
 			log.Fatalf("%v", err)
 		}
 
 		// update tracks and cars only once in a while
 		if forceUpdate {
-			c.CollectTracks()
-			c.CollectCars()
+			c.CollectTracks(ctx)
+			c.CollectCars(ctx)
 		}
 
 		// fetch all current seasons and go through them
-		seasons, err := c.client.GetCurrentSeasons()
+		seasons, err := c.client.GetCurrentSeasons(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return // shutting down
+			}
 			log.Fatalf("%v", err)
 		}
 
 		if len(seasons) == 0 {
-			collectorErrors.Inc()
-			log.Errorf("no seasons found, couldn't get anything from iRacing!")
+			collectorError(ctx, "no seasons found, couldn't get anything from iRacing!")
+		}
+		if c.discovery != nil {
+			c.DiscoverSeries(ctx, *c.discovery, seasons)
 		}
+		current := make(map[int]bool)
 		for _, series := range series {
 			var found bool
-			namerx := regexp.MustCompile(series.SeriesRegex)
+			namerx, err := regexp.Compile(series.SeriesRegex)
+			if err != nil { // series are validated when managed through the API, but might still have been edited by hand
+				collectorError(ctx, "invalid regex [%s] of series [%s]: %v", series.SeriesRegex, series.SeriesName, err)
+				continue
+			}
 			for _, season := range seasons {
 				if namerx.MatchString(season.SeasonName) || season.SeriesID == series.APISeriesID { // does SeasonName match seriesRegex from db? or the API provided SeriesID?
 					log.Infof("Season: %s", season)
 					found = true
+					current[season.SeasonID] = true
+
+					s := c.upsertSeason(ctx, series, season)
 
-					// does it already exist in db?
-					s, err := c.db.GetSeasonByID(season.SeasonID)
+					// seasons with a known timeslot are collected by the scheduler once their sessions are over, all others are polled
+					length, err := c.db.GetAverageRaceLengthBySeasonID(ctx, season.SeasonID)
 					if err != nil {
-						log.Errorf("could not get season [%d] from database: %v", season.SeasonID, err)
+						collectorError(ctx, "could not get average race length of season [%d] from database: %v", season.SeasonID, err)
 					}
-					if err != nil || len(s.SeasonName) == 0 || len(s.Timeslots) == 0 || s.StartDate.Before(time.Now().AddDate(-1, -1, -1)) {
-						year := season.Year
-						quarter := season.Quarter
-						if year < 2018 || quarter < 1 { // figure out which season we are in incase API returns nonsense
-							if seasonrx.MatchString(season.SeasonNameShort) {
-								var err error
-								year, err = strconv.Atoi(season.SeasonNameShort[0:4])
-								if err != nil {
-									collectorErrors.Inc()
-									log.Errorf("could not convert SeasonNameShort [%s] to year: %v", season.SeasonNameShort, err)
-								}
-								quarter, err = strconv.Atoi(season.SeasonNameShort[12:13])
-								if err != nil {
-									collectorErrors.Inc()
-									log.Errorf("could not convert SeasonNameShort [%s] to quarter: %v", season.SeasonNameShort, err)
-								}
-							}
-							// if we couldn't figure out the season from SeasonNameShort, then we'll try to calculate it based on 2018S1 which started on 2017-12-12
-							if year < 2018 || quarter < 1 {
-								iracingEpoch := time.Date(2017, 12, 12, 0, 0, 0, 0, time.UTC)
-								daysSince := int(time.Since(iracingEpoch).Hours() / 24)
-								weeksSince := daysSince / 7
-								seasonsSince := int(weeksSince / 13)
-								yearsSince := int(seasonsSince / 4)
-								year = 2018 + yearsSince
-								quarter = (seasonsSince % 4) + 1
-							}
-						}
-
-						// startDate := database.WeekStart(time.Now().UTC().AddDate(0, 0, -7*season.RaceWeek))
-						log.Infof("Current season: %dS%d, started: %s", year, quarter, season.StartDate)
-
-						// upsert current season
-						s.SeriesID = series.SeriesID
-						s.SeasonID = season.SeasonID
-						s.Year = year
-						s.Quarter = quarter
-						s.Category = "-" // pointless since this can change each week / for each track
-						s.SeasonName = season.SeasonName
-						s.SeasonNameShort = season.SeasonNameShort
-						s.BannerImage = "-" // does not exist anymore in new API
-						s.PanelImage = "-"  // does not exist anymore in new API
-						s.LogoImage = "-"   // does not exist anymore in new API
-						s.StartDate = season.StartDate
-						if err := c.db.UpsertSeason(s); err != nil {
-							collectorErrors.Inc()
-							log.Errorf("could not store season [%s] in database: %v", season.SeasonName, err)
-						}
+					scheduled, added := c.scheduler.update(season.SeasonID, season.RaceWeek, s.Timeslots, length, time.Now())
+					if scheduled && !added && !forceUpdate {
+						continue
 					}
 
 					// insert current raceweek
-					c.CollectRaceWeek(season.SeasonID, season.RaceWeek, forceUpdate)
+					c.CollectRaceWeek(ctx, season.SeasonID, season.RaceWeek, forceUpdate)
 
 					// update previous week too
 					if season.RaceWeek > 0 {
-						c.CollectRaceWeek(season.SeasonID, season.RaceWeek-1, forceUpdate)
+						c.CollectRaceWeek(ctx, season.SeasonID, season.RaceWeek-1, forceUpdate)
 					} else {
 						// find previous season
-						ss, err := c.db.GetSeasonsBySeriesID(series.SeriesID)
+						ss, err := c.db.GetSeasonsBySeriesID(ctx, series.SeriesID)
 						if err != nil {
+							if ctx.Err() != nil {
+								return // shutting down
+							}
 							log.Errorln("could not read seasons from database")
 							log.Fatalf("%v", err)
 						}
@@ -153,7 +133,7 @@ func (c *Collector) Run() {
 								quarterToFind = 4
 							}
 							if s.Year == yearToFind && s.Quarter == quarterToFind { // previous season found
-								c.CollectRaceWeek(s.SeasonID, 11, forceUpdate)
+								c.CollectRaceWeek(ctx, s.SeasonID, 11, forceUpdate)
 								break
 							}
 						}
@@ -164,6 +144,9 @@ func (c *Collector) Run() {
 				log.Errorf("no seasons found for series [%s], couldn't match anything to regex [%s] or API series_id [%d]!", series.SeriesName, series.SeriesRegex, series.APISeriesID)
 			}
 		}
+		if len(seasons) > 0 {
+			c.scheduler.remove(current)
+		}
 
 		// check if we should forcibly update the whole raceweek / do a full snapshot
 		if forceUpdate {
@@ -175,53 +158,157 @@ func (c *Collector) Run() {
 			forceUpdate = true
 			forceUpdateCounter = 0
 		}
-		time.Sleep(15 * time.Minute)
+
+		// wait for the next poll, collecting the results of timeslot sessions as they finish in between
+		poll := time.After(pollInterval)
+		for waiting := true; waiting; {
+			wait := pollInterval
+			if next, ok := c.scheduler.next(); ok {
+				wait = time.Until(next)
+			}
+			select {
+			case <-ctx.Done():
+				log.Infoln("collector stopped")
+				return
+			case <-poll:
+				waiting = false
+			case <-time.After(wait):
+				c.collectDueTimeslots(ctx)
+			}
+		}
+	}
+}
+
+// collectDueTimeslots collects the raceweek of every season with a session that should have finished by now
+func (c *Collector) collectDueTimeslots(ctx context.Context) {
+	for _, t := range c.scheduler.due(time.Now()) {
+		if ctx.Err() != nil {
+			return // shutting down
+		}
+		log.Infof("session of season [%d] with timeslot [%s] should be over, collecting results ...", t.seasonID, t.crontab)
+		c.CollectRaceWeek(ctx, t.seasonID, t.week, false)
+
+		// the week rolled over since, the last session of the previous week might have just finished
+		if t.lastWeek != t.week {
+			c.CollectRaceWeek(ctx, t.seasonID, t.lastWeek, false)
+		}
 	}
 }
 
-func (c *Collector) CollectSeason(seasonID int) {
+// upsertSeason stores a season of a series, unless it is already known and complete
+func (c *Collector) upsertSeason(ctx context.Context, series database.Series, season api.Season) database.Season {
+	// does it already exist in db?
+	s, err := c.db.GetSeasonByID(ctx, season.SeasonID)
+	if err != nil {
+		log.Errorf("could not get season [%d] from database: %v", season.SeasonID, err)
+	}
+	known := err == nil && len(s.SeasonName) > 0
+	if err != nil || len(s.SeasonName) == 0 || len(s.Timeslots) == 0 || s.StartDate.Before(time.Now().AddDate(-1, -1, -1)) {
+		year := season.Year
+		quarter := season.Quarter
+		if year < 2018 || quarter < 1 { // figure out which season we are in incase API returns nonsense
+			if seasonrx.MatchString(season.SeasonNameShort) {
+				var err error
+				year, err = strconv.Atoi(season.SeasonNameShort[0:4])
+				if err != nil {
+					collectorError(ctx, "could not convert SeasonNameShort [%s] to year: %v", season.SeasonNameShort, err)
+				}
+				quarter, err = strconv.Atoi(season.SeasonNameShort[12:13])
+				if err != nil {
+					collectorError(ctx, "could not convert SeasonNameShort [%s] to quarter: %v", season.SeasonNameShort, err)
+				}
+			}
+			// if we couldn't figure out the season from SeasonNameShort, then we'll try to calculate it based on 2018S1 which started on 2017-12-12
+			if year < 2018 || quarter < 1 {
+				iracingEpoch := time.Date(2017, 12, 12, 0, 0, 0, 0, time.UTC)
+				daysSince := int(time.Since(iracingEpoch).Hours() / 24)
+				weeksSince := daysSince / 7
+				seasonsSince := int(weeksSince / 13)
+				yearsSince := int(seasonsSince / 4)
+				year = 2018 + yearsSince
+				quarter = (seasonsSince % 4) + 1
+			}
+		}
+
+		// startDate := database.WeekStart(time.Now().UTC().AddDate(0, 0, -7*season.RaceWeek))
+		log.Infof("Current season: %dS%d, started: %s", year, quarter, season.StartDate)
+
+		// upsert current season
+		s.SeriesID = series.SeriesID
+		s.SeasonID = season.SeasonID
+		s.Year = year
+		s.Quarter = quarter
+		s.Category = "-" // pointless since this can change each week / for each track
+		s.SeasonName = season.SeasonName
+		s.SeasonNameShort = season.SeasonNameShort
+		s.BannerImage = "-" // does not exist anymore in new API
+		s.PanelImage = "-"  // does not exist anymore in new API
+		s.LogoImage = "-"   // does not exist anymore in new API
+		s.StartDate = season.StartDate
+		if err := c.db.UpsertSeason(ctx, s); err != nil {
+			collectorError(ctx, "could not store season [%s] in database: %v", season.SeasonName, err)
+			return s
+		}
+	}
+	c.storeSchedule(ctx, season, !known)
+	return s
+}
+
+// CollectSeason collects all weeks of a season, it returns the error of the first week that could not be collected
+func (c *Collector) CollectSeason(ctx context.Context, seasonID int) error {
 	log.Infof("collecting whole season [%d], all 12 weeks ...", seasonID)
 
-	for w := 0; w < 12; w++ {
-		c.CollectRaceWeek(seasonID, w, true)
+	var failure error
+	jobTotal(ctx, "weeks", 12)
+	for w := 0; w < 12 && ctx.Err() == nil; w++ {
+		if err := c.CollectRaceWeek(ctx, seasonID, w, true); err != nil && failure == nil {
+			failure = err
+		}
+		jobStep(ctx, "weeks")
 	}
+	return failure
 }
 
-func (c *Collector) CollectSeasons() {
+// CollectSeasons collects all current seasons of the active series.
+// It only returns an error if the series or seasons could not be listed, errors of single seasons are just recorded.
+func (c *Collector) CollectSeasons(ctx context.Context) error {
 	log.Infof("collecting all current seasons ...")
 
-	series, err := c.db.GetActiveSeries()
+	series, err := c.db.GetActiveSeries(ctx)
 	if err != nil {
-		log.Errorln("could not read series information from database")
-		log.Fatalf("%v", err)
+		return jobError(ctx, "could not read series information from database: %v", err)
 	}
 
 	// fetch all current seasons and go through them
-	seasons, err := c.client.GetCurrentSeasons()
+	seasons, err := c.client.GetCurrentSeasons(ctx)
 	if err != nil {
-		log.Fatalf("%v", err)
+		return jobError(ctx, "could not get current seasons: %v", err)
 	}
 
 	if len(seasons) == 0 {
-		collectorErrors.Inc()
-		log.Errorf("no seasons found, couldn't get anything from iRacing!")
+		collectorError(ctx, "no seasons found, couldn't get anything from iRacing!")
 	}
 	for _, series := range series {
-		namerx := regexp.MustCompile(series.SeriesRegex)
+		namerx, err := regexp.Compile(series.SeriesRegex)
+		if err != nil {
+			collectorError(ctx, "invalid regex [%s] of series [%s]: %v", series.SeriesRegex, series.SeriesName, err)
+			continue
+		}
 		for _, season := range seasons {
 			if namerx.MatchString(season.SeasonName) || season.SeriesID == series.APISeriesID { // does SeasonName match seriesRegex from db? or the API provided SeriesID?
 				log.Infof("Season: %s", season)
 
 				// does it already exist in db?
-				if _, err := c.db.GetSeasonByID(season.SeasonID); err != nil {
+				if _, err := c.db.GetSeasonByID(ctx, season.SeasonID); err != nil {
 					log.Warnf("could not get season [%d] from database: %v", season.SeasonID, err)
 					log.Warnf("will skip that season ...")
 					continue
 				}
 
 				// collect it
-				c.CollectSeason(season.SeasonID)
+				c.CollectSeason(ctx, season.SeasonID)
 			}
 		}
 	}
+	return nil
 }
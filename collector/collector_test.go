@@ -0,0 +1,666 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/apitest"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollector(t *testing.T) (*Collector, *apitest.Server) {
+	t.Setenv("DB_URI", "sqlite://file::memory:")
+
+	server := apitest.NewServer()
+	t.Cleanup(server.Close)
+
+	adapter := database.NewAdapter()
+	t.Cleanup(func() { adapter.GetDatabase().Close() })
+	require.NoError(t, adapter.RunMigrations("../database/migrations"))
+
+	return New(database.NewDatabase(adapter), server.Options()...), server
+}
+
+// upsertTestSeason stores the season the apitest fixtures are about
+func upsertTestSeason(t *testing.T, c *Collector) {
+	ctx := context.Background()
+	series, err := c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	var seriesID int
+	for _, s := range series {
+		if s.SeriesName == "Radical Racing Challenge C" {
+			seriesID = s.SeriesID
+		}
+	}
+	require.NotZero(t, seriesID)
+	require.NoError(t, c.db.UpsertSeason(ctx, database.Season{
+		SeriesID:        seriesID,
+		SeasonID:        3492,
+		Year:            2022,
+		Quarter:         1,
+		Category:        "-",
+		SeasonName:      "Radical Racing Challenge - 2022 Season 1",
+		SeasonNameShort: "2022 Season 1",
+		StartDate:       time.Date(2021, 12, 14, 0, 0, 0, 0, time.UTC),
+	}))
+}
+
+func Test_Collector_RaceWeek(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestCollector(t)
+
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	track, err := c.db.GetTrackByID(ctx, 413)
+	require.NoError(t, err)
+	assert.Equal(t, "Hungaroring", track.Name)
+	car, err := c.db.GetCarByID(ctx, 13)
+	require.NoError(t, err)
+	assert.Equal(t, "SR8", car.Abbreviation)
+
+	upsertTestSeason(t, c)
+
+	c.CollectRaceWeek(ctx, 3492, 3, true)
+
+	results, err := c.db.GetRaceWeekResultsBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	// only official races have their results collected
+	assert.Equal(t, 1, server.Requests("/data/results/get"))
+
+	stats, err := c.db.GetRaceStatsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	assert.Equal(t, 14, stats.Laps)
+	assert.Equal(t, 22, stats.WeatherTemp)
+
+	raceResults, err := c.db.GetRaceResultsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	require.Len(t, raceResults, 3) // feature race and qualifying
+	assert.Equal(t, "Jack Example", raceResults[0].Driver.Name)
+	assert.Equal(t, 1745, raceResults[0].IRatingAfter)
+	assert.Equal(t, -1, raceResults[2].SimsessionNumber)
+	assert.Equal(t, 2, raceResults[2].LapsCompleted)
+
+	simsessions, err := c.db.GetSimsessionsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	require.Len(t, simsessions, 2)
+	assert.Equal(t, "RACE", simsessions[0].SimsessionName)
+	assert.Equal(t, "Lone Qualifying", simsessions[1].SimsessionTypeName)
+
+	summaries, err := c.db.GetDriverSummariesBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, summaries, 2) // qualifying must not count as another race
+	for _, summary := range summaries {
+		assert.Equal(t, 1, summary.NumberOfRaces)
+	}
+
+	laps, err := c.db.GetRaceLapsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	require.Len(t, laps, 8)
+	assert.Equal(t, 0, laps[0].Lap)
+	assert.Zero(t, laps[0].Laptime)
+	assert.Equal(t, "Jack Example", laps[0].Driver.Name)
+	var pitted, offTrack, incidents int
+	for _, lap := range laps {
+		if lap.Pitted {
+			pitted++
+		}
+		if lap.OffTrack {
+			offTrack++
+		}
+		if lap.Incident {
+			incidents++
+		}
+	}
+	assert.Equal(t, 1, pitted)
+	assert.Equal(t, 1, offTrack)
+	assert.Equal(t, 1, incidents)
+	assert.Equal(t, "Jack Example", laps[len(laps)-1].Driver.Name) // lost the lead on the last lap
+	assert.Equal(t, 2, laps[len(laps)-1].Position)
+
+	timeline, err := c.db.GetIncidentTimelineBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	require.Len(t, timeline, 3)
+	assert.Equal(t, "incident", timeline[0].EventType)
+	assert.Equal(t, 100, *timeline[0].DriverID)
+	assert.Equal(t, "caution", timeline[2].EventType)
+	assert.Equal(t, "Jean O'Neil", timeline[2].DriverName)
+	timeline, err = c.db.GetIncidentTimelineBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, timeline, 3)
+
+	pitStops, err := c.db.GetPitStopSummariesBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	require.Len(t, pitStops, 1)
+	assert.Equal(t, "Jack Example", pitStops[0].Driver.Name)
+	assert.Equal(t, 1, pitStops[0].PitStops)
+	assert.Equal(t, 3, pitStops[0].FirstPitLap)
+	pitStops, err = c.db.GetPitStopSummariesBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	require.Len(t, pitStops, 1)
+	assert.Equal(t, 1, pitStops[0].Races)
+	assert.Equal(t, 1, pitStops[0].PitStops)
+
+	rankings, err := c.db.GetTimeRankingsBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, rankings, 2)
+	ttResults, err := c.db.GetTimeTrialResultsBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, ttResults, 2)
+
+	driver, err := c.db.GetDriverByID(ctx, 102)
+	require.NoError(t, err)
+	assert.Equal(t, "Tim Trialist", driver.Name)
+}
+
+func Test_Collector_DatabaseTokenStore(t *testing.T) {
+	c, _ := newTestCollector(t)
+	store := NewDatabaseTokenStore(c.db)
+
+	token, err := store.LoadToken()
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveToken(api.StoredToken{
+		Token:                 api.Token{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"},
+		AccessTokenExpiresAt:  expiry,
+		RefreshTokenExpiresAt: expiry,
+	}))
+	token, err = store.LoadToken()
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.True(t, expiry.Equal(token.RefreshTokenExpiresAt))
+}
+
+func Test_Collector_Cancel(t *testing.T) {
+	c, server := newTestCollector(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.CollectSeason(ctx, 3492)
+	c.CollectRaceWeek(ctx, 3492, 3, true)
+	assert.Equal(t, 0, server.Requests("/data/results/season_results"))
+
+	// Run returns once cancelled, instead of looping forever
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector did not stop")
+	}
+}
+
+func Test_Collector_Replay(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestCollector(t)
+	archive := NewDatabaseArchive(c.db)
+	c.client = api.New(append(server.Options(), api.WithArchive(archive))...)
+
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	series, err := c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	seasons, err := c.client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	for _, s := range series {
+		for _, season := range seasons {
+			if regexp.MustCompile(s.SeriesRegex).MatchString(season.SeasonName) {
+				c.upsertSeason(ctx, s, season)
+			}
+		}
+	}
+	c.CollectRaceWeek(ctx, 3492, 3, true)
+	server.Close()
+
+	// rebuild a fresh database from the archived payloads only
+	t.Setenv("DB_URI", "sqlite://file:replay?mode=memory&cache=shared")
+	adapter := database.NewAdapter()
+	t.Cleanup(func() { adapter.GetDatabase().Close() })
+	require.NoError(t, adapter.RunMigrations("../database/migrations"))
+	replay := New(database.NewDatabase(adapter), append(server.Options(), api.WithReplay(archive))...)
+	replay.Replay(ctx, archive)
+
+	track, err := replay.db.GetTrackByID(ctx, 413)
+	require.NoError(t, err)
+	assert.Equal(t, "Hungaroring", track.Name)
+	season, err := replay.db.GetSeasonByID(ctx, 3492)
+	require.NoError(t, err)
+	assert.Equal(t, "Radical Racing Challenge - 2022 Season 1", season.SeasonName)
+	results, err := replay.db.GetRaceWeekResultsBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	raceResults, err := replay.db.GetRaceResultsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	assert.Len(t, raceResults, 3)
+	ttResults, err := replay.db.GetTimeTrialResultsBySeasonIDAndWeek(ctx, 3492, 3)
+	require.NoError(t, err)
+	assert.Len(t, ttResults, 2)
+}
+
+func Test_Collector_Scheduler(t *testing.T) {
+	s := newScheduler()
+	now := time.Date(2022, 1, 4, 13, 0, 0, 0, time.UTC)
+
+	// no timeslot detected yet, needs polling
+	scheduled, _ := s.update(3492, 3, "", 0, now)
+	assert.False(t, scheduled)
+	scheduled, _ = s.update(3492, 3, "not a crontab", 0, now)
+	assert.False(t, scheduled)
+	_, ok := s.next()
+	assert.False(t, ok)
+
+	// every 2 hours at 45 minutes past, 30 minute races
+	scheduled, added := s.update(3492, 3, "45 0-23/2 * * *", 30*time.Minute, now)
+	assert.True(t, scheduled)
+	assert.True(t, added)
+	_, added = s.update(3492, 3, "45 0-23/2 * * *", 30*time.Minute, now)
+	assert.False(t, added)
+
+	// the 12:45 session is still running, its results are expected 30 minutes race length plus grace period later
+	next, ok := s.next()
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2022, 1, 4, 12, 45, 0, 0, time.UTC).Add(30*time.Minute+resultsGracePeriod), next)
+	assert.Empty(t, s.due(now))
+
+	due := s.due(next)
+	require.Len(t, due, 1)
+	assert.Equal(t, 3492, due[0].seasonID)
+	assert.Equal(t, 3, due[0].week)
+	next, _ = s.next()
+	assert.Equal(t, time.Date(2022, 1, 4, 14, 45, 0, 0, time.UTC).Add(30*time.Minute+resultsGracePeriod), next)
+
+	// raceweek rolls over, the previous week gets collected once more
+	s.update(3492, 4, "45 0-23/2 * * *", 30*time.Minute, next)
+	due = s.due(next)
+	require.Len(t, due, 1)
+	assert.Equal(t, 4, due[0].week)
+	assert.Equal(t, 3, due[0].lastWeek)
+	due = s.due(next.Add(2 * time.Hour))
+	require.Len(t, due, 1)
+	assert.Equal(t, 4, due[0].lastWeek)
+
+	// seasons that are over are dropped
+	s.remove(map[int]bool{})
+	_, ok = s.next()
+	assert.False(t, ok)
+}
+
+func Test_Collector_Jobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, _ := newTestCollector(t)
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	upsertTestSeason(t, c)
+
+	seasonID, week := 3492, 3
+	job, err := c.EnqueueJob(ctx, JobWeek, &seasonID, &week)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", job.Status)
+	assert.Equal(t, "subsessions", job.Unit)
+
+	// identical pending jobs are not queued twice
+	again, err := c.EnqueueJob(ctx, JobWeek, &seasonID, &week)
+	require.NoError(t, err)
+	assert.Equal(t, job.JobID, again.JobID)
+	invalid := 13
+	failing, err := c.EnqueueJob(ctx, JobWeek, &seasonID, &invalid)
+	require.NoError(t, err)
+	assert.NotEqual(t, job.JobID, failing.JobID)
+	_, err = c.EnqueueJob(ctx, "unknown", nil, nil)
+	assert.True(t, errors.Is(err, ErrUnknownJob))
+
+	done := make(chan struct{})
+	go func() {
+		c.RunJobs(ctx)
+		close(done)
+	}()
+	waitForJob := func(id int, status string) database.Job {
+		for i := 0; i < 100; i++ {
+			j, err := c.db.GetJobByID(ctx, id)
+			require.NoError(t, err)
+			if j.Status == status {
+				return j
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("job [%d] did not reach status [%s]", id, status)
+		return database.Job{}
+	}
+
+	job = waitForJob(job.JobID, "done")
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, 1, job.Total) // one official race
+	assert.Equal(t, 1, job.Done)
+	assert.Empty(t, job.Errors)
+	require.NotNil(t, job.FinishedAt)
+
+	// failed jobs are retried later
+	failing = waitForJob(failing.JobID, "pending")
+	for failing.Attempts == 0 {
+		failing = waitForJob(failing.JobID, "pending")
+	}
+	assert.Equal(t, 1, failing.Attempts)
+	assert.Equal(t, []string{"week [13] is invalid"}, []string(failing.Errors))
+	assert.True(t, failing.ScheduledAt.After(time.Now()))
+
+	// pending jobs get cancelled, finished ones deleted
+	failing, err = c.CancelJob(ctx, failing.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", failing.Status)
+	_, err = c.CancelJob(ctx, job.JobID)
+	require.NoError(t, err)
+	jobs, err := c.db.GetJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, failing.JobID, jobs[0].JobID)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job workers did not stop")
+	}
+
+	// jobs cancelled right after being claimed are not marked as done afterwards
+	ctx = context.Background()
+	queued, err := c.EnqueueJob(ctx, JobWeek, &seasonID, &week)
+	require.NoError(t, err)
+	claimed, err := c.db.ClaimJob(ctx)
+	require.NoError(t, err)
+	require.Equal(t, queued.JobID, claimed.JobID)
+	_, err = c.CancelJob(ctx, claimed.JobID)
+	require.NoError(t, err)
+	c.runJob(ctx, claimed)
+	claimed, err = c.db.GetJobByID(ctx, claimed.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", claimed.Status)
+}
+
+func Test_Collector_JobErrors(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestCollector(t)
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	upsertTestSeason(t, c)
+
+	// failing to list the current seasons fails the job instead of exiting
+	server.FailNext("/data/series/seasons", http.StatusNotFound, 1)
+	assert.Error(t, c.CollectSeasons(ctx))
+
+	// a single subsession that can't be collected is kept as a partial result
+	seasonID, week := 3492, 3
+	_, err := c.EnqueueJob(ctx, JobWeek, &seasonID, &week)
+	require.NoError(t, err)
+	job, err := c.db.ClaimJob(ctx)
+	require.NoError(t, err)
+	server.FailNext("/data/results/get", http.StatusNotFound, 1)
+	c.runJob(ctx, job)
+	job, err = c.db.GetJobByID(ctx, job.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, "done", job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Len(t, job.Errors, 1)
+}
+
+func Test_Collector_Backfill(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestCollector(t)
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	seasonResults, err := os.ReadFile("../apitest/fixtures/season_results.json")
+	require.NoError(t, err)
+	server.SetFixture("/data/results/season_results", []byte(`{"results_list": []}`))
+	server.SetFixture("/data/results/season_results?season_id=3350&event_type=5&race_week_num=3", seasonResults)
+	server.SetFixture("/data/season/list?season_year=2021&season_quarter=3", []byte(`{"season_year": 2021, "season_quarter": 3, "seasons": [
+		{"season_id": 3234, "series_id": 74, "season_name": "Radical Racing Challenge - 2021 Season 3", "season_year": 2021, "season_quarter": 3, "max_weeks": 13}
+	]}`))
+
+	// the series has no API series_id, seasons are found by matching its regex
+	series, err := c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	var seriesID int
+	for _, s := range series {
+		if s.SeriesName == "Radical Racing Challenge C" {
+			seriesID = s.SeriesID
+		}
+	}
+	require.NotZero(t, seriesID)
+
+	c.Backfill(ctx, seriesID, 2021, 3, 2021, 4)
+	assert.Equal(t, 2, server.Requests("/data/season/list"))
+	assert.Equal(t, 13+12, server.Requests("/data/results/season_results")) // 2021S3 had 13 weeks
+
+	season, err := c.db.GetSeasonByID(ctx, 3350)
+	require.NoError(t, err)
+	assert.Equal(t, 2021, season.Year)
+	assert.Equal(t, 4, season.Quarter)
+	assert.Equal(t, seasonStartDate(2021, 4), season.StartDate.UTC())
+	_, err = c.db.GetSeasonByID(ctx, 3234)
+	require.NoError(t, err)
+	results, err := c.db.GetRaceWeekResultsBySeasonIDAndWeek(ctx, 3350, 3)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// resuming skips the weeks that are complete already
+	c.Backfill(ctx, seriesID, 2021, 3, 2021, 4)
+	assert.Equal(t, 25+24, server.Requests("/data/results/season_results"))
+}
+
+func Test_Collector_DriverEnrichment(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestCollector(t)
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+	upsertTestSeason(t, c)
+	c.CollectRaceWeek(ctx, 3492, 3, false)
+
+	profile, err := c.db.GetDriverProfileByDriverID(ctx, 100)
+	require.NoError(t, err)
+	assert.Nil(t, profile.RefreshedAt)
+	assert.Empty(t, profile.Licenses)
+
+	job, err := c.EnqueueDriverEnrichment(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "drivers", job.Unit)
+	ids, err := c.db.GetDriverIDsToEnrich(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	require.NotEmpty(t, ids)
+
+	c.EnrichDrivers(ctx, 24*time.Hour)
+	assert.Equal(t, 1, server.Requests("/data/member/get"))
+	assert.Equal(t, 2, server.Requests("/data/stats/member_career"))
+
+	profile, err = c.db.GetDriverProfileByDriverID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Jack Example", profile.Driver.Name)
+	require.NotNil(t, profile.RefreshedAt)
+	require.NotNil(t, profile.MemberSince)
+	assert.Equal(t, 2015, profile.MemberSince.Year())
+	require.Len(t, profile.Licenses, 2)
+	assert.Equal(t, "road", profile.Licenses[1].Category)
+	assert.Equal(t, 1745, profile.Licenses[1].IRating)
+	assert.Equal(t, 3.87, profile.Licenses[1].SafetyRating)
+	require.Len(t, profile.CareerStats, 2)
+	assert.Equal(t, 27, profile.CareerStats[1].Wins)
+
+	// fresh profiles are left alone
+	ids, err = c.db.GetDriverIDsToEnrich(ctx, time.Now().UTC().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.NotContains(t, ids, 100)
+	assert.NotContains(t, ids, 101)
+	ids, err = c.db.GetDriverIDsToEnrich(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Contains(t, ids, 100)
+}
+
+func Test_Collector_SaveSeries(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCollector(t)
+
+	series := database.Series{
+		SeriesName: "Radical Esports Cup", SeriesNameShort: "Radical Esports",
+		SeriesRegex: "Radical Esports", ColorScheme: "radical", Active: "true", APISeriesID: 1,
+	}
+	saved, err := c.SaveSeries(ctx, series)
+	require.NoError(t, err)
+	assert.NotZero(t, saved.SeriesID)
+	assert.Equal(t, "true", saved.Active)
+
+	// the run loop picks up whatever is active the next time around
+	active, err := c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, active, 5)
+
+	saved.SeriesRegex = "Radical ("
+	_, err = c.SaveSeries(ctx, saved)
+	assert.True(t, errors.Is(err, ErrInvalidSeries))
+	saved.SeriesRegex = "Radical Esports"
+	saved.APISeriesID = 999
+	_, err = c.SaveSeries(ctx, saved)
+	assert.True(t, errors.Is(err, ErrInvalidSeries))
+	saved.Active = "false" // inactive series don't need a current season
+	saved, err = c.SaveSeries(ctx, saved)
+	require.NoError(t, err)
+	assert.Equal(t, 999, saved.APISeriesID)
+
+	active, err = c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, active, 4)
+
+	// active series without a current season can still be edited, as long as API series_id and active stay the same
+	saved.Active = "true"
+	saved, err = c.db.UpsertSeries(ctx, saved)
+	require.NoError(t, err)
+	saved.SeriesName = "Radical Esports Cup 2"
+	saved.ColorScheme = "radical2"
+	saved, err = c.SaveSeries(ctx, saved)
+	require.NoError(t, err)
+	assert.Equal(t, "Radical Esports Cup 2", saved.SeriesName)
+	saved.APISeriesID = 998
+	_, err = c.SaveSeries(ctx, saved)
+	assert.Error(t, err)
+	saved.APISeriesID = 999
+	saved.Active = "false"
+	saved, err = c.db.UpsertSeries(ctx, saved)
+	require.NoError(t, err)
+	saved.Active = "true"
+	_, err = c.SaveSeries(ctx, saved)
+	assert.Error(t, err)
+
+	// series edited by hand with a broken regex are skipped instead of taking down a job
+	broken, err := c.db.UpsertSeries(ctx, database.Series{
+		SeriesName: "Broken", SeriesNameShort: "Broken", SeriesRegex: "Broken (", ColorScheme: "broken", Active: "true",
+	})
+	require.NoError(t, err)
+	assert.NotPanics(t, func() { c.CollectSeasons(ctx) })
+	assert.NotPanics(t, func() { c.Backfill(ctx, broken.SeriesID, 2021, 3, 2021, 3) })
+}
+
+func Test_Collector_Discovery(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCollector(t)
+
+	seasons, err := c.client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	require.Len(t, seasons, 2)
+
+	official, fixed := true, true
+	assert.True(t, DiscoveryCriteria{}.Matches(seasons[1]))
+	assert.True(t, DiscoveryCriteria{Official: &official, TrackTypes: []string{"road"}, CarClasses: []int{1, 2}}.Matches(seasons[1]))
+	assert.False(t, DiscoveryCriteria{FixedSetup: &fixed}.Matches(seasons[1]))
+	assert.False(t, DiscoveryCriteria{LicenseGroups: []int{3, 4, 5}}.Matches(seasons[1]))
+	assert.False(t, DiscoveryCriteria{TrackTypes: []string{"oval"}}.Matches(seasons[1]))
+	assert.False(t, DiscoveryCriteria{CarClasses: []int{74}}.Matches(seasons[1]))
+
+	// license categories come from the schedule, or from the track types if there is no schedule
+	assert.True(t, DiscoveryCriteria{Categories: []string{"road"}}.Matches(seasons[0]))
+	assert.True(t, DiscoveryCriteria{Categories: []string{"Road"}}.Matches(seasons[1]))
+	assert.False(t, DiscoveryCriteria{Categories: []string{"oval", "dirt_oval"}}.Matches(seasons[0]))
+	assert.False(t, DiscoveryCriteria{Categories: []string{"oval"}}.Matches(seasons[1]))
+	dirt := seasons[0]
+	dirt.Schedule = append(dirt.Schedule[:0:0], dirt.Schedule...)
+	dirt.Schedule[0].Track.Category = "Dirt Oval"
+	assert.True(t, DiscoveryCriteria{Categories: []string{"dirt_oval"}}.Matches(dirt))
+	assert.False(t, DiscoveryCriteria{Categories: []string{"road"}}.Matches(dirt))
+
+	// the radical season belongs to a known series already, skip barber is new
+	c.EnableDiscovery(DiscoveryCriteria{Official: &official})
+	c.DiscoverSeries(ctx, *c.discovery, seasons)
+	c.DiscoverSeries(ctx, *c.discovery, seasons)
+	series, err := c.db.GetSeries(ctx)
+	require.NoError(t, err)
+	require.Len(t, series, 5)
+	var discovered database.Series
+	for _, s := range series {
+		if s.Pending {
+			discovered = s
+		}
+	}
+	assert.Equal(t, "Skip Barber Race Series", discovered.SeriesName)
+	assert.Equal(t, 1, discovered.APISeriesID)
+	assert.Equal(t, "false", discovered.Active)
+
+	// pending series are not collected until approved
+	active, err := c.db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, active, 4)
+
+	// rejected series are not discovered again either
+	require.NoError(t, c.db.DeactivateSeries(ctx, discovered.SeriesID))
+	c.DiscoverSeries(ctx, *c.discovery, seasons)
+	series, err = c.db.GetSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, series, 5)
+}
+
+func Test_Collector_Schedule(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCollector(t)
+
+	seasons, err := c.client.GetCurrentSeasons(ctx)
+	require.NoError(t, err)
+	radical := seasons[0]
+	require.Equal(t, 3492, radical.SeasonID)
+	series, err := c.db.GetSeriesByID(ctx, 1)
+	require.NoError(t, err)
+
+	// the schedule is stored as soon as the season is first seen, before any race has run
+	c.upsertSeason(ctx, series, radical)
+	schedule, err := c.db.GetSeasonScheduleBySeasonID(ctx, 3492)
+	require.NoError(t, err)
+	assert.Equal(t, []int{74}, schedule.CarClassIDs)
+	assert.Equal(t, 4, schedule.DropWeeks)
+	assert.Equal(t, 12, schedule.MaxWeeks)
+	require.Len(t, schedule.Weeks, 1)
+	assert.Equal(t, 3, schedule.Weeks[0].RaceWeek)
+	assert.Equal(t, 413, schedule.Weeks[0].TrackID)
+	assert.Equal(t, "Hungaroring", schedule.Weeks[0].TrackName)
+	assert.Equal(t, 25, schedule.Weeks[0].RaceTime)
+	assert.Zero(t, schedule.Weeks[0].RaceLaps)
+	assert.True(t, time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC).Equal(schedule.Weeks[0].StartDate))
+	raceweeks, err := c.db.GetRaceWeekMetricsBySeasonID(ctx, 3492)
+	require.NoError(t, err)
+	assert.Empty(t, raceweeks)
+
+	// known seasons keep their schedule
+	radical.Schedule[0].RaceTime = 40
+	c.upsertSeason(ctx, series, radical)
+	schedule, err = c.db.GetSeasonScheduleBySeasonID(ctx, 3492)
+	require.NoError(t, err)
+	assert.Equal(t, 25, schedule.Weeks[0].RaceTime)
+}
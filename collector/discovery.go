@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// DiscoveryCriteria decide which current seasons of unknown series are registered for approval, empty fields match anything
+type DiscoveryCriteria struct {
+	Categories    []string // license categories, i.e. road, oval, dirt_road, dirt_oval
+	LicenseGroups []int    // 1 rookie, 2 D, 3 C, 4 B, 5 A
+	Official      *bool    // nil matches official and unofficial series
+	FixedSetup    *bool    // nil matches fixed and open setup series
+	CarClasses    []int    // the season has to use at least one of these car classes
+	TrackTypes    []string // the season has to race on at least one of these track types, i.e. road, oval, dirt_road, dirt_oval
+}
+
+var seasonNameRx = regexp.MustCompile(`^(.+?)\s+-\s+\d{4}\s+Season\s+\d+`)
+
+// Matches tells whether a season fulfills all criteria
+func (d DiscoveryCriteria) Matches(season api.Season) bool {
+	if d.Official != nil && season.Official != *d.Official {
+		return false
+	}
+	if d.FixedSetup != nil && season.FixedSetup != *d.FixedSetup {
+		return false
+	}
+	if len(d.Categories) > 0 {
+		found := false
+		for _, category := range seasonCategories(season) {
+			for _, c := range d.Categories {
+				found = found || normalizeCategory(c) == category
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(d.LicenseGroups) > 0 && !containsInt(d.LicenseGroups, season.LicenseGroup) {
+		return false
+	}
+	if len(d.CarClasses) > 0 {
+		found := false
+		for _, class := range season.CarClasses {
+			found = found || containsInt(d.CarClasses, class)
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(d.TrackTypes) > 0 {
+		found := false
+		for _, trackType := range season.TrackTypes {
+			for _, t := range d.TrackTypes {
+				found = found || t == trackType.TrackType
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// seasonCategories returns the license categories a season races in, taken from the tracks of its schedule.
+// Seasons without a schedule fall back to their track types, which are named after the license categories as well.
+func seasonCategories(season api.Season) []string {
+	categories := make([]string, 0)
+	for _, week := range season.Schedule {
+		if len(week.Track.Category) > 0 {
+			categories = append(categories, normalizeCategory(week.Track.Category))
+		}
+	}
+	if len(categories) == 0 {
+		for _, trackType := range season.TrackTypes {
+			categories = append(categories, normalizeCategory(trackType.TrackType))
+		}
+	}
+	return categories
+}
+
+// normalizeCategory turns "Dirt Oval" into "dirt_oval"
+func normalizeCategory(category string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(category)), " ", "_")
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableDiscovery makes the Run loop register every unknown series with a current season matching the criteria
+func (c *Collector) EnableDiscovery(criteria DiscoveryCriteria) {
+	c.discovery = &criteria
+}
+
+// DiscoverSeries registers the series of all current seasons that match the criteria and are not known yet,
+// as inactive and pending until approved through PUT /series/{seriesID}?active=true
+func (c *Collector) DiscoverSeries(ctx context.Context, criteria DiscoveryCriteria, seasons []api.Season) {
+	known, err := c.db.GetSeries(ctx)
+	if err != nil {
+		collectorError(ctx, "could not read series from database: %v", err)
+		return
+	}
+
+	for _, season := range seasons {
+		if !criteria.Matches(season) || isKnownSeries(known, season) {
+			continue
+		}
+
+		name := season.SeasonName
+		if m := seasonNameRx.FindStringSubmatch(season.SeasonName); len(m) > 1 {
+			name = m[1]
+		}
+		series, err := c.db.UpsertSeries(ctx, database.Series{
+			SeriesName:      name,
+			SeriesNameShort: name,
+			SeriesRegex:     regexp.QuoteMeta(name),
+			Active:          "false",
+			Pending:         true,
+			APISeriesID:     season.SeriesID,
+		})
+		if err != nil {
+			collectorError(ctx, "could not register discovered series [%s]: %v", name, err)
+			continue
+		}
+		log.Infof("discovered series [%s], waiting for approval", series.SeriesName)
+		known = append(known, series)
+	}
+}
+
+// isKnownSeries tells whether the season belongs to any series in the database, including inactive and pending ones
+func isKnownSeries(known []database.Series, season api.Season) bool {
+	for _, series := range known {
+		if series.APISeriesID == season.SeriesID {
+			return true
+		}
+		if rx, err := regexp.Compile(series.SeriesRegex); err == nil && rx.MatchString(season.SeasonName) {
+			return true
+		}
+	}
+	return false
+}
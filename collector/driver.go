@@ -1,18 +1,20 @@
 package collector
 
 import (
+	"context"
+	"time"
+
 	"github.com/JamesClonk/iRcollector/database"
-	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) UpsertDriverAndClub(driverName, clubName string, driverID, clubID int) (database.Driver, bool) {
+// UpsertDriverAndClub stores a driver and its club, seen is when the driver raced under that name
+func (c *Collector) UpsertDriverAndClub(ctx context.Context, driverName, clubName string, driverID, clubID int, seen time.Time) (database.Driver, bool) {
 	club := database.Club{
 		ClubID: clubID,
 		Name:   clubName,
 	}
-	if err := c.db.UpsertClub(club); err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not store club [%v] in database: %v", club, err)
+	if err := c.db.UpsertClub(ctx, club); err != nil {
+		collectorError(ctx, "could not store club [%v] in database: %v", club, err)
 		return database.Driver{}, false
 	}
 	driver := database.Driver{
@@ -20,9 +22,8 @@ func (c *Collector) UpsertDriverAndClub(driverName, clubName string, driverID, c
 		Name:     driverName,
 		Club:     club,
 	}
-	if err := c.db.UpsertDriver(driver); err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not store driver [%v] in database: %v", driver, err)
+	if err := c.db.UpsertDriver(ctx, driver, seen); err != nil {
+		collectorError(ctx, "could not store driver [%v] in database: %v", driver, err)
 		return database.Driver{}, false
 	}
 	return driver, true
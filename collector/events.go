@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// CollectRaceEvents stores the event log of a race, drivers must already have been stored through the race results
+func (c *Collector) CollectRaceEvents(ctx context.Context, subsessionID int, drivers map[int]database.Driver) {
+	log.Infof("collecting event log for subsession [%d]...", subsessionID)
+
+	events, err := c.client.GetEventLog(ctx, subsessionID)
+	if err != nil {
+		collectorError(ctx, "could not get event log [subsessionID:%d]: %v", subsessionID, err)
+		return
+	}
+
+	raceEvents := make([]database.RaceEvent, 0, len(events))
+	for _, event := range events {
+		raceEvent := database.RaceEvent{
+			SubsessionID:     subsessionID,
+			SimsessionNumber: event.SimsessionNumber,
+			EventSeq:         event.EventSeq,
+			Lap:              event.LapNumber,
+			SessionTime:      event.SessionTime,
+			DriverName:       event.DriverName,
+			EventCode:        event.EventCode,
+			EventType:        event.Type(),
+			Description:      event.Description,
+			Message:          event.Message,
+		}
+		if driver, ok := drivers[event.DriverID]; ok { // events without a driver or of team entries keep only the name
+			raceEvent.DriverID = &driver.DriverID
+		}
+		raceEvents = append(raceEvents, raceEvent)
+	}
+	if err := c.db.UpsertRaceEvents(ctx, raceEvents); err != nil {
+		collectorError(ctx, "could not store event log [subsessionID:%d] in database: %v", subsessionID, err)
+		return
+	}
+	log.Debugf("Race events: %d", len(raceEvents))
+}
@@ -0,0 +1,314 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+const (
+	JobSeasons  = "seasons"
+	JobSeason   = "season"
+	JobWeek     = "week"
+	JobBackfill = "backfill"
+	JobDrivers  = "drivers"
+
+	jobWorkers     = 2
+	maxJobAttempts = 3
+)
+
+var ErrUnknownJob = errors.New("unknown job kind")
+
+// jobs keeps track of the jobs currently worked on, so they can be cancelled
+type jobs struct {
+	mutex   sync.Mutex
+	running map[int]context.CancelFunc
+	wake    chan struct{}
+}
+
+func newJobs() *jobs {
+	return &jobs{
+		running: make(map[int]context.CancelFunc),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// jobTracker records progress and errors of a running job
+type jobTracker struct {
+	mutex sync.Mutex
+	db    database.Database
+	job   database.Job
+}
+
+type jobTrackerKey struct{}
+
+func trackerFrom(ctx context.Context) *jobTracker {
+	t, _ := ctx.Value(jobTrackerKey{}).(*jobTracker)
+	return t
+}
+
+// jobTotal adds to the amount of work a job has to do, if the job counts its progress in unit
+func jobTotal(ctx context.Context, unit string, n int) {
+	if t := trackerFrom(ctx); t != nil && t.job.Unit == unit {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.job.Total += n
+		t.save(ctx)
+	}
+}
+
+// jobStep marks one unit of work of a job as done
+func jobStep(ctx context.Context, unit string) {
+	if t := trackerFrom(ctx); t != nil && t.job.Unit == unit {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.job.Done++
+		t.save(ctx)
+	}
+}
+
+func (t *jobTracker) save(ctx context.Context) {
+	if err := t.db.UpdateJob(ctx, t.job); err != nil && ctx.Err() == nil {
+		log.Errorf("could not update job [%d] in database: %v", t.job.JobID, err)
+	}
+}
+
+// collectorError logs and counts an error, and records it on the job it happened in
+func collectorError(ctx context.Context, format string, args ...interface{}) {
+	collectorErrors.Inc()
+	log.Errorf(format, args...)
+	if t := trackerFrom(ctx); t != nil {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.job.Errors = append(t.job.Errors, strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " "))
+		t.save(ctx)
+	}
+}
+
+// jobError records an error that fails the job it happened in, so the job gets retried.
+// Errors of single subsessions or drivers only go through collectorError and are kept on the job as partial results.
+func jobError(ctx context.Context, format string, args ...interface{}) error {
+	collectorError(ctx, format, args...)
+	return fmt.Errorf(format, args...)
+}
+
+// EnqueueJob queues a collection job, identical jobs that are still pending or running are not queued twice
+func (c *Collector) EnqueueJob(ctx context.Context, kind string, seasonID, week *int) (database.Job, error) {
+	return c.enqueue(ctx, database.Job{
+		Kind:     kind,
+		SeasonID: seasonID,
+		Week:     week,
+	})
+}
+
+// EnqueueBackfill queues a backfill of all seasons of a series between two year/quarter pairs
+func (c *Collector) EnqueueBackfill(ctx context.Context, seriesID, fromYear, fromQuarter, toYear, toQuarter int) (database.Job, error) {
+	params := url.Values{}
+	params.Set("series_id", strconv.Itoa(seriesID))
+	params.Set("from_year", strconv.Itoa(fromYear))
+	params.Set("from_quarter", strconv.Itoa(fromQuarter))
+	params.Set("to_year", strconv.Itoa(toYear))
+	params.Set("to_quarter", strconv.Itoa(toQuarter))
+	return c.enqueue(ctx, database.Job{
+		Kind:   JobBackfill,
+		Params: params.Encode(),
+	})
+}
+
+// EnqueueDriverEnrichment queues a refresh of all driver profiles older than maxAge
+func (c *Collector) EnqueueDriverEnrichment(ctx context.Context, maxAge time.Duration) (database.Job, error) {
+	params := url.Values{}
+	params.Set("max_age", maxAge.String())
+	return c.enqueue(ctx, database.Job{
+		Kind:   JobDrivers,
+		Params: params.Encode(),
+	})
+}
+
+func (c *Collector) enqueue(ctx context.Context, job database.Job) (database.Job, error) {
+	job.Status = "pending"
+	switch job.Kind {
+	case JobSeasons, JobSeason, JobBackfill:
+		job.Unit = "weeks"
+	case JobWeek:
+		job.Unit = "subsessions"
+	case JobDrivers:
+		job.Unit = "drivers"
+	default:
+		return database.Job{}, fmt.Errorf("%w: %s", ErrUnknownJob, job.Kind)
+	}
+
+	job, err := c.db.InsertJob(ctx, job)
+	if err != nil {
+		return job, err
+	}
+	select {
+	case c.jobs.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// CancelJob stops a pending or running job, finished jobs are deleted
+func (c *Collector) CancelJob(ctx context.Context, id int) (database.Job, error) {
+	job, err := c.db.GetJobByID(ctx, id)
+	if err != nil {
+		return job, err
+	}
+
+	switch job.Status {
+	case "pending", "running":
+		// a worker might claim the job right now, it does not overwrite the cancelled status once stored
+		now := time.Now().UTC()
+		job.Status = "cancelled"
+		job.FinishedAt = &now
+		if err := c.db.UpdateJob(ctx, job); err != nil {
+			return job, err
+		}
+
+		c.jobs.mutex.Lock()
+		if cancel, ok := c.jobs.running[id]; ok {
+			cancel()
+		}
+		c.jobs.mutex.Unlock()
+		return job, nil
+	default:
+		return job, c.db.DeleteJob(ctx, id)
+	}
+}
+
+// RunJobs works through the job queue until ctx is done, jobs interrupted by a previous shutdown are picked up again
+func (c *Collector) RunJobs(ctx context.Context) {
+	if err := c.db.ResetRunningJobs(ctx); err != nil {
+		collectorError(ctx, "could not reset interrupted jobs: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				job, err := c.db.ClaimJob(ctx)
+				if err != nil {
+					if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+						collectorError(ctx, "could not get next job from database: %v", err)
+					}
+					select {
+					case <-ctx.Done():
+					case <-c.jobs.wake:
+					case <-time.After(time.Minute):
+					}
+					continue
+				}
+				c.runJob(ctx, job)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Collector) runJob(ctx context.Context, job database.Job) {
+	log.Infof("running job %s ...", job)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.jobs.mutex.Lock()
+	c.jobs.running[job.JobID] = cancel
+	c.jobs.mutex.Unlock()
+	defer func() {
+		c.jobs.mutex.Lock()
+		delete(c.jobs.running, job.JobID)
+		c.jobs.mutex.Unlock()
+	}()
+
+	// every attempt starts from scratch
+	job.Done = 0
+	job.Total = 0
+	job.Errors = database.JobErrors{}
+	tracker := &jobTracker{db: c.db, job: job}
+	jobCtx = context.WithValue(jobCtx, jobTrackerKey{}, tracker)
+
+	var failure error
+	switch {
+	case job.Kind == JobSeasons:
+		failure = c.CollectSeasons(jobCtx)
+	case job.Kind == JobSeason && job.SeasonID != nil:
+		failure = c.CollectSeason(jobCtx, *job.SeasonID)
+	case job.Kind == JobWeek && job.SeasonID != nil && job.Week != nil:
+		failure = c.CollectRaceWeek(jobCtx, *job.SeasonID, *job.Week, true)
+	case job.Kind == JobBackfill:
+		params, err := url.ParseQuery(job.Params)
+		if err != nil {
+			failure = jobError(jobCtx, "invalid job parameters [%s]: %v", job.Params, err)
+			break
+		}
+		param := func(key string) int {
+			value, _ := strconv.Atoi(params.Get(key))
+			return value
+		}
+		failure = c.Backfill(jobCtx, param("series_id"), param("from_year"), param("from_quarter"), param("to_year"), param("to_quarter"))
+	case job.Kind == JobDrivers:
+		params, err := url.ParseQuery(job.Params)
+		if err != nil {
+			failure = jobError(jobCtx, "invalid job parameters [%s]: %v", job.Params, err)
+			break
+		}
+		maxAge, err := time.ParseDuration(params.Get("max_age"))
+		if err != nil {
+			failure = jobError(jobCtx, "invalid job parameters [%s]: %v", job.Params, err)
+			break
+		}
+		c.EnrichDrivers(jobCtx, maxAge)
+	default:
+		failure = jobError(jobCtx, "invalid job %s", job)
+	}
+
+	if ctx.Err() != nil {
+		return // shutting down, the job gets picked up again on the next start
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	job = tracker.job
+
+	// the job might have been cancelled right after it was claimed, before it could be stopped
+	current, err := c.db.GetJobByID(ctx, job.JobID)
+	if err != nil {
+		collectorError(ctx, "could not get job [%d] from database: %v", job.JobID, err)
+		return
+	}
+	if current.Status == "cancelled" {
+		log.Infof("cancelled job %s", current)
+		return
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case jobCtx.Err() != nil:
+		job.Status = "cancelled"
+		job.FinishedAt = &now
+	case failure == nil: // errors of single subsessions or drivers stay on the job as partial results
+		job.Status = "done"
+		job.FinishedAt = &now
+	case job.Attempts < maxJobAttempts:
+		job.Status = "pending"
+		job.ScheduledAt = now.Add(time.Duration(job.Attempts) * time.Minute)
+	default:
+		job.Status = "failed"
+		job.FinishedAt = &now
+	}
+	if err := c.db.UpdateJob(ctx, job); err != nil {
+		collectorError(ctx, "could not update job [%d] in database: %v", job.JobID, err)
+	}
+	log.Infof("finished job %s", job)
+}
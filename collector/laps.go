@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// CollectRaceLaps stores lap by lap data of a race, drivers must already have been stored through the race results
+func (c *Collector) CollectRaceLaps(ctx context.Context, subsessionID int, drivers map[int]database.Driver) {
+	log.Infof("collecting laps for subsession [%d]...", subsessionID)
+
+	laps, err := c.client.GetLapChartData(ctx, subsessionID)
+	if err != nil {
+		collectorError(ctx, "could not get lap chart data [subsessionID:%d]: %v", subsessionID, err)
+		return
+	}
+
+	raceLaps := make([]database.RaceLap, 0, len(laps))
+	for _, lap := range laps {
+		driver, ok := drivers[lap.DriverID]
+		if !ok { // i.e. team entries, or drivers that could not be stored
+			log.Debugf("skipping lap of unknown driver: %s", lap)
+			continue
+		}
+		laptime := lap.LapTime
+		if laptime < 0 {
+			laptime = 0
+		}
+		raceLaps = append(raceLaps, database.RaceLap{
+			SubsessionID: subsessionID,
+			Driver:       driver,
+			Lap:          lap.LapNumber,
+			Laptime:      database.Laptime(laptime),
+			Position:     lap.LapPosition,
+			Interval:     lap.Interval,
+			SessionTime:  lap.SessionTime,
+			Flags:        lap.Flags,
+			Pitted:       lap.Pitted(),
+			OffTrack:     lap.OffTrack(),
+			Incident:     lap.Incident || lap.Flags&(api.LapFlagContact|api.LapFlagCarContact|api.LapFlagLostControl) != 0,
+			Events:       strings.Join(lap.LapEvents, ","),
+		})
+	}
+	if err := c.db.UpsertRaceLaps(ctx, raceLaps); err != nil {
+		collectorError(ctx, "could not store laps [subsessionID:%d] in database: %v", subsessionID, err)
+		return
+	}
+	log.Debugf("Race laps: %d", len(raceLaps))
+}
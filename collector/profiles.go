@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+const (
+	memberBatchSize          = 50 // cust_ids per member request
+	driverEnrichmentInterval = time.Hour
+)
+
+// ScheduleDriverEnrichment queues a driver enrichment job right away and every hour after, until ctx is done.
+// Each job refreshes the profiles of all drivers that have not been refreshed within maxAge.
+func (c *Collector) ScheduleDriverEnrichment(ctx context.Context, maxAge time.Duration) {
+	for {
+		if _, err := c.EnqueueDriverEnrichment(ctx, maxAge); err != nil && ctx.Err() == nil {
+			collectorError(ctx, "could not queue driver enrichment: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(driverEnrichmentInterval):
+		}
+	}
+}
+
+// EnrichDrivers fetches member profiles, licenses and career stats of all drivers not refreshed within maxAge
+func (c *Collector) EnrichDrivers(ctx context.Context, maxAge time.Duration) {
+	log.Infof("enriching driver profiles older than [%s] ...", maxAge)
+
+	ids, err := c.db.GetDriverIDsToEnrich(ctx, time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		collectorError(ctx, "could not get drivers to enrich from database: %v", err)
+		return
+	}
+	jobTotal(ctx, "drivers", len(ids))
+
+	for start := 0; start < len(ids); start += memberBatchSize {
+		if ctx.Err() != nil {
+			return
+		}
+		end := start + memberBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		members, err := c.client.GetMembers(ctx, ids[start:end])
+		if err != nil {
+			collectorError(ctx, "could not get members %v: %v", ids[start:end], err)
+			continue
+		}
+		for _, member := range members {
+			c.enrichDriver(ctx, member)
+			jobStep(ctx, "drivers")
+		}
+	}
+}
+
+func (c *Collector) enrichDriver(ctx context.Context, member api.Member) {
+	driver, err := c.db.GetDriverByID(ctx, member.ID)
+	if err != nil {
+		log.Warnf("member [%d:%s] is not a known driver: %v", member.ID, member.Name, err)
+		return
+	}
+
+	stats, err := c.client.GetMemberStats(ctx, member.ID)
+	if err != nil {
+		collectorError(ctx, "could not get career stats of member [%d]: %v", member.ID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	profile := database.DriverProfile{
+		Driver:      driver,
+		RefreshedAt: &now,
+		Licenses:    make([]database.DriverLicense, 0, len(member.Licenses)),
+		CareerStats: make([]database.DriverCareerStats, 0, len(stats)),
+	}
+	if since, err := time.Parse("2006-01-02", member.MemberSince); err == nil {
+		profile.MemberSince = &since
+	}
+	if !member.LastLogin.IsZero() {
+		lastLogin := member.LastLogin.UTC()
+		profile.LastLogin = &lastLogin
+	}
+	for _, l := range member.Licenses {
+		profile.Licenses = append(profile.Licenses, database.DriverLicense{
+			DriverID:     driver.DriverID,
+			CategoryID:   l.CategoryID,
+			Category:     l.Category,
+			LicenseLevel: l.LicenseLevel,
+			Group:        l.Group,
+			SafetyRating: l.SafetyRating,
+			CPI:          l.CPI,
+			IRating:      l.IRating,
+			TTRating:     l.TTRating,
+			Color:        l.Color,
+		})
+	}
+	for _, s := range stats {
+		profile.CareerStats = append(profile.CareerStats, database.DriverCareerStats{
+			DriverID:          driver.DriverID,
+			CategoryID:        s.CategoryID,
+			Category:          s.Category,
+			Starts:            s.Starts,
+			Wins:              s.Wins,
+			Top5:              s.Top5,
+			Poles:             s.Poles,
+			AvgStartPosition:  s.AvgStartPosition,
+			AvgFinishPosition: s.AvgFinishPosition,
+			Laps:              s.Laps,
+			LapsLed:           s.LapsLed,
+			AvgIncidents:      s.AvgIncidents,
+			AvgPoints:         s.AvgPoints,
+			WinPercentage:     s.WinPercentage,
+			Top5Percentage:    s.Top5Percentage,
+			LapsLedPercentage: s.LapsLedPercentage,
+			TotalClubPoints:   s.TotalClubPoints,
+		})
+	}
+
+	if err := c.db.UpsertDriverProfile(ctx, profile); err != nil {
+		collectorError(ctx, "could not store profile of driver [%d:%s] in database: %v", driver.DriverID, driver.Name, err)
+		return
+	}
+	log.Debugf("Driver profile: %s", profile)
+}
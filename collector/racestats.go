@@ -1,19 +1,19 @@
 package collector
 
 import (
-	"strings"
+	"context"
 	"time"
 
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) CollectRaceStats(rws database.RaceWeekResult, forceUpdate bool) {
+func (c *Collector) CollectRaceStats(ctx context.Context, rws database.RaceWeekResult, forceUpdate bool) {
 	log.Infof("collecting race stats for subsession [%d]...", rws.SubsessionID)
 
 	// check if race stats need to be updated in DB
 	if !forceUpdate {
-		racestats, err := c.db.GetRaceStatsBySubsessionID(rws.SubsessionID)
+		racestats, err := c.db.GetRaceStatsBySubsessionID(ctx, rws.SubsessionID)
 		if err == nil && racestats.SubsessionID == rws.SubsessionID && racestats.Laps > 0 &&
 			int(time.Since(racestats.StartTime).Seconds()) >= racestats.AvgLaptime.Seconds()*racestats.Laps*25 {
 			log.Infof("Existing race stats found, no need for update: %s", racestats)
@@ -22,16 +22,14 @@ func (c *Collector) CollectRaceStats(rws database.RaceWeekResult, forceUpdate bo
 	}
 
 	// collect race result
-	result, err := c.client.GetSessionResult(rws.SubsessionID)
+	result, err := c.client.GetSessionResult(ctx, rws.SubsessionID)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not get race result [subsessionID:%d]: %v", rws.SubsessionID, err)
+		collectorError(ctx, "could not get race result [subsessionID:%d]: %v", rws.SubsessionID, err)
 		return
 	}
 	//log.Debugf("Result: %v", result)
 	if result.Laps <= 0 || result.SubsessionID <= 0 { // skip invalid race results
-		collectorErrors.Inc()
-		log.Errorf("invalid race result: %v", result)
+		collectorError(ctx, "invalid race result: %v", result)
 		return
 	}
 
@@ -50,32 +48,39 @@ func (c *Collector) CollectRaceStats(rws database.RaceWeekResult, forceUpdate bo
 		WeatherRH:          result.Weather.RelHumidity.IntValue(),
 		WeatherTemp:        result.Weather.TempValue.IntValue(),
 	}
-	racestats, err := c.db.InsertRaceStats(stats)
+	racestats, err := c.db.InsertRaceStats(ctx, stats)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not store race stats [%s] in database: %v", stats, err)
+		collectorError(ctx, "could not store race stats [%s] in database: %v", stats, err)
 		return
 	}
 	if racestats.SubsessionID <= 0 {
-		collectorErrors.Inc()
-		log.Errorf("empty race stats: %s", stats)
+		collectorError(ctx, "empty race stats: %s", stats)
 		return
 	}
 	log.Debugf("Race stats: %s", racestats)
 
-	// go through simsessions
+	// go through simsessions, practice, qualifying, heats and the feature race
+	drivers := make(map[int]database.Driver)
 	for _, simsession := range result.Results {
-		if simsession.SimsessionNumber != 0 ||
-			strings.ToLower(simsession.SimsessionName) != "race" ||
-			strings.ToLower(simsession.SimsessionTypeName) != "race" {
-			// skip anything that's not a race session entry
+		ss := database.Simsession{
+			SubsessionID:       result.SubsessionID,
+			SimsessionNumber:   simsession.SimsessionNumber,
+			SimsessionType:     simsession.SimsessionType,
+			SimsessionTypeName: simsession.SimsessionTypeName,
+			SimsessionSubtype:  simsession.SimsessionSubtype,
+			SimsessionName:     simsession.SimsessionName,
+		}
+		if err := c.db.UpsertSimsession(ctx, ss); err != nil {
+			collectorError(ctx, "could not store simsession [%s] in database: %v", ss, err)
 			continue
 		}
-		// go through race / driver results
+		log.Debugf("Simsession: %s", ss)
+
+		// go through session / driver results
 		for _, row := range simsession.Results {
 			//log.Debugf("Driver result: %s", row)
 			// update club & driver
-			driver, ok := c.UpsertDriverAndClub(row.RacerName, row.ClubName, row.RacerID, row.ClubID)
+			driver, ok := c.UpsertDriverAndClub(ctx, row.RacerName, row.ClubName, row.RacerID, row.ClubID, result.StartTime)
 			if !ok {
 				continue
 			}
@@ -83,6 +88,7 @@ func (c *Collector) CollectRaceStats(rws database.RaceWeekResult, forceUpdate bo
 			// insert driver result
 			rr := database.RaceResult{
 				SubsessionID:             result.SubsessionID,
+				SimsessionNumber:         simsession.SimsessionNumber,
 				Driver:                   driver,
 				IRatingBefore:            row.IRatingBefore,
 				IRatingAfter:             row.IRatingAfter,
@@ -112,14 +118,22 @@ func (c *Collector) CollectRaceStats(rws database.RaceWeekResult, forceUpdate bo
 				ReasonOut:                row.ReasonOut,
 				SessionStartTime:         result.StartTime.Unix() * 1000,
 			}
-			raceResult, err := c.db.InsertRaceResult(rr)
+			raceResult, err := c.db.InsertRaceResult(ctx, rr)
 			if err != nil {
-				collectorErrors.Inc()
-				log.Errorf("could not store race result [subsessionID:%d] for driver [%d:%s] in database: %v",
-					result.SubsessionID, driver.DriverID, driver.Name, err)
+				collectorError(ctx, "could not store race result [subsessionID:%d, simsession:%d] for driver [%d:%s] in database: %v",
+					result.SubsessionID, simsession.SimsessionNumber, driver.DriverID, driver.Name, err)
 				continue
 			}
 			log.Debugf("Race result: %s", raceResult)
+			if simsession.SimsessionNumber == 0 { // lap chart data is only collected for the feature race
+				drivers[driver.DriverID] = driver
+			}
 		}
 	}
+
+	// insert lap by lap data
+	c.CollectRaceLaps(ctx, result.SubsessionID, drivers)
+
+	// insert incidents, cautions and pit stops
+	c.CollectRaceEvents(ctx, result.SubsessionID, drivers)
 }
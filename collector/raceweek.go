@@ -1,29 +1,33 @@
 package collector
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) CollectRaceWeek(seasonID, week int, forceUpdate bool) {
+const raceStatsWorkers = 4
+
+// CollectRaceWeek collects all results of a raceweek. It returns an error if the raceweek itself could not be collected,
+// errors of single subsessions are just recorded.
+func (c *Collector) CollectRaceWeek(ctx context.Context, seasonID, week int, forceUpdate bool) error {
 	log.Infof("collecting race week [%d] for season [%d] ...", week, seasonID)
 
 	if week < 0 || week > 12 { // 0-12 (13) to allow for leap weeks / seasons with 13 official weeks, like 2020S3
-		collectorErrors.Inc()
-		log.Errorf("week [%d] is invalid", week)
-		return
+		return jobError(ctx, "week [%d] is invalid", week)
 	}
 
-	results, err := c.client.GetRaceWeekResults(seasonID, week)
+	results, err := c.client.GetRaceWeekResults(ctx, seasonID, week)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("invalid raceweek results for seasonID [%d], week [%d]: %v", seasonID, week, err)
-		return
+		return jobError(ctx, "invalid raceweek results for seasonID [%d], week [%d]: %v", seasonID, week, err)
 	}
 	if len(results) == 0 {
 		collectorErrors.Inc()
 		log.Warnf("no results found for season [%d], week [%d]", seasonID, week)
-		return
+		return nil
 	}
 	trackID := results[0].Track.ID
 
@@ -33,25 +37,42 @@ func (c *Collector) CollectRaceWeek(seasonID, week int, forceUpdate bool) {
 		RaceWeek: week,
 		TrackID:  trackID,
 	}
-	raceweek, err := c.db.InsertRaceWeek(r)
+	raceweek, err := c.db.InsertRaceWeek(ctx, r)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not store raceweek [%d] in database: %v", r.RaceWeek, err)
-		return
+		return jobError(ctx, "could not store raceweek [%d] in database: %v", r.RaceWeek, err)
 	}
 	if raceweek.RaceWeekID <= 0 {
-		collectorErrors.Inc()
-		log.Errorf("empty raceweek: %v", raceweek)
-		return
+		return jobError(ctx, "empty raceweek: %v", raceweek)
 	}
-	if err := c.db.UpdateRaceWeekLastUpdateToNow(raceweek.RaceWeekID); err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not update raceweek [%d] last-update timestamp in database: %v", r.RaceWeek, err)
+	if err := c.db.UpdateRaceWeekLastUpdateToNow(ctx, raceweek.RaceWeekID); err != nil {
+		collectorError(ctx, "could not update raceweek [%d] last-update timestamp in database: %v", r.RaceWeek, err)
 	}
 	log.Debugf("Raceweek: %v", raceweek)
 
 	// figure out raceweek timeslots / schedule
-	c.CollectTimeslots(seasonID, results)
+	c.CollectTimeslots(ctx, seasonID, results)
+
+	// race stats of official races are fetched in parallel, the api client keeps us within the ratelimit
+	official := make(chan database.RaceWeekResult)
+	var wg sync.WaitGroup
+	for i := 0; i < raceStatsWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range official {
+				c.CollectRaceStats(ctx, result, forceUpdate)
+				jobStep(ctx, "subsessions")
+			}
+		}()
+	}
+
+	var officials int
+	for _, r := range results {
+		if r.Official {
+			officials++
+		}
+	}
+	jobTotal(ctx, "subsessions", officials)
 
 	// upsert raceweek results
 	for _, r := range results {
@@ -66,16 +87,15 @@ func (c *Collector) CollectRaceWeek(seasonID, week int, forceUpdate bool) {
 			SizeOfField:     r.SizeOfField,
 			StrengthOfField: r.StrengthOfField,
 		}
-		result, err := c.db.InsertRaceWeekResult(rs)
+		result, err := c.db.InsertRaceWeekResult(ctx, rs)
 		if err != nil {
-			collectorErrors.Inc()
-			log.Errorf("could not store raceweek result [subsessionID:%d] in database: %v", r.SubsessionID, err)
+			collectorError(ctx, "could not store raceweek result [subsessionID:%d] in database: %v", r.SubsessionID, err)
 			continue
 		}
 		if result.SubsessionID <= 0 {
-			collectorErrors.Inc()
-			log.Errorf("empty raceweek result: %v", result)
-			return
+			close(official)
+			wg.Wait()
+			return jobError(ctx, "empty raceweek result: %v", result)
 		}
 
 		// skip unofficial races
@@ -84,12 +104,23 @@ func (c *Collector) CollectRaceWeek(seasonID, week int, forceUpdate bool) {
 		}
 
 		// insert race statistics
-		c.CollectRaceStats(result, forceUpdate)
+		official <- result
+	}
+	close(official)
+	wg.Wait()
+
+	// time trials have no date of their own, drivers are seen with their names as of the latest race of the raceweek
+	var seen time.Time
+	for _, r := range results {
+		if r.StartTime.After(seen) {
+			seen = r.StartTime
+		}
 	}
 
 	// upsert time rankings for all car classes of raceweek
-	c.CollectTimeRankings(raceweek)
+	c.CollectTimeRankings(ctx, raceweek, seen)
 
 	// upsert time trial results for all car classes of raceweek
-	c.CollectTTResults(raceweek)
+	c.CollectTTResults(ctx, raceweek, seen)
+	return nil
 }
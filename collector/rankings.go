@@ -1,33 +1,33 @@
 package collector
 
 import (
+	"context"
+	"time"
+
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) CollectTimeRankings(raceweek database.RaceWeek) {
+func (c *Collector) CollectTimeRankings(ctx context.Context, raceweek database.RaceWeek, seen time.Time) {
 	log.Infof("collecting time rankings for raceweek [%d] ...", raceweek.RaceWeek)
 
-	cars, err := c.db.GetCarsByRaceWeekID(raceweek.RaceWeekID)
+	cars, err := c.db.GetCarsByRaceWeekID(ctx, raceweek.RaceWeekID)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not get cars [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
+		collectorError(ctx, "could not get cars [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
 		return
 	}
 
-	carIDs, err := c.db.GetCarClassIDsByRaceWeekID(raceweek.RaceWeekID)
+	carIDs, err := c.db.GetCarClassIDsByRaceWeekID(ctx, raceweek.RaceWeekID)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not get car classes [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
+		collectorError(ctx, "could not get car classes [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
 		return
 	}
 
 	for _, car := range cars {
 		for _, carClassID := range carIDs {
-			rankings, err := c.client.GetTimeTrialTimeRankings(raceweek.SeasonID, carClassID, raceweek.TrackID, raceweek.RaceWeek)
+			rankings, err := c.client.GetTimeTrialTimeRankings(ctx, raceweek.SeasonID, carClassID, raceweek.TrackID, raceweek.RaceWeek)
 			if err != nil {
-				collectorErrors.Inc()
-				log.Errorf("could not get time trial rankings for [season_id:%d,raceweek:%d,car_class_id:%d,track_id:%d]: %v",
+				collectorError(ctx, "could not get time trial rankings for [season_id:%d,raceweek:%d,car_class_id:%d,track_id:%d]: %v",
 					raceweek.SeasonID, raceweek.RaceWeek, carClassID, raceweek.TrackID, err)
 				return
 			}
@@ -35,7 +35,7 @@ func (c *Collector) CollectTimeRankings(raceweek database.RaceWeek) {
 				log.Debugf("Time trial ranking: %s", ranking)
 
 				// update club & driver
-				driver, ok := c.UpsertDriverAndClub(ranking.DriverName, ranking.ClubName, ranking.DriverID, ranking.ClubID)
+				driver, ok := c.UpsertDriverAndClub(ctx, ranking.DriverName, ranking.ClubName, ranking.DriverID, ranking.ClubID, seen)
 				if !ok {
 					continue
 				}
@@ -51,9 +51,8 @@ func (c *Collector) CollectTimeRankings(raceweek database.RaceWeek) {
 					LicenseClass:          "",
 					IRating:               0,
 				}
-				if err := c.db.UpsertTimeRanking(t); err != nil {
-					collectorErrors.Inc()
-					log.Errorf("could not store time trial ranking of [%s] in database: %v", ranking.DriverName, err)
+				if err := c.db.UpsertTimeRanking(ctx, t); err != nil {
+					collectorError(ctx, "could not store time trial ranking of [%s] in database: %v", ranking.DriverName, err)
 					continue
 				}
 			}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// Replay rebuilds the database from archived payloads, the collector must have been created with api.WithReplay(archive)
+func (c *Collector) Replay(ctx context.Context, archive api.Archive) {
+	log.Infoln("replaying archived iRacing payloads ...")
+
+	c.CollectTracks(ctx)
+	c.CollectCars(ctx)
+
+	// seasons from the most recently archived season list
+	series, err := c.db.GetActiveSeries(ctx)
+	if err != nil {
+		collectorError(ctx, "could not read series information from database: %v", err)
+		return
+	}
+	seasons, err := c.client.GetCurrentSeasons(ctx)
+	if err != nil {
+		collectorError(ctx, "could not replay seasons: %v", err)
+	}
+	for _, series := range series {
+		namerx, err := regexp.Compile(series.SeriesRegex)
+		if err != nil {
+			collectorError(ctx, "invalid regex [%s] of series [%s]: %v", series.SeriesRegex, series.SeriesName, err)
+			continue
+		}
+		for _, season := range seasons {
+			if namerx.MatchString(season.SeasonName) || season.SeriesID == series.APISeriesID {
+				c.upsertSeason(ctx, series, season)
+			}
+		}
+	}
+
+	// every raceweek there are season results archived for
+	params, err := archive.List(ctx, "/data/results/season_results")
+	if err != nil {
+		collectorError(ctx, "could not list archived season results: %v", err)
+		return
+	}
+	for _, p := range params {
+		if ctx.Err() != nil {
+			return // shutting down
+		}
+		values, err := url.ParseQuery(p)
+		if err != nil {
+			collectorError(ctx, "could not parse archived season results parameters [%s]: %v", p, err)
+			continue
+		}
+		seasonID, err := strconv.Atoi(values.Get("season_id"))
+		if err != nil {
+			collectorError(ctx, "could not convert season_id [%s] to int: %v", values.Get("season_id"), err)
+			continue
+		}
+		week, err := strconv.Atoi(values.Get("race_week_num"))
+		if err != nil {
+			collectorError(ctx, "could not convert race_week_num [%s] to int: %v", values.Get("race_week_num"), err)
+			continue
+		}
+		c.CollectRaceWeek(ctx, seasonID, week, true)
+	}
+	log.Infoln("replay finished")
+}
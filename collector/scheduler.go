@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	pollInterval       = 15 * time.Minute
+	defaultRaceLength  = 45 * time.Minute
+	resultsGracePeriod = 10 * time.Minute // practice, qualifying and iRacing needing a moment to publish the results
+)
+
+// timeslot is a season whose sessions start on the crontab detected by CollectTimeslots
+type timeslot struct {
+	seasonID int
+	week     int
+	lastWeek int // raceweek collected the last time, to catch sessions finishing after the week rolled over
+	crontab  string
+	schedule cron.Schedule
+	length   time.Duration
+	start    time.Time // start of the next session whose results have not been collected yet
+}
+
+func (t *timeslot) due() time.Time {
+	return t.start.Add(t.length + resultsGracePeriod)
+}
+
+// scheduler keeps track of when results of timeslot sessions should be available
+type scheduler struct {
+	mutex     sync.Mutex
+	timeslots map[int]*timeslot
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		timeslots: make(map[int]*timeslot),
+	}
+}
+
+// update registers or refreshes the timeslot of a season,
+// it returns false if the season has no usable timeslot and needs to be polled instead, and whether it was newly added
+func (s *scheduler) update(seasonID, week int, crontab string, length time.Duration, now time.Time) (bool, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(crontab) == 0 {
+		delete(s.timeslots, seasonID)
+		return false, false
+	}
+	if length <= 0 {
+		length = defaultRaceLength
+	}
+
+	t, found := s.timeslots[seasonID]
+	if !found || t.crontab != crontab {
+		schedule, err := cron.ParseStandard("CRON_TZ=UTC " + crontab)
+		if err != nil {
+			delete(s.timeslots, seasonID)
+			return false, false
+		}
+		t = &timeslot{
+			seasonID: seasonID,
+			lastWeek: week,
+			crontab:  crontab,
+			schedule: schedule,
+		}
+		s.timeslots[seasonID] = t
+	}
+	t.week = week
+	if t.length != length || t.start.IsZero() {
+		// first session that has not finished yet
+		t.length = length
+		t.start = t.schedule.Next(now.Add(-(length + resultsGracePeriod)))
+	}
+	return true, !found
+}
+
+// remove forgets all seasons not in keep, i.e. seasons that are over
+func (s *scheduler) remove(keep map[int]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for seasonID := range s.timeslots {
+		if !keep[seasonID] {
+			delete(s.timeslots, seasonID)
+		}
+	}
+}
+
+// due returns all timeslots whose session results should be available by now and moves them on to their next session
+func (s *scheduler) due(now time.Time) []timeslot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	due := make([]timeslot, 0)
+	for _, t := range s.timeslots {
+		if t.due().After(now) {
+			continue
+		}
+		due = append(due, *t)
+		t.lastWeek = t.week
+		for !t.due().After(now) {
+			t.start = t.schedule.Next(t.start)
+		}
+	}
+	return due
+}
+
+// next returns when the next timeslot is due, or false if there are none
+func (s *scheduler) next() (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var next time.Time
+	for _, t := range s.timeslots {
+		if next.IsZero() || t.due().Before(next) {
+			next = t.due()
+		}
+	}
+	return next, !next.IsZero()
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+// storeSchedule persists the schedule of a season, so upcoming tracks are known before any race has run.
+// Unless forced for a season seen for the first time, it is only stored if there is none yet.
+func (c *Collector) storeSchedule(ctx context.Context, season api.Season, force bool) {
+	if len(season.Schedule) == 0 {
+		return
+	}
+	if !force {
+		schedule, err := c.db.GetSeasonScheduleBySeasonID(ctx, season.SeasonID)
+		if err != nil {
+			collectorError(ctx, "could not get schedule of season [%d] from database: %v", season.SeasonID, err)
+			return
+		}
+		if len(schedule.Weeks) > 0 {
+			return
+		}
+	}
+
+	schedule := seasonSchedule(season)
+	log.Infof("Schedule: %s", schedule)
+	if err := c.db.UpsertSeasonSchedule(ctx, schedule); err != nil {
+		collectorError(ctx, "could not store schedule of season [%s] in database: %v", season.SeasonName, err)
+	}
+}
+
+func seasonSchedule(season api.Season) database.SeasonSchedule {
+	schedule := database.SeasonSchedule{
+		SeasonID:    season.SeasonID,
+		CarClassIDs: season.CarClasses,
+		DropWeeks:   season.DropWeeks,
+		MaxWeeks:    season.MaxWeeks,
+		Weeks:       make([]database.ScheduleWeek, 0, len(season.Schedule)),
+	}
+	if schedule.MaxWeeks <= 0 {
+		schedule.MaxWeeks = 12
+	}
+	for _, week := range season.Schedule {
+		schedule.Weeks = append(schedule.Weeks, database.ScheduleWeek{
+			SeasonID:      season.SeasonID,
+			RaceWeek:      week.RaceWeek,
+			StartDate:     week.StartDate.Time,
+			TrackID:       week.Track.TrackID,
+			TrackName:     week.Track.Name,
+			TrackConfig:   week.Track.Config,
+			TrackCategory: week.Track.Category,
+			RaceLaps:      week.RaceLaps,
+			RaceTime:      week.RaceTime,
+		})
+	}
+	return schedule
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+)
+
+// ErrInvalidSeries is wrapped by the errors of a series failing validation
+var ErrInvalidSeries = errors.New("invalid series")
+
+// SaveSeries validates a series and stores it, the Run loop picks it up on its next iteration
+func (c *Collector) SaveSeries(ctx context.Context, series database.Series) (database.Series, error) {
+	if err := c.validateSeries(ctx, series); err != nil {
+		return database.Series{}, err
+	}
+	return c.db.UpsertSeries(ctx, series)
+}
+
+// validateSeries makes sure the regex compiles, and that an active series matches a current season by its API series_id.
+// Existing series are only checked against the current seasons if their API series_id or active flag changes,
+// so they can still be edited between seasons.
+func (c *Collector) validateSeries(ctx context.Context, series database.Series) error {
+	if len(strings.TrimSpace(series.SeriesName)) == 0 || len(strings.TrimSpace(series.SeriesNameShort)) == 0 {
+		return fmt.Errorf("%w: needs a name and a short name", ErrInvalidSeries)
+	}
+	if len(series.SeriesRegex) == 0 {
+		return fmt.Errorf("%w: needs a regex", ErrInvalidSeries)
+	}
+	if _, err := regexp.Compile(series.SeriesRegex); err != nil {
+		return fmt.Errorf("%w: regex [%s] does not compile: %v", ErrInvalidSeries, series.SeriesRegex, err)
+	}
+	if series.Active == "false" {
+		return nil
+	}
+	if series.SeriesID > 0 {
+		existing, err := c.db.GetSeriesByID(ctx, series.SeriesID)
+		if err != nil {
+			return fmt.Errorf("could not get series [%d] from database: %w", series.SeriesID, err)
+		}
+		if existing.Active != "false" && apiSeriesID(existing) == apiSeriesID(series) {
+			return nil
+		}
+	}
+
+	seasons, err := c.client.GetCurrentSeasons(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get current seasons to validate series against: %v", err)
+	}
+	for _, season := range seasons {
+		if season.SeriesID == series.APISeriesID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: API series_id [%d] does not match any current season", ErrInvalidSeries, series.APISeriesID)
+}
+
+// apiSeriesID returns the API series_id of a series, 0 if it has none
+func apiSeriesID(series database.Series) int {
+	if series.APISeriesID <= 0 {
+		return 0
+	}
+	return series.APISeriesID
+}
@@ -1,25 +1,26 @@
 package collector
 
 import (
+	"context"
+	"time"
+
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) CollectTTResults(raceweek database.RaceWeek) {
+func (c *Collector) CollectTTResults(ctx context.Context, raceweek database.RaceWeek, seen time.Time) {
 	log.Infof("collecting TT statistics for raceweek [%d] ...", raceweek.RaceWeek)
 
-	carIDs, err := c.db.GetCarClassIDsByRaceWeekID(raceweek.RaceWeekID)
+	carIDs, err := c.db.GetCarClassIDsByRaceWeekID(ctx, raceweek.RaceWeekID)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not get car classes [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
+		collectorError(ctx, "could not get car classes [raceweek_id:%d] from database: %v", raceweek.RaceWeekID, err)
 		return
 	}
 
 	for _, carClassID := range carIDs {
-		results, err := c.client.GetTimeTrialResults(raceweek.SeasonID, carClassID, raceweek.RaceWeek)
+		results, err := c.client.GetTimeTrialResults(ctx, raceweek.SeasonID, carClassID, raceweek.RaceWeek)
 		if err != nil {
-			collectorErrors.Inc()
-			log.Errorf("could not get time trial results for [season_id:%d,raceweek:%d,car_class_id:%d]: %v",
+			collectorError(ctx, "could not get time trial results for [season_id:%d,raceweek:%d,car_class_id:%d]: %v",
 				raceweek.SeasonID, raceweek.RaceWeek, carClassID, err)
 			continue
 		}
@@ -27,7 +28,7 @@ func (c *Collector) CollectTTResults(raceweek database.RaceWeek) {
 			log.Debugf("Time trial result: %s", result)
 
 			// update club & driver
-			driver, ok := c.UpsertDriverAndClub(result.DriverName, result.ClubName, result.DriverID, result.ClubID)
+			driver, ok := c.UpsertDriverAndClub(ctx, result.DriverName, result.ClubName, result.DriverID, result.ClubID, seen)
 			if !ok {
 				continue
 			}
@@ -46,9 +47,8 @@ func (c *Collector) CollectTTResults(raceweek database.RaceWeek) {
 				Dropped:    result.Dropped,
 				Division:   result.Division,
 			}
-			if err := c.db.UpsertTimeTrialResult(ttr); err != nil {
-				collectorErrors.Inc()
-				log.Errorf("could not store time trial result of [%s] in database: %v", result.DriverName, err)
+			if err := c.db.UpsertTimeTrialResult(ctx, ttr); err != nil {
+				collectorError(ctx, "could not store time trial result of [%s] in database: %v", result.DriverName, err)
 				continue
 			}
 		}
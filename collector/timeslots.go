@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -8,13 +9,12 @@ import (
 	"github.com/JamesClonk/iRcollector/log"
 )
 
-func (c *Collector) CollectTimeslots(seasonID int, results []api.RaceWeekResult) {
+func (c *Collector) CollectTimeslots(ctx context.Context, seasonID int, results []api.RaceWeekResult) {
 	log.Infof("collecting timeslots for season [%d] ...", seasonID)
 
-	season, err := c.db.GetSeasonByID(seasonID)
+	season, err := c.db.GetSeasonByID(ctx, seasonID)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("could not get season [%d] from database: %v", seasonID, err)
+		collectorError(ctx, "could not get season [%d] from database: %v", seasonID, err)
 		return
 	}
 
@@ -46,8 +46,7 @@ func (c *Collector) CollectTimeslots(seasonID int, results []api.RaceWeekResult)
 		// collect minute mark
 		minute := results[0].StartTime.Minute()
 		if minute != results[1].StartTime.Minute() {
-			collectorErrors.Inc()
-			log.Errorf("something fishy is going on, starttimes are not on a repeating timeslot: [%v] vs. [%s]", results[0].StartTime, results[1].StartTime)
+			collectorError(ctx, "something fishy is going on, starttimes are not on a repeating timeslot: [%v] vs. [%s]", results[0].StartTime, results[1].StartTime)
 			return
 		}
 
@@ -56,9 +55,8 @@ func (c *Collector) CollectTimeslots(seasonID int, results []api.RaceWeekResult)
 
 		// update season with timeslot information
 		season.Timeslots = fmt.Sprintf("%d %d-23/%d * * *", minute, startingHour, hourlyInterval)
-		if err := c.db.UpsertSeason(season); err != nil {
-			collectorErrors.Inc()
-			log.Errorf("could not update season [%s] in database: %v", season.SeasonName, err)
+		if err := c.db.UpsertSeason(ctx, season); err != nil {
+			collectorError(ctx, "could not update season [%s] in database: %v", season.SeasonName, err)
 		}
 	}
 }
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/database"
+)
+
+// DatabaseTokenStore persists the iRacing oauth token in the tokens table
+type DatabaseTokenStore struct {
+	db   database.Database
+	name string
+}
+
+func NewDatabaseTokenStore(db database.Database) *DatabaseTokenStore {
+	return &DatabaseTokenStore{
+		db:   db,
+		name: "iracing",
+	}
+}
+
+func (s *DatabaseTokenStore) LoadToken() (*api.StoredToken, error) {
+	token, err := s.db.GetToken(context.Background(), s.name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &api.StoredToken{
+		Token: api.Token{
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			TokenType:    token.TokenType,
+			Scope:        token.Scope,
+		},
+		AccessTokenExpiresAt:  token.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: token.RefreshTokenExpiresAt,
+		LastLogin:             token.LastLogin,
+	}, nil
+}
+
+func (s *DatabaseTokenStore) SaveToken(token api.StoredToken) error {
+	return s.db.UpsertToken(context.Background(), database.Token{
+		Name:                  s.name,
+		AccessToken:           token.AccessToken,
+		RefreshToken:          token.RefreshToken,
+		TokenType:             token.TokenType,
+		Scope:                 token.Scope,
+		AccessTokenExpiresAt:  token.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: token.RefreshTokenExpiresAt,
+		LastLogin:             token.LastLogin,
+	})
+}
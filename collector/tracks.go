@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,13 +15,12 @@ var (
 	})
 )
 
-func (c *Collector) CollectTracks() {
+func (c *Collector) CollectTracks(ctx context.Context) {
 	log.Infof("collecting tracks ...")
 
-	tracks, err := c.client.GetTracks()
+	tracks, err := c.client.GetTracks(ctx)
 	if err != nil {
-		collectorErrors.Inc()
-		log.Errorf("%v", err)
+		collectorError(ctx, "%v", err)
 		return
 	}
 
@@ -44,9 +44,8 @@ func (c *Collector) CollectTracks() {
 			MapImage:    track.MapImage,
 			ConfigImage: track.ConfigImage,
 		}
-		if err := c.db.UpsertTrack(t); err != nil {
-			collectorErrors.Inc()
-			log.Errorf("could not store track [%s] in database: %v", track.Name, err)
+		if err := c.db.UpsertTrack(ctx, t); err != nil {
+			collectorError(ctx, "could not store track [%s] in database: %v", track.Name, err)
 			continue
 		}
 	}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/JamesClonk/iRcollector/env"
 	"github.com/JamesClonk/iRcollector/log"
@@ -38,8 +39,13 @@ func NewAdapter() (adapter Adapter) {
 		databaseUri = env.MustGet("DB_URI")
 	}
 
-	// setup database adapter
-	adapter = newPostgresAdapter(databaseUri)
+	// setup database adapter, based on URI scheme
+	switch {
+	case strings.HasPrefix(databaseUri, "sqlite://"), strings.HasPrefix(databaseUri, "sqlite3://"):
+		adapter = newSQLiteAdapter(databaseUri)
+	default:
+		adapter = newPostgresAdapter(databaseUri)
+	}
 
 	// panic if no database adapter was set up
 	if adapter == nil {
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/log"
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/source/file"
+	"github.com/jmoiron/sqlx"
+	gosqlite3 "github.com/mattn/go-sqlite3"
+)
+
+const sqliteDriverName = "sqlite3_ircollector"
+
+func init() {
+	// register our own driver with the functions sqlite is missing compared to postgres
+	sql.Register(sqliteDriverName, &gosqlite3.SQLiteDriver{
+		ConnectHook: func(conn *gosqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("ceil", math.Ceil, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("floor", math.Floor, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("date_part", datePart, true)
+		},
+	})
+}
+
+// datePart mimics date_part('epoch', timestamp) of postgres for timestamps stored as text
+func datePart(field string, value interface{}) (interface{}, error) {
+	if field != "epoch" {
+		return nil, fmt.Errorf("date_part: unsupported field %s", field)
+	}
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case time.Time:
+		return float64(v.UnixNano()) / 1e9, nil
+	default:
+		return nil, fmt.Errorf("date_part: unsupported value %v", value)
+	}
+	for _, format := range gosqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(format, strings.TrimSuffix(text, "Z"), time.UTC); err == nil {
+			return float64(t.UnixNano()) / 1e9, nil
+		}
+	}
+	return nil, fmt.Errorf("date_part: could not parse timestamp %s", text)
+}
+
+type SQLiteAdapter struct {
+	Database *sqlx.DB
+	URI      string
+	Type     string
+}
+
+func newSQLiteAdapter(uri string) *SQLiteAdapter {
+	dsn := uri
+	for _, scheme := range []string{"sqlite3://", "sqlite://"} {
+		dsn = strings.TrimPrefix(dsn, scheme)
+	}
+	if !strings.Contains(dsn, "_foreign_keys") && !strings.Contains(dsn, "_fk") {
+		if strings.Contains(dsn, "?") {
+			dsn = dsn + "&_foreign_keys=1"
+		} else {
+			dsn = dsn + "?_foreign_keys=1"
+		}
+	}
+
+	db, err := sqlx.Open(sqliteDriverName, dsn)
+	if err != nil {
+		panic(err)
+	}
+	// sqlite only allows one writer at a time anyway, and an in-memory database only exists per connection
+	db.SetMaxIdleConns(1)
+	db.SetMaxOpenConns(1)
+
+	return &SQLiteAdapter{
+		Database: db,
+		URI:      uri,
+		Type:     "sqlite",
+	}
+}
+
+func (adapter *SQLiteAdapter) GetDatabase() *sqlx.DB {
+	return adapter.Database
+}
+
+func (adapter *SQLiteAdapter) GetURI() string {
+	return adapter.URI
+}
+
+func (adapter *SQLiteAdapter) GetType() string {
+	return adapter.Type
+}
+
+func (adapter *SQLiteAdapter) RunMigrations(basePath string) error {
+	driver, err := sqlite3.WithInstance(adapter.Database.DB, &sqlite3.Config{})
+	if err != nil {
+		log.Errorln("could not create database migration driver")
+		log.Fatalf("%v", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(fmt.Sprintf("file://%s/sqlite", basePath), "sqlite3", driver)
+	if err != nil {
+		log.Errorln("could not create database migration instance")
+		log.Fatalf("%v", err)
+	}
+
+	log.Infoln("running sqlite database migrations - up ...")
+	return m.Up()
+}
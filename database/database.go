@@ -1,59 +1,114 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/JamesClonk/iRcollector/log"
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrInvalid is wrapped by errors about values that can't be used, like overlapping team memberships or an unknown rating interval
+var ErrInvalid = errors.New("invalid")
+
 type Database interface {
-	GetSeries() ([]Series, error)
-	GetActiveSeries() ([]Series, error)
-	GetSeasons() ([]Season, error)
-	GetSeasonsBySeriesID(int) ([]Season, error)
-	GetSeasonsByAPISeriesID(int) ([]Season, error)
-	GetSeasonByID(int) (Season, error)
-	UpsertSeason(Season) error
-	UpsertTrack(Track) error
-	UpsertCar(Car) error
-	GetCarByID(int) (Car, error)
-	GetCarsByRaceWeekID(int) ([]Car, error)
-	GetCarClassIDsByRaceWeekID(int) ([]int, error)
-	UpsertTimeTrialResult(TimeTrialResult) error
-	GetTimeTrialResultsBySeasonIDAndWeek(int, int) ([]TimeTrialResult, error)
-	GetTimeTrialResultsBySeasonIDWeekAndCarClass(int, int, int) ([]TimeTrialResult, error)
-	UpsertTimeRanking(TimeRanking) error
-	GetTimeRankingsBySeasonIDAndWeek(int, int) ([]TimeRanking, error)
-	GetFastestTimeTrialSessionsBySeasonIDAndWeek(int, int) ([]FastestLaptime, error)
-	GetFastestRaceLaptimesBySeasonIDAndWeek(int, int) ([]FastestLaptime, error)
-	InsertRaceWeek(RaceWeek) (RaceWeek, error)
-	UpdateRaceWeekLastUpdateToNow(int) error
-	GetRaceWeekByID(int) (RaceWeek, error)
-	GetRaceWeekBySeasonIDAndWeek(int, int) (RaceWeek, error)
-	GetRaceWeekMetricsBySeasonID(int) ([]RaceWeekMetrics, error)
-	GetRaceWeekMetricsBySeasonIDAndWeek(int, int) (RaceWeekMetrics, error)
-	InsertRaceWeekResult(RaceWeekResult) (RaceWeekResult, error)
-	GetRaceWeekResultBySubsessionID(int) (RaceWeekResult, error)
-	GetRaceWeekResultsBySeasonIDAndWeek(int, int) ([]RaceWeekResult, error)
-	InsertRaceStats(RaceStats) (RaceStats, error)
-	GetRaceStatsBySubsessionID(int) (RaceStats, error)
-	GetSeasonMetricsBySeriesID(int) ([]SeasonMetrics, error)
-	UpsertClub(Club) error
-	UpsertDriver(Driver) error
-	InsertRaceResult(RaceResult) (RaceResult, error)
-	GetRaceResultBySubsessionIDAndDriverID(int, int) (RaceResult, error)
-	GetRaceResultsBySubsessionID(int) ([]RaceResult, error)
-	GetRaceResultsBySeasonIDAndWeek(int, int) ([]RaceResult, error)
-	GetPointsBySeasonIDAndWeek(int, int) ([]Points, error)
-	GetPointsBySeasonIDAndWeekAndTrackCategory(int, int, string) ([]Points, error)
-	GetDriverSummariesBySeasonIDAndWeek(int, int) ([]Summary, error)
-	GetDriverSummariesBySeasonIDAndWeekAndTeam(int, int, string) ([]Summary, error)
-	GetDriverSummariesBySeasonIDAndTeam(int, string) ([]Summary, error)
-	GetClubByID(int) (Club, error)
-	GetDriverByID(int) (Driver, error)
-	GetTrackByID(int) (Track, error)
+	GetSeries(context.Context) ([]Series, error)
+	GetActiveSeries(context.Context) ([]Series, error)
+	GetSeriesByID(context.Context, int) (Series, error)
+	UpsertSeries(context.Context, Series) (Series, error)
+	DeactivateSeries(context.Context, int) error
+	GetSeasons(context.Context) ([]Season, error)
+	GetSeasonsBySeriesID(context.Context, int) ([]Season, error)
+	GetSeasonsByAPISeriesID(context.Context, int) ([]Season, error)
+	GetSeasonByID(context.Context, int) (Season, error)
+	UpsertSeason(context.Context, Season) error
+	GetSeasonScheduleBySeasonID(context.Context, int) (SeasonSchedule, error)
+	UpsertSeasonSchedule(context.Context, SeasonSchedule) error
+	UpsertTrack(context.Context, Track) error
+	UpsertCar(context.Context, Car) error
+	GetCarByID(context.Context, int) (Car, error)
+	GetCarsByRaceWeekID(context.Context, int) ([]Car, error)
+	GetCarClassIDsByRaceWeekID(context.Context, int) ([]int, error)
+	UpsertTimeTrialResult(context.Context, TimeTrialResult) error
+	GetTimeTrialResultsBySeasonIDAndWeek(context.Context, int, int) ([]TimeTrialResult, error)
+	GetTimeTrialResultsBySeasonIDWeekAndCarClass(context.Context, int, int, int) ([]TimeTrialResult, error)
+	UpsertTimeRanking(context.Context, TimeRanking) error
+	GetTimeRankingsBySeasonIDAndWeek(context.Context, int, int) ([]TimeRanking, error)
+	GetFastestTimeTrialSessionsBySeasonIDAndWeek(context.Context, int, int) ([]FastestLaptime, error)
+	GetFastestRaceLaptimesBySeasonIDAndWeek(context.Context, int, int) ([]FastestLaptime, error)
+	InsertRaceWeek(context.Context, RaceWeek) (RaceWeek, error)
+	UpdateRaceWeekLastUpdateToNow(context.Context, int) error
+	GetRaceWeekByID(context.Context, int) (RaceWeek, error)
+	GetRaceWeekBySeasonIDAndWeek(context.Context, int, int) (RaceWeek, error)
+	GetRaceWeekMetricsBySeasonID(context.Context, int) ([]RaceWeekMetrics, error)
+	GetRaceWeekMetricsBySeasonIDAndWeek(context.Context, int, int) (RaceWeekMetrics, error)
+	InsertRaceWeekResult(context.Context, RaceWeekResult) (RaceWeekResult, error)
+	GetRaceWeekResultBySubsessionID(context.Context, int) (RaceWeekResult, error)
+	GetRaceWeekResultsBySeasonIDAndWeek(context.Context, int, int) ([]RaceWeekResult, error)
+	InsertRaceStats(context.Context, RaceStats) (RaceStats, error)
+	GetRaceStatsBySubsessionID(context.Context, int) (RaceStats, error)
+	GetAverageRaceLengthBySeasonID(context.Context, int) (time.Duration, error)
+	GetSeasonMetricsBySeriesID(context.Context, int) ([]SeasonMetrics, error)
+	UpsertClub(context.Context, Club) error
+	UpsertDriver(context.Context, Driver, time.Time) error
+	InsertRaceResult(context.Context, RaceResult) (RaceResult, error)
+	GetRaceResultBySubsessionIDAndDriverID(context.Context, int, int) (RaceResult, error)
+	GetRaceResultsBySubsessionID(context.Context, int) ([]RaceResult, error)
+	UpsertSimsession(context.Context, Simsession) error
+	GetSimsessionsBySubsessionID(context.Context, int) ([]Simsession, error)
+	GetRaceResultsBySeasonIDAndWeek(context.Context, int, int) ([]RaceResult, error)
+	GetPointsBySeasonIDAndWeek(context.Context, int, int) ([]Points, error)
+	GetPointsBySeasonIDAndWeekAndTrackCategory(context.Context, int, int, string) ([]Points, error)
+	GetDriverSummariesBySeasonIDAndWeek(context.Context, int, int) ([]Summary, error)
+	GetDriverSummariesBySeasonIDAndWeekAndTeam(context.Context, int, int, string) ([]Summary, error)
+	GetDriverSummariesBySeasonIDAndTeam(context.Context, int, string) ([]Summary, error)
+	GetClubByID(context.Context, int) (Club, error)
+	GetTeams(context.Context) ([]Team, error)
+	GetTeamByID(context.Context, int) (Team, error)
+	InsertTeam(context.Context, Team) (Team, error)
+	UpdateTeam(context.Context, Team) error
+	DeleteTeam(context.Context, int) error
+	GetTeamMembershipByID(context.Context, int) (TeamMembership, error)
+	GetTeamMembershipsByTeamID(context.Context, int) ([]TeamMembership, error)
+	GetTeamMembershipsByDriverID(context.Context, int) ([]TeamMembership, error)
+	InsertTeamMembership(context.Context, TeamMembership) (TeamMembership, error)
+	UpdateTeamMembership(context.Context, TeamMembership) error
+	DeleteTeamMembership(context.Context, int) error
+	GetTeamRulesBySeriesID(context.Context, int) (TeamRules, error)
+	UpsertTeamRules(context.Context, TeamRules) error
+	GetTeamPointsBySeasonID(context.Context, int) ([]TeamPoints, error)
+	GetDriverByID(context.Context, int) (Driver, error)
+	UpsertDriverProfile(context.Context, DriverProfile) error
+	GetDriverProfileByDriverID(context.Context, int) (DriverProfile, error)
+	GetDriverIDsToEnrich(context.Context, time.Time) ([]int, error)
+	GetDriverNamesByDriverID(context.Context, int) ([]DriverName, error)
+	SearchDriversByName(context.Context, string) ([]DriverNameMatch, error)
+	GetRaceResultsByDriverID(context.Context, int, DriverFilter) ([]DriverRaceResult, error)
+	GetDriverSeasonSummariesByDriverID(context.Context, int, DriverFilter) ([]DriverSeasonSummary, error)
+	GetRatingHistoryByDriverID(context.Context, int, DriverFilter) ([]RatingPoint, error)
+	GetRatingTimeSeriesByDriverID(context.Context, int, DriverFilter) ([]RatingPoint, error)
+	GetTrackByID(context.Context, int) (Track, error)
+	GetToken(context.Context, string) (Token, error)
+	UpsertToken(context.Context, Token) error
+	InsertRawPayload(context.Context, RawPayload) error
+	GetLatestRawPayload(context.Context, string, string) (RawPayload, error)
+	GetRawPayloadParams(context.Context, string) ([]string, error)
+	InsertJob(context.Context, Job) (Job, error)
+	GetJobByID(context.Context, int) (Job, error)
+	GetJobs(context.Context) ([]Job, error)
+	ClaimJob(context.Context) (Job, error)
+	UpdateJob(context.Context, Job) error
+	ResetRunningJobs(context.Context) error
+	DeleteJob(context.Context, int) error
+	UpsertRaceLaps(context.Context, []RaceLap) error
+	GetRaceLapsBySubsessionID(context.Context, int) ([]RaceLap, error)
+	UpsertRaceEvents(context.Context, []RaceEvent) error
+	GetIncidentTimelineBySubsessionID(context.Context, int) ([]RaceEvent, error)
+	GetIncidentTimelineBySeasonIDAndWeek(context.Context, int, int) ([]RaceEvent, error)
+	GetPitStopSummariesBySubsessionID(context.Context, int) ([]PitStopSummary, error)
+	GetPitStopSummariesBySeasonIDAndWeek(context.Context, int, int) ([]PitStopSummary, error)
 }
 
 type database struct {
@@ -65,52 +120,101 @@ func NewDatabase(adapter Adapter) Database {
 	return &database{adapter.GetDatabase(), adapter.GetType()}
 }
 
-func (db *database) GetSeries() ([]Series, error) {
-	series := make([]Series, 0)
-	if err := db.Select(&series, `
-		select
+const seriesColumns = `
 			s.pk_series_id,
 			s.name,
 			s.short_name,
 			s.regex,
 			s.colorscheme,
-			s.active,
+			case when s.active then 'true' else 'false' end as active,
+			s.pending,
 			coalesce(s.api_series_id, -1) as api_series_id,
 			coalesce((select name from seasons where pk_season_id = (select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id)), '-') as current_season,
 			coalesce((select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id), -1) as current_season_id,
 			coalesce((select max(raceweek)+1 from raceweeks where fk_season_id = (select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id)), -1) as current_week
-		from series s
+		from series s`
+
+func (db *database) GetSeries(ctx context.Context) ([]Series, error) {
+	series := make([]Series, 0)
+	if err := db.SelectContext(ctx, &series, `
+		select`+seriesColumns+`
 		order by s.name asc, s.short_name asc`); err != nil {
 		return nil, err
 	}
 	return series, nil
 }
 
-func (db *database) GetActiveSeries() ([]Series, error) {
+func (db *database) GetActiveSeries(ctx context.Context) ([]Series, error) {
 	series := make([]Series, 0)
-	if err := db.Select(&series, `
-		select
-			s.pk_series_id,
-			s.name,
-			s.short_name,
-			s.regex,
-			s.colorscheme,
-			s.active,
-			coalesce(s.api_series_id, -1) as api_series_id,
-			coalesce((select name from seasons where pk_season_id = (select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id)), '-') as current_season,
-			coalesce((select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id), -1) as current_season_id,
-			coalesce((select max(raceweek)+1 from raceweeks where fk_season_id = (select max(ss.pk_season_id) from seasons ss where ss.fk_series_id = s.pk_series_id)), -1) as current_week
-		from series s
-		where s.active = 't'
+	if err := db.SelectContext(ctx, &series, `
+		select`+seriesColumns+`
+		where s.active = true
 		order by s.name asc, s.short_name asc`); err != nil {
 		return nil, err
 	}
 	return series, nil
 }
 
-func (db *database) GetSeasons() ([]Season, error) {
+func (db *database) GetSeriesByID(ctx context.Context, seriesID int) (Series, error) {
+	series := Series{}
+	if err := db.GetContext(ctx, &series, `
+		select`+seriesColumns+`
+		where s.pk_series_id = $1`, seriesID); err != nil {
+		return series, err
+	}
+	return series, nil
+}
+
+// UpsertSeries inserts a new series if it has no SeriesID yet, or updates the existing one otherwise
+func (db *database) UpsertSeries(ctx context.Context, series Series) (Series, error) {
+	active := series.Active != "false"
+	apiSeriesID := &series.APISeriesID
+	if series.APISeriesID <= 0 {
+		apiSeriesID = nil
+	}
+
+	if series.SeriesID <= 0 {
+		if err := db.GetContext(ctx, &series.SeriesID, `
+			insert into series
+				(name, short_name, regex, colorscheme, active, pending, api_series_id)
+			values ($1, $2, $3, $4, $5, $6, $7)
+			returning pk_series_id`,
+			series.SeriesName, series.SeriesNameShort, series.SeriesRegex, series.ColorScheme, active, series.Pending, apiSeriesID); err != nil {
+			return Series{}, err
+		}
+		return db.GetSeriesByID(ctx, series.SeriesID)
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		update series
+		set name = $2,
+			short_name = $3,
+			regex = $4,
+			colorscheme = $5,
+			active = $6,
+			pending = $7,
+			api_series_id = $8
+		where pk_series_id = $1`,
+		series.SeriesID, series.SeriesName, series.SeriesNameShort, series.SeriesRegex, series.ColorScheme, active, series.Pending, apiSeriesID); err != nil {
+		return Series{}, err
+	}
+	return db.GetSeriesByID(ctx, series.SeriesID)
+}
+
+// DeactivateSeries stops a series from being collected, while keeping everything collected so far.
+// Pending series are rejected that way, they stay known and are not discovered again.
+func (db *database) DeactivateSeries(ctx context.Context, seriesID int) error {
+	_, err := db.ExecContext(ctx, `
+		update series
+		set active = false,
+			pending = false
+		where pk_series_id = $1`, seriesID)
+	return err
+}
+
+func (db *database) GetSeasons(ctx context.Context) ([]Season, error) {
 	seasons := make([]Season, 0)
-	if err := db.Select(&seasons, `
+	if err := db.SelectContext(ctx, &seasons, `
 		select
 			s.pk_season_id,
 			s.fk_series_id,
@@ -133,9 +237,9 @@ func (db *database) GetSeasons() ([]Season, error) {
 	return seasons, nil
 }
 
-func (db *database) GetSeasonsBySeriesID(seriesID int) ([]Season, error) {
+func (db *database) GetSeasonsBySeriesID(ctx context.Context, seriesID int) ([]Season, error) {
 	seasons := make([]Season, 0)
-	if err := db.Select(&seasons, `
+	if err := db.SelectContext(ctx, &seasons, `
 		select
 			s.pk_season_id,
 			s.fk_series_id,
@@ -159,9 +263,9 @@ func (db *database) GetSeasonsBySeriesID(seriesID int) ([]Season, error) {
 	return seasons, nil
 }
 
-func (db *database) GetSeasonsByAPISeriesID(apiSeriesID int) ([]Season, error) {
+func (db *database) GetSeasonsByAPISeriesID(ctx context.Context, apiSeriesID int) ([]Season, error) {
 	seasons := make([]Season, 0)
-	if err := db.Select(&seasons, `
+	if err := db.SelectContext(ctx, &seasons, `
 		select
 			s.pk_season_id,
 			s.fk_series_id,
@@ -185,9 +289,9 @@ func (db *database) GetSeasonsByAPISeriesID(apiSeriesID int) ([]Season, error) {
 	return seasons, nil
 }
 
-func (db *database) GetSeasonByID(seasonID int) (Season, error) {
+func (db *database) GetSeasonByID(ctx context.Context, seasonID int) (Season, error) {
 	season := Season{}
-	if err := db.Get(&season, `
+	if err := db.GetContext(ctx, &season, `
 		select
 			s.pk_season_id,
 			s.fk_series_id,
@@ -210,13 +314,13 @@ func (db *database) GetSeasonByID(seasonID int) (Season, error) {
 	return season, nil
 }
 
-func (db *database) UpsertSeason(season Season) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertSeason(ctx context.Context, season Season) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into seasons
 			(pk_season_id, fk_series_id, year, quarter, category, name, short_name, banner_image, panel_image, logo_image, timeslots, startdate)
 		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
@@ -238,7 +342,7 @@ func (db *database) UpsertSeason(season Season) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		season.SeasonID, season.SeriesID, season.Year, season.Quarter,
 		season.Category, season.SeasonName, season.SeasonNameShort,
 		season.BannerImage, season.PanelImage, season.LogoImage, season.Timeslots, season.StartDate); err != nil {
@@ -248,13 +352,13 @@ func (db *database) UpsertSeason(season Season) error {
 	return tx.Commit()
 }
 
-func (db *database) UpsertTrack(track Track) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertTrack(ctx context.Context, track Track) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into tracks
 			(pk_track_id, name, config, category, free_with_subscription, retired, is_dirt, is_oval, banner_image, panel_image, logo_image, map_image, config_image)
 		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
@@ -277,7 +381,7 @@ func (db *database) UpsertTrack(track Track) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		track.TrackID, track.Name, track.Config, track.Category,
 		track.Free, track.Retired, track.IsDirt, track.IsOval,
 		track.BannerImage, track.PanelImage, track.LogoImage, track.MapImage, track.ConfigImage); err != nil {
@@ -287,13 +391,13 @@ func (db *database) UpsertTrack(track Track) error {
 	return tx.Commit()
 }
 
-func (db *database) UpsertCar(car Car) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertCar(ctx context.Context, car Car) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into cars
 			(pk_car_id, name, description, model, make, panel_image, logo_image, car_image, abbreviation, free_with_subscription, retired)
 		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
@@ -314,7 +418,7 @@ func (db *database) UpsertCar(car Car) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		car.CarID, car.Name, car.Description, car.Model, car.Make,
 		car.PanelImage, car.LogoImage, car.CarImage,
 		car.Abbreviation, car.Free, car.Retired); err != nil {
@@ -324,9 +428,9 @@ func (db *database) UpsertCar(car Car) error {
 	return tx.Commit()
 }
 
-func (db *database) GetCarByID(id int) (Car, error) {
+func (db *database) GetCarByID(ctx context.Context, id int) (Car, error) {
 	car := Car{}
-	if err := db.Get(&car, `
+	if err := db.GetContext(ctx, &car, `
 		select
 			c.pk_car_id,
 			c.name,
@@ -346,9 +450,9 @@ func (db *database) GetCarByID(id int) (Car, error) {
 	return car, nil
 }
 
-func (db *database) GetCarsByRaceWeekID(raceweekID int) ([]Car, error) {
+func (db *database) GetCarsByRaceWeekID(ctx context.Context, raceweekID int) ([]Car, error) {
 	cars := make([]Car, 0)
-	if err := db.Select(&cars, `
+	if err := db.SelectContext(ctx, &cars, `
 		select
 			c.pk_car_id,
 			c.name,
@@ -366,7 +470,7 @@ func (db *database) GetCarsByRaceWeekID(raceweekID int) ([]Car, error) {
 			select
 				distinct c.pk_car_id
 			from cars c
-				join race_results rr on (rr.fk_car_id = c.pk_car_id)
+				join race_results rr on (rr.fk_car_id = c.pk_car_id and rr.simsession_number = 0)
 				join raceweek_results rwr on (rwr.subsession_id = rr.fk_subsession_id)
 			where rwr.fk_raceweek_id = $1
 		)
@@ -377,14 +481,15 @@ func (db *database) GetCarsByRaceWeekID(raceweekID int) ([]Car, error) {
 	return cars, nil
 }
 
-func (db *database) GetCarClassIDsByRaceWeekID(raceweekID int) ([]int, error) {
+func (db *database) GetCarClassIDsByRaceWeekID(ctx context.Context, raceweekID int) ([]int, error) {
 	carIDs := make([]int, 0)
-	if err := db.Select(&carIDs, `
+	if err := db.SelectContext(ctx, &carIDs, `
 		select
 			distinct rr.car_class_id
 		from race_results rr
 			join raceweek_results rwr on (rwr.subsession_id = rr.fk_subsession_id)
 		where rwr.fk_raceweek_id = $1
+		and rr.simsession_number = 0
 		order by rr.car_class_id asc
 		`, raceweekID); err != nil {
 		return nil, err
@@ -392,17 +497,17 @@ func (db *database) GetCarClassIDsByRaceWeekID(raceweekID int) ([]int, error) {
 	return carIDs, nil
 }
 
-func (db *database) UpsertTimeTrialResult(r TimeTrialResult) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertTimeTrialResult(ctx context.Context, r TimeTrialResult) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into time_trial_results
 			(fk_raceweek_id, fk_driver_id, car_class_id, rank, position, points, starts, wins, weeks, dropped, division, last_update)
 		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		on conflict on constraint uniq_time_trial_results do update
+		on conflict (fk_driver_id, fk_raceweek_id, car_class_id) do update
 		set rank = excluded.rank,
 			position = excluded.position,
 			points = excluded.points,
@@ -418,7 +523,7 @@ func (db *database) UpsertTimeTrialResult(r TimeTrialResult) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		r.RaceWeek.RaceWeekID, r.Driver.DriverID, r.CarClassID,
 		r.Rank, r.Position, r.Points, r.Starts,
 		r.Wins, r.Weeks, r.Dropped, r.Division,
@@ -430,13 +535,13 @@ func (db *database) UpsertTimeTrialResult(r TimeTrialResult) error {
 	return tx.Commit()
 }
 
-func (db *database) GetTimeTrialResultsBySeasonIDAndWeek(seasonID, week int) ([]TimeTrialResult, error) {
+func (db *database) GetTimeTrialResultsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]TimeTrialResult, error) {
 	results := make([]TimeTrialResult, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+currentTeam+`,
 			cl.pk_club_id,
 			cl.name,
 			rw.pk_raceweek_id,
@@ -478,13 +583,13 @@ func (db *database) GetTimeTrialResultsBySeasonIDAndWeek(seasonID, week int) ([]
 	return results, nil
 }
 
-func (db *database) GetTimeTrialResultsBySeasonIDWeekAndCarClass(seasonID, week, carClassID int) ([]TimeTrialResult, error) {
+func (db *database) GetTimeTrialResultsBySeasonIDWeekAndCarClass(ctx context.Context, seasonID, week, carClassID int) ([]TimeTrialResult, error) {
 	results := make([]TimeTrialResult, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+currentTeam+`,
 			cl.pk_club_id,
 			cl.name,
 			rw.pk_raceweek_id,
@@ -527,17 +632,17 @@ func (db *database) GetTimeTrialResultsBySeasonIDWeekAndCarClass(seasonID, week,
 	return results, nil
 }
 
-func (db *database) UpsertTimeRanking(r TimeRanking) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertTimeRanking(ctx context.Context, r TimeRanking) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into time_rankings
 			(fk_driver_id, fk_raceweek_id, fk_car_id, race, time_trial_subsession_id, time_trial, time_trial_fastest_lap, license_class, irating)
 		values ($1, $2, $3, null, $4, $5, $6, $7, $8)
-		on conflict on constraint uniq_time_ranking do update
+		on conflict (fk_driver_id, fk_raceweek_id, fk_car_id) do update
 		set race = null,
 			time_trial_subsession_id = excluded.time_trial_subsession_id,
 			time_trial_fastest_lap = excluded.time_trial_fastest_lap,
@@ -560,7 +665,7 @@ func (db *database) UpsertTimeRanking(r TimeRanking) error {
 		}
 	}
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		r.Driver.DriverID, r.RaceWeek.RaceWeekID, r.Car.CarID,
 		r.TimeTrialSubsessionID, null(r.TimeTrial), null(r.TimeTrialFastestLap), r.LicenseClass, r.IRating,
 	); err != nil {
@@ -570,17 +675,18 @@ func (db *database) UpsertTimeRanking(r TimeRanking) error {
 	return tx.Commit()
 }
 
-func (db *database) GetTimeRankingsBySeasonIDAndWeek(seasonID, week int) ([]TimeRanking, error) {
+func (db *database) GetTimeRankingsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]TimeRanking, error) {
 	rankings := make([]TimeRanking, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+currentTeam+`,
 			coalesce((select min(rr.division)
 				from race_results rr
 					join raceweek_results rwr on (rwr.subsession_id = rr.fk_subsession_id)
 				where rr.fk_driver_id = d.pk_driver_id
+				and rr.simsession_number = 0
 				and rwr.fk_raceweek_id = rw.pk_raceweek_id), 10)+1 as division,
 			cl.pk_club_id,
 			cl.name,
@@ -612,6 +718,7 @@ func (db *database) GetTimeRankingsBySeasonIDAndWeek(seasonID, week int) ([]Time
 				from race_results rr
 					join raceweek_results rwr on (rwr.subsession_id = rr.fk_subsession_id)
 				where rr.fk_driver_id = d.pk_driver_id
+				and rr.simsession_number = 0
 				and rwr.fk_raceweek_id = rw.pk_raceweek_id
 				and rr.best_laptime > 0), coalesce((select min(coalesce(tr.race , 0))
 				from time_rankings tr
@@ -626,6 +733,7 @@ func (db *database) GetTimeRankingsBySeasonIDAndWeek(seasonID, week int) ([]Time
 			join clubs cl on (d.fk_club_id = cl.pk_club_id)
 			join cars c on (rr.fk_car_id = c.pk_car_id)
 		where rw.fk_season_id = $1
+		and rr.simsession_number = 0
 		and rw.raceweek = $2
 		order by d.name asc`, seasonID, week)
 	if err != nil {
@@ -650,13 +758,13 @@ func (db *database) GetTimeRankingsBySeasonIDAndWeek(seasonID, week int) ([]Time
 	return rankings, nil
 }
 
-func (db *database) GetFastestTimeTrialSessionsBySeasonIDAndWeek(seasonID, week int) ([]FastestLaptime, error) {
+func (db *database) GetFastestTimeTrialSessionsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]FastestLaptime, error) {
 	laptimes := make([]FastestLaptime, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, '') as team,
+			`+currentTeam+` as team,
 			coalesce(tr.time_trial, 0) as time_trial
 		from time_rankings tr
 			join cars c on (tr.fk_car_id = c.pk_car_id)
@@ -683,13 +791,13 @@ func (db *database) GetFastestTimeTrialSessionsBySeasonIDAndWeek(seasonID, week
 	return laptimes, nil
 }
 
-func (db *database) GetFastestRaceLaptimesBySeasonIDAndWeek(seasonID, week int) ([]FastestLaptime, error) {
+func (db *database) GetFastestRaceLaptimesBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]FastestLaptime, error) {
 	laptimes := make([]FastestLaptime, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, '') as team,
+			`+teamOfWeek+` as team,
 			coalesce(min(rr.division), 10)+1 as division,
 			coalesce(min(rr.best_laptime), 0) as race
 		from race_results rr
@@ -699,9 +807,10 @@ func (db *database) GetFastestRaceLaptimesBySeasonIDAndWeek(seasonID, week int)
 			join clubs cl on (d.fk_club_id = cl.pk_club_id)
 			join cars c on (rr.fk_car_id = c.pk_car_id)
 		where rw.fk_season_id = $1
+		and rr.simsession_number = 0
 		and rw.raceweek = $2
 		and rr.best_laptime > 0
-		group by d.pk_driver_id, d.name, team, division
+		group by d.pk_driver_id, d.name, division
 		order by race asc, d.name asc`, seasonID, week)
 	if err != nil {
 		return nil, err
@@ -720,14 +829,14 @@ func (db *database) GetFastestRaceLaptimesBySeasonIDAndWeek(seasonID, week int)
 	return laptimes, nil
 }
 
-func (db *database) InsertRaceWeek(raceweek RaceWeek) (RaceWeek, error) {
-	if rw, err := db.GetRaceWeekBySeasonIDAndWeek(raceweek.SeasonID, raceweek.RaceWeek); err == nil && rw.SeasonID > 0 {
+func (db *database) InsertRaceWeek(ctx context.Context, raceweek RaceWeek) (RaceWeek, error) {
+	if rw, err := db.GetRaceWeekBySeasonIDAndWeek(ctx, raceweek.SeasonID, raceweek.RaceWeek); err == nil && rw.SeasonID > 0 {
 		return rw, nil
 	} else {
 		log.Warnf("could not read raceweek [%d:%d] from database: %v", raceweek.SeasonID, raceweek.RaceWeek, err)
 	}
 
-	stmt, err := db.Preparex(`
+	stmt, err := db.PreparexContext(ctx, `
 		insert into raceweeks
 			(raceweek, fk_track_id, fk_season_id, last_update)
 		values ($1, $2, $3, $4)`)
@@ -736,15 +845,15 @@ func (db *database) InsertRaceWeek(raceweek RaceWeek) (RaceWeek, error) {
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(
+	if _, err := stmt.ExecContext(ctx,
 		raceweek.RaceWeek, raceweek.TrackID, raceweek.SeasonID, time.Now()); err != nil {
 		return RaceWeek{}, err
 	}
-	return db.GetRaceWeekBySeasonIDAndWeek(raceweek.SeasonID, raceweek.RaceWeek)
+	return db.GetRaceWeekBySeasonIDAndWeek(ctx, raceweek.SeasonID, raceweek.RaceWeek)
 }
 
-func (db *database) UpdateRaceWeekLastUpdateToNow(id int) error {
-	stmt, err := db.Preparex(`
+func (db *database) UpdateRaceWeekLastUpdateToNow(ctx context.Context, id int) error {
+	stmt, err := db.PreparexContext(ctx, `
 		update raceweeks
 		set last_update = $1
 		where pk_raceweek_id = $2`)
@@ -753,15 +862,15 @@ func (db *database) UpdateRaceWeekLastUpdateToNow(id int) error {
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(time.Now(), id); err != nil {
+	if _, err := stmt.ExecContext(ctx, time.Now(), id); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (db *database) GetRaceWeekByID(id int) (RaceWeek, error) {
+func (db *database) GetRaceWeekByID(ctx context.Context, id int) (RaceWeek, error) {
 	raceweek := RaceWeek{}
-	if err := db.Get(&raceweek, `
+	if err := db.GetContext(ctx, &raceweek, `
 		select
 			r.pk_raceweek_id,
 			r.raceweek,
@@ -775,9 +884,9 @@ func (db *database) GetRaceWeekByID(id int) (RaceWeek, error) {
 	return raceweek, nil
 }
 
-func (db *database) GetRaceWeekBySeasonIDAndWeek(seasonID, week int) (RaceWeek, error) {
+func (db *database) GetRaceWeekBySeasonIDAndWeek(ctx context.Context, seasonID, week int) (RaceWeek, error) {
 	raceweek := RaceWeek{}
-	if err := db.Get(&raceweek, `
+	if err := db.GetContext(ctx, &raceweek, `
 		select
 			r.pk_raceweek_id,
 			r.raceweek,
@@ -792,12 +901,12 @@ func (db *database) GetRaceWeekBySeasonIDAndWeek(seasonID, week int) (RaceWeek,
 	return raceweek, nil
 }
 
-func (db *database) InsertRaceWeekResult(result RaceWeekResult) (RaceWeekResult, error) {
-	if r, err := db.GetRaceWeekResultBySubsessionID(result.SubsessionID); err == nil && r.SubsessionID > 0 {
+func (db *database) InsertRaceWeekResult(ctx context.Context, result RaceWeekResult) (RaceWeekResult, error) {
+	if r, err := db.GetRaceWeekResultBySubsessionID(ctx, result.SubsessionID); err == nil && r.SubsessionID > 0 {
 		return r, nil
 	}
 
-	stmt, err := db.Preparex(`
+	stmt, err := db.PreparexContext(ctx, `
 		insert into raceweek_results
 			(fk_raceweek_id, starttime, fk_track_id, session_id, subsession_id, official, size, sof)
 		values ($1, $2, $3, $4, $5, $6, $7, $8)`)
@@ -806,17 +915,17 @@ func (db *database) InsertRaceWeekResult(result RaceWeekResult) (RaceWeekResult,
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(
+	if _, err = stmt.ExecContext(ctx,
 		result.RaceWeekID, result.StartTime, result.TrackID,
 		result.SessionID, result.SubsessionID, result.Official, result.SizeOfField, result.StrengthOfField); err != nil {
 		return RaceWeekResult{}, err
 	}
-	return db.GetRaceWeekResultBySubsessionID(result.SubsessionID)
+	return db.GetRaceWeekResultBySubsessionID(ctx, result.SubsessionID)
 }
 
-func (db *database) GetRaceWeekResultBySubsessionID(subsessionID int) (RaceWeekResult, error) {
+func (db *database) GetRaceWeekResultBySubsessionID(ctx context.Context, subsessionID int) (RaceWeekResult, error) {
 	result := RaceWeekResult{}
-	if err := db.Get(&result, `
+	if err := db.GetContext(ctx, &result, `
 		select
 			r.fk_raceweek_id,
 			r.starttime,
@@ -833,9 +942,9 @@ func (db *database) GetRaceWeekResultBySubsessionID(subsessionID int) (RaceWeekR
 	return result, nil
 }
 
-func (db *database) GetRaceWeekResultsBySeasonIDAndWeek(seasonID, week int) ([]RaceWeekResult, error) {
+func (db *database) GetRaceWeekResultsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]RaceWeekResult, error) {
 	results := make([]RaceWeekResult, 0)
-	if err := db.Select(&results, `
+	if err := db.SelectContext(ctx, &results, `
 		select
 			rr.fk_raceweek_id,
 			rr.starttime,
@@ -855,24 +964,25 @@ func (db *database) GetRaceWeekResultsBySeasonIDAndWeek(seasonID, week int) ([]R
 	return results, nil
 }
 
-func (db *database) GetRaceWeekMetricsBySeasonID(seasonID int) ([]RaceWeekMetrics, error) {
+func (db *database) GetRaceWeekMetricsBySeasonID(ctx context.Context, seasonID int) ([]RaceWeekMetrics, error) {
 	results := make([]RaceWeekMetrics, 0)
-	if err := db.Select(&results, `
+	if err := db.SelectContext(ctx, &results, `
 		select
 			rw.fk_season_id as season_id,
 			rw.raceweek+1 as raceweek,
 			rs.simulated_starttime time_of_day,
 			max(rs.laps) as laps,
-			ceil(avg(rs.cautions)) as avg_cautions,
-			round(avg(rs.avg_laptime)) as avg_laptime,
+			cast(ceil(avg(rs.cautions)) as integer) as avg_cautions,
+			cast(round(avg(rs.avg_laptime)) as integer) as avg_laptime,
 			min(coalesce((select min(rr.best_laptime)
 				from race_results rr
 				where rwr.subsession_id = rr.fk_subsession_id
+				and rr.simsession_number = 0
 				and rr.best_laptime > 0), coalesce(tr.race, 0))) as fastest_laptime,
 			max(rwr.sof) as max_sof,
 			min(rwr.sof) as min_sof,
-			round(avg(rwr.sof)) as avg_sof,
-			round(avg(rwr.size)) as avg_size
+			cast(round(avg(rwr.sof)) as integer) as avg_sof,
+			cast(round(avg(rwr.size)) as integer) as avg_size
 		from race_stats rs
 			join raceweek_results rwr on rwr.subsession_id = rs.fk_subsession_id
 			join raceweeks rw on rw.pk_raceweek_id = rwr.fk_raceweek_id
@@ -886,24 +996,25 @@ func (db *database) GetRaceWeekMetricsBySeasonID(seasonID int) ([]RaceWeekMetric
 	return results, nil
 }
 
-func (db *database) GetRaceWeekMetricsBySeasonIDAndWeek(seasonID, week int) (RaceWeekMetrics, error) {
+func (db *database) GetRaceWeekMetricsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) (RaceWeekMetrics, error) {
 	result := RaceWeekMetrics{}
-	if err := db.Get(&result, `
+	if err := db.GetContext(ctx, &result, `
 		select
 			rw.fk_season_id as season_id,
 			rw.raceweek+1 as raceweek,
 			rs.simulated_starttime time_of_day,
 			max(rs.laps) as laps,
-			ceil(avg(rs.cautions)) as avg_cautions,
-			round(avg(rs.avg_laptime)) as avg_laptime,
+			cast(ceil(avg(rs.cautions)) as integer) as avg_cautions,
+			cast(round(avg(rs.avg_laptime)) as integer) as avg_laptime,
 			min(coalesce((select min(rr.best_laptime)
 				from race_results rr
 				where rwr.subsession_id = rr.fk_subsession_id
+				and rr.simsession_number = 0
 				and rr.best_laptime > 0), coalesce(tr.race, 0))) as fastest_laptime,
 			max(rwr.sof) as max_sof,
 			min(rwr.sof) as min_sof,
-			round(avg(rwr.sof)) as avg_sof,
-			round(avg(rwr.size)) as avg_size
+			cast(round(avg(rwr.sof)) as integer) as avg_sof,
+			cast(round(avg(rwr.size)) as integer) as avg_size
 		from race_stats rs
 			join raceweek_results rwr on rwr.subsession_id = rs.fk_subsession_id
 			join raceweeks rw on rw.pk_raceweek_id = rwr.fk_raceweek_id
@@ -918,12 +1029,12 @@ func (db *database) GetRaceWeekMetricsBySeasonIDAndWeek(seasonID, week int) (Rac
 	return result, nil
 }
 
-func (db *database) InsertRaceStats(racestats RaceStats) (RaceStats, error) {
-	if rs, err := db.GetRaceStatsBySubsessionID(racestats.SubsessionID); err == nil && rs.SubsessionID > 0 {
+func (db *database) InsertRaceStats(ctx context.Context, racestats RaceStats) (RaceStats, error) {
+	if rs, err := db.GetRaceStatsBySubsessionID(ctx, racestats.SubsessionID); err == nil && rs.SubsessionID > 0 {
 		return rs, nil
 	}
 
-	stmt, err := db.Preparex(`
+	stmt, err := db.PreparexContext(ctx, `
 		insert into race_stats
 			(fk_subsession_id, starttime, simulated_starttime, lead_changes, laps,
 			cautions, caution_laps, corners_per_lap, avg_laptime, avg_quali_laps, weather_rh, weather_temp)
@@ -933,18 +1044,18 @@ func (db *database) InsertRaceStats(racestats RaceStats) (RaceStats, error) {
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(
+	if _, err := stmt.ExecContext(ctx,
 		racestats.SubsessionID, racestats.StartTime, racestats.SimulatedStartTime, racestats.LeadChanges,
 		racestats.Laps, racestats.Cautions, racestats.CautionLaps, racestats.CornersPerLap,
 		racestats.AvgLaptime, racestats.AvgQualiLaps, racestats.WeatherRH, racestats.WeatherTemp); err != nil {
 		return RaceStats{}, err
 	}
-	return db.GetRaceStatsBySubsessionID(racestats.SubsessionID)
+	return db.GetRaceStatsBySubsessionID(ctx, racestats.SubsessionID)
 }
 
-func (db *database) GetRaceStatsBySubsessionID(subsessionID int) (RaceStats, error) {
+func (db *database) GetRaceStatsBySubsessionID(ctx context.Context, subsessionID int) (RaceStats, error) {
 	racestats := RaceStats{}
-	if err := db.Get(&racestats, `
+	if err := db.GetContext(ctx, &racestats, `
 		select
 			r.fk_subsession_id,
 			r.starttime,
@@ -965,9 +1076,25 @@ func (db *database) GetRaceStatsBySubsessionID(subsessionID int) (RaceStats, err
 	return racestats, nil
 }
 
-func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, error) {
+func (db *database) GetAverageRaceLengthBySeasonID(ctx context.Context, seasonID int) (time.Duration, error) {
+	var length Laptime
+	if err := db.GetContext(ctx, &length, `
+		select
+			coalesce(cast(round(avg(r.laps * r.avg_laptime)) as integer), 0)
+		from race_stats r
+			join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
+		where rw.fk_season_id = $1
+		and r.laps > 0
+		and r.avg_laptime > 0`, seasonID); err != nil {
+		return 0, err
+	}
+	return time.Duration(length*100) * time.Microsecond, nil
+}
+
+func (db *database) GetSeasonMetricsBySeriesID(ctx context.Context, seriesID int) ([]SeasonMetrics, error) {
 	results := make([]SeasonMetrics, 0)
-	if err := db.Select(&results, `
+	if err := db.SelectContext(ctx, &results, `
 		select
 			s.fk_series_id as series_id,
 			s.year,
@@ -975,15 +1102,15 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 			s.timeslots,
 			max(rw.raceweek+1) as weeks,
 			count(rwr.subsession_id) as nof_sessions,
-			round(avg(rwr.size)) as avg_size,
-			round(avg(rwr.sof)) as avg_sof,
+			cast(round(avg(rwr.size)) as integer) as avg_size,
+			cast(round(avg(rwr.sof)) as integer) as avg_sof,
 			(
 				select
 					count(d.pk_driver_id)
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -994,7 +1121,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1006,7 +1133,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join tracks t on t.pk_track_id = rw.fk_track_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1022,7 +1149,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1032,7 +1159,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 						join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 						join tracks t on t.pk_track_id = rw.fk_track_id
 						join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-						join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+						join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 						join drivers d on d.pk_driver_id = rr.fk_driver_id
 					where rwr.official = true
 					and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1051,7 +1178,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join tracks t on t.pk_track_id = rw.fk_track_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1067,7 +1194,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1077,7 +1204,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 						join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 						join tracks t on t.pk_track_id = rw.fk_track_id
 						join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-						join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+						join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 						join drivers d on d.pk_driver_id = rr.fk_driver_id
 					where rwr.official = true
 					and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1096,7 +1223,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join tracks t on t.pk_track_id = rw.fk_track_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1107,7 +1234,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 						join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 						join tracks t on t.pk_track_id = rw.fk_track_id
 						join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-						join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+						join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 						join drivers d on d.pk_driver_id = rr.fk_driver_id
 					where rwr.official = true
 					and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1124,7 +1251,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1144,7 +1271,7 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 				from seasons s2
 					join raceweeks rw on rw.fk_season_id = s2.pk_season_id
 					join raceweek_results rwr on rwr.fk_raceweek_id = rw.pk_raceweek_id
-					join race_results rr on rr.fk_subsession_id = rwr.subsession_id
+					join race_results rr on rr.fk_subsession_id = rwr.subsession_id and rr.simsession_number = 0
 					join drivers d on d.pk_driver_id = rr.fk_driver_id
 				where rwr.official = true
 				and s2.fk_series_id = s.fk_series_id and s2.year = s.year and s2.quarter = s.quarter and s2.timeslots = s.timeslots
@@ -1166,13 +1293,13 @@ func (db *database) GetSeasonMetricsBySeriesID(seriesID int) ([]SeasonMetrics, e
 	return results, nil
 }
 
-func (db *database) UpsertClub(club Club) error {
-	tx, err := db.Beginx()
+func (db *database) UpsertClub(ctx context.Context, club Club) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into clubs
 			(pk_club_id, name)
 		values ($1, $2)
@@ -1184,20 +1311,22 @@ func (db *database) UpsertClub(club Club) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(club.ClubID, club.Name); err != nil {
+	if _, err = stmt.ExecContext(ctx, club.ClubID, club.Name); err != nil {
 		tx.Rollback()
 		return err
 	}
 	return tx.Commit()
 }
 
-func (db *database) UpsertDriver(driver Driver) error {
-	tx, err := db.Beginx()
+// UpsertDriver stores a driver and the name it was seen with at the given time, which is the start of the race for race results.
+// Using the time of the race keeps the name history intact when older seasons are backfilled or replayed.
+func (db *database) UpsertDriver(ctx context.Context, driver Driver, seen time.Time) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Preparex(`
+	stmt, err := tx.PreparexContext(ctx, `
 		insert into drivers
 			(pk_driver_id, name, fk_club_id)
 		values ($1, $2, $3)
@@ -1210,19 +1339,52 @@ func (db *database) UpsertDriver(driver Driver) error {
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(driver.DriverID, driver.Name, driver.Club.ClubID); err != nil {
+	if _, err = stmt.ExecContext(ctx, driver.DriverID, driver.Name, driver.Club.ClubID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// keep track of every name the driver has been seen with
+	nameStmt, err := tx.PreparexContext(ctx, `
+		insert into driver_names
+			(fk_driver_id, name, first_seen, last_seen)
+		values ($1, $2, $3, $3)
+		on conflict (fk_driver_id, name) do update
+		set first_seen = case when excluded.first_seen < driver_names.first_seen then excluded.first_seen else driver_names.first_seen end,
+			last_seen = case when excluded.last_seen > driver_names.last_seen then excluded.last_seen else driver_names.last_seen end`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer nameStmt.Close()
+
+	if _, err = nameStmt.ExecContext(ctx, driver.DriverID, driver.Name, seen.UTC().Truncate(time.Second)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the current name is the most recently seen one, not the one of whatever race was stored last
+	if _, err = tx.ExecContext(ctx, `
+		update drivers
+		set name = (
+			select n.name
+			from driver_names n
+			where n.fk_driver_id = drivers.pk_driver_id
+			order by n.last_seen desc
+			limit 1)
+		where pk_driver_id = $1`, driver.DriverID); err != nil {
 		tx.Rollback()
 		return err
 	}
 	return tx.Commit()
 }
 
-func (db *database) InsertRaceResult(result RaceResult) (RaceResult, error) {
-	if rr, err := db.GetRaceResultBySubsessionIDAndDriverID(result.SubsessionID, result.Driver.DriverID); err == nil && rr.SubsessionID > 0 {
+func (db *database) InsertRaceResult(ctx context.Context, result RaceResult) (RaceResult, error) {
+	if rr, err := db.getRaceResult(ctx, result.SubsessionID, result.SimsessionNumber, result.Driver.DriverID); err == nil && rr.SubsessionID > 0 {
 		return rr, nil
 	}
 
-	stmt, err := db.Preparex(`
+	stmt, err := db.PreparexContext(ctx, `
 		insert into race_results
 			(fk_subsession_id, fk_driver_id,
 			old_irating, new_irating, old_license_level, new_license_level,
@@ -1231,10 +1393,10 @@ func (db *database) InsertRaceResult(result RaceResult) (RaceResult, error) {
 			fk_car_id, car_class_id,
 			starting_position, position, finishing_position, finishing_position_in_class,
 			division, interval, class_interval, avg_laptime, best_laptime,
-			laps_completed, laps_lead, incidents, reason_out, session_starttime)
+			laps_completed, laps_lead, incidents, reason_out, session_starttime, simsession_number)
 		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-				$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
-		on conflict on constraint uniq_race_result do update
+				$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
+		on conflict (fk_subsession_id, simsession_number, fk_driver_id) do update
 		set old_irating = excluded.old_irating,
 			new_irating = excluded.new_irating,
 			old_license_level = excluded.old_license_level,
@@ -1267,7 +1429,7 @@ func (db *database) InsertRaceResult(result RaceResult) (RaceResult, error) {
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(
+	if _, err := stmt.ExecContext(ctx,
 		result.SubsessionID, result.Driver.DriverID,
 		result.IRatingBefore, result.IRatingAfter, result.LicenseLevelBefore, result.LicenseLevelAfter,
 		result.SafetyRatingBefore, result.SafetyRatingAfter, result.CPIBefore, result.CPIAfter,
@@ -1275,22 +1437,28 @@ func (db *database) InsertRaceResult(result RaceResult) (RaceResult, error) {
 		result.CarID, result.CarClassID,
 		result.StartingPosition, result.Position, result.FinishingPosition, result.FinishingPositionInClass,
 		result.Division, result.Interval, result.ClassInterval, result.AvgLaptime, result.BestLaptime,
-		result.LapsCompleted, result.LapsLead, result.Incidents, result.ReasonOut, result.SessionStartTime); err != nil {
+		result.LapsCompleted, result.LapsLead, result.Incidents, result.ReasonOut, result.SessionStartTime,
+		result.SimsessionNumber); err != nil {
 		return RaceResult{}, err
 	}
-	return db.GetRaceResultBySubsessionIDAndDriverID(result.SubsessionID, result.Driver.DriverID)
+	return db.getRaceResult(ctx, result.SubsessionID, result.SimsessionNumber, result.Driver.DriverID)
 }
 
-func (db *database) GetRaceResultBySubsessionIDAndDriverID(subsessionID, driverID int) (RaceResult, error) {
+func (db *database) GetRaceResultBySubsessionIDAndDriverID(ctx context.Context, subsessionID, driverID int) (RaceResult, error) {
+	return db.getRaceResult(ctx, subsessionID, 0, driverID)
+}
+
+func (db *database) getRaceResult(ctx context.Context, subsessionID, simsessionNumber, driverID int) (RaceResult, error) {
 	r := RaceResult{}
-	if err := db.QueryRowx(`
+	if err := db.QueryRowxContext(ctx, `
 		select
 			r.fk_subsession_id,
+			r.simsession_number,
 			c.pk_club_id,
 			c.name,
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+teamOf("r.fk_driver_id", "r.session_starttime")+`,
 			r.division,
 			r.old_irating,
 			r.new_irating,
@@ -1323,8 +1491,9 @@ func (db *database) GetRaceResultBySubsessionIDAndDriverID(subsessionID, driverI
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
 			join clubs c on (d.fk_club_id = c.pk_club_id)
 		where r.fk_subsession_id = $1
-		and r.fk_driver_id = $2`, subsessionID, driverID).Scan(
-		&r.SubsessionID,
+		and r.simsession_number = $2
+		and r.fk_driver_id = $3`, subsessionID, simsessionNumber, driverID).Scan(
+		&r.SubsessionID, &r.SimsessionNumber,
 		&r.Driver.Club.ClubID, &r.Driver.Club.Name,
 		&r.Driver.DriverID, &r.Driver.Name, &r.Driver.Team, &r.Driver.Division,
 		&r.IRatingBefore, &r.IRatingAfter, &r.LicenseLevelBefore, &r.LicenseLevelAfter,
@@ -1340,16 +1509,17 @@ func (db *database) GetRaceResultBySubsessionIDAndDriverID(subsessionID, driverI
 	return r, nil
 }
 
-func (db *database) GetRaceResultsBySubsessionID(subsessionID int) ([]RaceResult, error) {
+func (db *database) GetRaceResultsBySubsessionID(ctx context.Context, subsessionID int) ([]RaceResult, error) {
 	results := make([]RaceResult, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select
 			r.fk_subsession_id,
+			r.simsession_number,
 			c.pk_club_id,
 			c.name,
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+teamOf("r.fk_driver_id", "r.session_starttime")+`,
 			r.division,
 			r.old_irating,
 			r.new_irating,
@@ -1382,7 +1552,7 @@ func (db *database) GetRaceResultsBySubsessionID(subsessionID int) ([]RaceResult
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
 			join clubs c on (d.fk_club_id = c.pk_club_id)
 		where r.fk_subsession_id = $1
-		order by r.finishing_position asc, r.champpoints desc, d.name asc`, subsessionID)
+		order by r.simsession_number desc, r.finishing_position asc, r.champpoints desc, d.name asc`, subsessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -1391,7 +1561,7 @@ func (db *database) GetRaceResultsBySubsessionID(subsessionID int) ([]RaceResult
 	for rows.Next() {
 		r := RaceResult{}
 		if err := rows.Scan(
-			&r.SubsessionID,
+			&r.SubsessionID, &r.SimsessionNumber,
 			&r.Driver.Club.ClubID, &r.Driver.Club.Name,
 			&r.Driver.DriverID, &r.Driver.Name, &r.Driver.Team, &r.Driver.Division,
 			&r.IRatingBefore, &r.IRatingAfter, &r.LicenseLevelBefore, &r.LicenseLevelAfter,
@@ -1409,16 +1579,17 @@ func (db *database) GetRaceResultsBySubsessionID(subsessionID int) ([]RaceResult
 	return results, nil
 }
 
-func (db *database) GetRaceResultsBySeasonIDAndWeek(seasonID, week int) ([]RaceResult, error) {
+func (db *database) GetRaceResultsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]RaceResult, error) {
 	results := make([]RaceResult, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select
 			r.fk_subsession_id,
+			r.simsession_number,
 			c.pk_club_id,
 			c.name,
 			d.pk_driver_id,
 			d.name,
-			coalesce(d.team, ''),
+			`+teamOf("r.fk_driver_id", "r.session_starttime")+`,
 			r.division,
 			r.old_irating,
 			r.new_irating,
@@ -1453,6 +1624,7 @@ func (db *database) GetRaceResultsBySeasonIDAndWeek(seasonID, week int) ([]RaceR
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
 			join clubs c on (d.fk_club_id = c.pk_club_id)
 		where rw.fk_season_id = $1
+		and r.simsession_number = 0
 		and rw.raceweek = $2
 		order by r.finishing_position asc, r.champpoints desc, d.name asc`, seasonID, week)
 	if err != nil {
@@ -1463,7 +1635,7 @@ func (db *database) GetRaceResultsBySeasonIDAndWeek(seasonID, week int) ([]RaceR
 	for rows.Next() {
 		r := RaceResult{}
 		if err := rows.Scan(
-			&r.SubsessionID,
+			&r.SubsessionID, &r.SimsessionNumber,
 			&r.Driver.Club.ClubID, &r.Driver.Club.Name,
 			&r.Driver.DriverID, &r.Driver.Name, &r.Driver.Team, &r.Driver.Division,
 			&r.IRatingBefore, &r.IRatingAfter, &r.LicenseLevelBefore, &r.LicenseLevelAfter,
@@ -1481,16 +1653,16 @@ func (db *database) GetRaceResultsBySeasonIDAndWeek(seasonID, week int) ([]RaceR
 	return results, nil
 }
 
-func (db *database) GetPointsBySeasonIDAndWeek(seasonID, week int) ([]Points, error) {
+func (db *database) GetPointsBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]Points, error) {
 	points := make([]Points, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			x.subsession_id,
 			c.pk_club_id,
 			c.name as club_name,
 			x.driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team,
+			`+teamOf("x.driver_id", "x.session_starttime")+` as driver_team,
 			coalesce(x.division,10)+1 as division,
 			x.champ_points
 		from (
@@ -1498,11 +1670,13 @@ func (db *database) GetPointsBySeasonIDAndWeek(seasonID, week int) ([]Points, er
 				r.fk_subsession_id as subsession_id,
 				r.fk_driver_id as driver_id,
 				r.division as division,
-				r.champpoints as champ_points
+				r.champpoints as champ_points,
+				r.session_starttime as session_starttime
 			from race_results r
 				join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
 				join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
 			where rw.fk_season_id = $1
+			and r.simsession_number = 0
 			and rw.raceweek = $2
 			and rr.official = true
 			order by driver_id asc, champ_points desc
@@ -1530,16 +1704,16 @@ func (db *database) GetPointsBySeasonIDAndWeek(seasonID, week int) ([]Points, er
 	return points, nil
 }
 
-func (db *database) GetPointsBySeasonIDAndWeekAndTrackCategory(seasonID, week int, category string) ([]Points, error) {
+func (db *database) GetPointsBySeasonIDAndWeekAndTrackCategory(ctx context.Context, seasonID, week int, category string) ([]Points, error) {
 	points := make([]Points, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			x.subsession_id,
 			c.pk_club_id,
 			c.name as club_name,
 			x.driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team,
+			`+teamOf("x.driver_id", "x.session_starttime")+` as driver_team,
 			coalesce(x.division,10)+1 as division,
 			x.champ_points
 		from (
@@ -1547,12 +1721,14 @@ func (db *database) GetPointsBySeasonIDAndWeekAndTrackCategory(seasonID, week in
 				r.fk_subsession_id as subsession_id,
 				r.fk_driver_id as driver_id,
 				r.division as division,
-				r.champpoints as champ_points
+				r.champpoints as champ_points,
+				r.session_starttime as session_starttime
 			from race_results r
 				join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
 				join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
 				join tracks tr on (tr.pk_track_id = rw.fk_track_id)
 			where rw.fk_season_id = $1
+			and r.simsession_number = 0
 			and rw.raceweek = $2
 			and lower(tr.category) = $3
 			and rr.official = true
@@ -1581,15 +1757,15 @@ func (db *database) GetPointsBySeasonIDAndWeekAndTrackCategory(seasonID, week in
 	return points, nil
 }
 
-func (db *database) GetDriverSummariesBySeasonIDAndWeek(seasonID, week int) ([]Summary, error) {
+func (db *database) GetDriverSummariesBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]Summary, error) {
 	summaries := make([]Summary, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			c.pk_club_id,
 			c.name as club_name,
 			d.pk_driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team,
+			`+teamOfWeek+` as driver_team,
 			r.division,
 			r.division,
 			max(r.new_irating - r.old_irating) as max_ir_gained,
@@ -1613,6 +1789,7 @@ func (db *database) GetDriverSummariesBySeasonIDAndWeek(seasonID, week int) ([]S
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
 			join clubs c on (d.fk_club_id = c.pk_club_id)
 		where rw.fk_season_id = $1
+		and r.simsession_number = 0
 		and rw.raceweek = $2
 		and rr.official = true
 		and r.laps_completed > 0
@@ -1639,15 +1816,16 @@ func (db *database) GetDriverSummariesBySeasonIDAndWeek(seasonID, week int) ([]S
 	return summaries, nil
 }
 
-func (db *database) GetDriverSummariesBySeasonIDAndWeekAndTeam(seasonID, week int, team string) ([]Summary, error) {
+// GetDriverSummariesBySeasonIDAndWeekAndTeam sums up the results of the drivers that were part of the team at the time of each race
+func (db *database) GetDriverSummariesBySeasonIDAndWeekAndTeam(ctx context.Context, seasonID, week int, team string) ([]Summary, error) {
 	summaries := make([]Summary, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			c.pk_club_id,
 			c.name as club_name,
 			d.pk_driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team,
+			t.name as driver_team,
 			r.division,
 			r.division,
 			max(r.new_irating - r.old_irating) as max_ir_gained,
@@ -1669,13 +1847,14 @@ func (db *database) GetDriverSummariesBySeasonIDAndWeekAndTeam(seasonID, week in
 			join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
 			join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
-			join clubs c on (d.fk_club_id = c.pk_club_id)
+			join clubs c on (d.fk_club_id = c.pk_club_id)`+teamAt+`
 		where rw.fk_season_id = $1
+		and r.simsession_number = 0
 		and rw.raceweek = $2
-		and d.team = $3
+		and t.name = $3
 		and rr.official = true
 		and r.laps_completed > 0
-		group by c.pk_club_id, c.name, d.pk_driver_id, d.name, r.division
+		group by c.pk_club_id, c.name, d.pk_driver_id, d.name, t.name, r.division
 		order by driver_name asc, max_champ_points desc, sum_club_points desc`, seasonID, week, team)
 	if err != nil {
 		return nil, err
@@ -1698,15 +1877,16 @@ func (db *database) GetDriverSummariesBySeasonIDAndWeekAndTeam(seasonID, week in
 	return summaries, nil
 }
 
-func (db *database) GetDriverSummariesBySeasonIDAndTeam(seasonID int, team string) ([]Summary, error) {
+// GetDriverSummariesBySeasonIDAndTeam sums up the results of the drivers that were part of the team at the time of each race
+func (db *database) GetDriverSummariesBySeasonIDAndTeam(ctx context.Context, seasonID int, team string) ([]Summary, error) {
 	summaries := make([]Summary, 0)
-	rows, err := db.Queryx(`
+	rows, err := db.QueryxContext(ctx, `
 		select distinct
 			c.pk_club_id,
 			c.name as club_name,
 			d.pk_driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team,
+			t.name as driver_team,
 			r.division,
 			r.division,
 			max(r.new_irating - r.old_irating) as max_ir_gained,
@@ -1728,12 +1908,13 @@ func (db *database) GetDriverSummariesBySeasonIDAndTeam(seasonID int, team strin
 			join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
 			join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
 			join drivers d on (r.fk_driver_id = d.pk_driver_id)
-			join clubs c on (d.fk_club_id = c.pk_club_id)
+			join clubs c on (d.fk_club_id = c.pk_club_id)`+teamAt+`
 		where rw.fk_season_id = $1
-		and d.team = $2
+		and r.simsession_number = 0
+		and t.name = $2
 		and rr.official = true
 		and r.laps_completed > 0
-		group by c.pk_club_id, c.name, d.pk_driver_id, d.name, r.division
+		group by c.pk_club_id, c.name, d.pk_driver_id, d.name, t.name, r.division
 		order by driver_name asc, avg_champ_points desc, sum_club_points desc`, seasonID, team)
 	if err != nil {
 		return nil, err
@@ -1756,9 +1937,9 @@ func (db *database) GetDriverSummariesBySeasonIDAndTeam(seasonID int, team strin
 	return summaries, nil
 }
 
-func (db *database) GetClubByID(id int) (Club, error) {
+func (db *database) GetClubByID(ctx context.Context, id int) (Club, error) {
 	club := Club{}
-	if err := db.Get(&club, `
+	if err := db.GetContext(ctx, &club, `
 		select
 			c.pk_club_id,
 			c.name
@@ -1769,15 +1950,15 @@ func (db *database) GetClubByID(id int) (Club, error) {
 	return club, nil
 }
 
-func (db *database) GetDriverByID(id int) (Driver, error) {
+func (db *database) GetDriverByID(ctx context.Context, id int) (Driver, error) {
 	d := Driver{}
-	if err := db.QueryRowx(`
+	if err := db.QueryRowxContext(ctx, `
 		select
 			c.name as club_name,
 			d.fk_club_id,
 			d.pk_driver_id,
 			d.name as driver_name,
-			coalesce(d.team, '') as driver_team
+			`+currentTeam+` as driver_team
 		from drivers d
 			join clubs c on (d.fk_club_id = c.pk_club_id)
 		where d.pk_driver_id = $1`, id).Scan(
@@ -1788,9 +1969,9 @@ func (db *database) GetDriverByID(id int) (Driver, error) {
 	return d, nil
 }
 
-func (db *database) GetTrackByID(id int) (Track, error) {
+func (db *database) GetTrackByID(ctx context.Context, id int) (Track, error) {
 	track := Track{}
-	if err := db.Get(&track, `
+	if err := db.GetContext(ctx, &track, `
 		select
 			t.pk_track_id,
 			t.name,
@@ -0,0 +1,639 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDatabase(t *testing.T) Database {
+	adapter := newSQLiteAdapter("sqlite://file::memory:")
+	t.Cleanup(func() { adapter.GetDatabase().Close() })
+	require.NoError(t, adapter.RunMigrations("migrations"))
+	return NewDatabase(adapter)
+}
+
+func seedTestDatabase(t *testing.T, db Database) (Season, RaceWeek) {
+	ctx := context.Background()
+	series, err := db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, series)
+
+	require.NoError(t, db.UpsertTrack(ctx, Track{TrackID: 413, Name: "Hungaroring", Category: "road"}))
+	require.NoError(t, db.UpsertCar(ctx, Car{CarID: 13, Name: "Radical SR8", Abbreviation: "SR8"}))
+
+	season := Season{
+		SeriesID:        series[0].SeriesID,
+		SeasonID:        3492,
+		Year:            2022,
+		Quarter:         1,
+		Category:        "-",
+		SeasonName:      "Radical Racing Challenge - 2022 Season 1",
+		SeasonNameShort: "2022 Season 1",
+		Timeslots:       "0 1-23/2 * * *",
+		StartDate:       time.Date(2021, 12, 14, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, db.UpsertSeason(ctx, season))
+
+	raceweek, err := db.InsertRaceWeek(ctx, RaceWeek{SeasonID: season.SeasonID, RaceWeek: 3, TrackID: 413})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateRaceWeekLastUpdateToNow(ctx, raceweek.RaceWeekID))
+
+	start := time.Date(2022, 1, 10, 7, 0, 0, 0, time.UTC)
+	rwr, err := db.InsertRaceWeekResult(ctx, RaceWeekResult{
+		RaceWeekID: raceweek.RaceWeekID, StartTime: start, TrackID: 413,
+		SessionID: 1, SubsessionID: 43774896, Official: true, SizeOfField: 2, StrengthOfField: 1500,
+	})
+	require.NoError(t, err)
+	_, err = db.InsertRaceStats(ctx, RaceStats{
+		SubsessionID: rwr.SubsessionID, StartTime: start, SimulatedStartTime: start,
+		Laps: 14, Cautions: 1, AvgLaptime: Laptime(1234567),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.UpsertSimsession(ctx, Simsession{
+		SubsessionID: rwr.SubsessionID, SimsessionNumber: 0, SimsessionType: 6, SimsessionTypeName: "Race", SimsessionName: "RACE",
+	}))
+	require.NoError(t, db.UpsertClub(ctx, Club{ClubID: 1, Name: "Finland"}))
+	for i, name := range []string{"Jack", `Jean "JJ" O'Neil`} {
+		driver := Driver{DriverID: 100 + i, Name: name, Club: Club{ClubID: 1}}
+		require.NoError(t, db.UpsertDriver(ctx, driver, start))
+		_, err := db.InsertRaceResult(ctx, RaceResult{
+			SubsessionID: rwr.SubsessionID, Driver: driver,
+			IRatingBefore: 1500, IRatingAfter: 1550 - 100*i, CPIAfter: 1.5,
+			ChampPoints: 100 - 10*i, CarID: 13, CarClassID: 74,
+			StartingPosition: i, FinishingPosition: i, Position: i + 1,
+			AvgLaptime: Laptime(1234567), BestLaptime: Laptime(1200000 + i),
+			LapsCompleted: 14, Incidents: 2 * i, ReasonOut: "Running",
+			SessionStartTime: start.Unix() * 1000,
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpsertTimeRanking(ctx, TimeRanking{
+			Driver: driver, RaceWeek: raceweek, Car: Car{CarID: 13}, TimeTrial: Laptime(1250000 + i),
+		}))
+		require.NoError(t, db.UpsertTimeTrialResult(ctx, TimeTrialResult{
+			Driver: driver, RaceWeek: raceweek, CarClassID: 74, Rank: i + 1, Points: 50 - i,
+		}))
+	}
+	return season, raceweek
+}
+
+func Test_Database_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	season, raceweek := seedTestDatabase(t, db)
+
+	series, err := db.GetSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, series, 4)
+	assert.Equal(t, "true", series[0].Active)
+
+	added, err := db.UpsertSeries(ctx, Series{SeriesName: "Super Formula Lights", SeriesNameShort: "SFL", SeriesRegex: "Super Formula Lights", Active: "true"})
+	require.NoError(t, err)
+	assert.Equal(t, -1, added.APISeriesID)
+	added.APISeriesID = 500
+	added, err = db.UpsertSeries(ctx, added)
+	require.NoError(t, err)
+	assert.Equal(t, 500, added.APISeriesID)
+	require.NoError(t, db.DeactivateSeries(ctx, added.SeriesID))
+	added, err = db.GetSeriesByID(ctx, added.SeriesID)
+	require.NoError(t, err)
+	assert.Equal(t, "false", added.Active)
+	series, err = db.GetActiveSeries(ctx)
+	require.NoError(t, err)
+	assert.Len(t, series, 4)
+
+	seasons, err := db.GetSeasons(ctx)
+	require.NoError(t, err)
+	assert.Len(t, seasons, 1)
+	s, err := db.GetSeasonByID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Equal(t, season.SeasonName, s.SeasonName)
+	assert.True(t, season.StartDate.Equal(s.StartDate))
+	seasons, err = db.GetSeasonsBySeriesID(ctx, season.SeriesID)
+	require.NoError(t, err)
+	assert.Len(t, seasons, 1)
+	_, err = db.GetSeasonsByAPISeriesID(ctx, 74)
+	require.NoError(t, err)
+
+	// upserts must update in place
+	s.Timeslots = "15 1-23/2 * * *"
+	require.NoError(t, db.UpsertSeason(ctx, s))
+	s, err = db.GetSeasonByID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Equal(t, "15 1-23/2 * * *", s.Timeslots)
+
+	schedule, err := db.GetSeasonScheduleBySeasonID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Empty(t, schedule.CarClassIDs)
+	assert.Empty(t, schedule.Weeks)
+	assert.Equal(t, 12, schedule.MaxWeeks)
+	require.NoError(t, db.UpsertSeasonSchedule(ctx, SeasonSchedule{
+		SeasonID: season.SeasonID, CarClassIDs: []int{74, 75}, DropWeeks: 4, MaxWeeks: 12,
+		Weeks: []ScheduleWeek{
+			{RaceWeek: 4, StartDate: time.Date(2022, 1, 11, 0, 0, 0, 0, time.UTC), TrackID: 999, TrackName: "Not collected yet", TrackCategory: "road", RaceLaps: 12},
+			{RaceWeek: 3, StartDate: time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC), TrackID: 413, TrackName: "Hungaroring", TrackCategory: "road", RaceTime: 25},
+		},
+	}))
+	require.NoError(t, db.UpsertSeasonSchedule(ctx, SeasonSchedule{
+		SeasonID: season.SeasonID, CarClassIDs: []int{74}, DropWeeks: 4, MaxWeeks: 12,
+		Weeks: []ScheduleWeek{
+			{RaceWeek: 4, StartDate: time.Date(2022, 1, 11, 0, 0, 0, 0, time.UTC), TrackID: 413, TrackName: "Hungaroring", TrackCategory: "road", RaceLaps: 14},
+		},
+	}))
+	schedule, err = db.GetSeasonScheduleBySeasonID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{74}, schedule.CarClassIDs)
+	assert.Equal(t, 4, schedule.DropWeeks)
+	require.Len(t, schedule.Weeks, 2)
+	assert.Equal(t, 3, schedule.Weeks[0].RaceWeek)
+	assert.Equal(t, 25, schedule.Weeks[0].RaceTime)
+	assert.True(t, time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC).Equal(schedule.Weeks[0].StartDate))
+	assert.Equal(t, 4, schedule.Weeks[1].RaceWeek)
+	assert.Equal(t, 413, schedule.Weeks[1].TrackID)
+	assert.Equal(t, 14, schedule.Weeks[1].RaceLaps)
+	_, err = db.GetSeasonScheduleBySeasonID(ctx, 1)
+	assert.Error(t, err)
+
+	rw, err := db.GetRaceWeekBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, raceweek.RaceWeekID, rw.RaceWeekID)
+	_, err = db.GetRaceWeekByID(ctx, raceweek.RaceWeekID)
+	require.NoError(t, err)
+
+	cars, err := db.GetCarsByRaceWeekID(ctx, raceweek.RaceWeekID)
+	require.NoError(t, err)
+	assert.Len(t, cars, 1)
+	classes, err := db.GetCarClassIDsByRaceWeekID(ctx, raceweek.RaceWeekID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{74}, classes)
+	_, err = db.GetCarByID(ctx, 13)
+	require.NoError(t, err)
+	_, err = db.GetTrackByID(ctx, 413)
+	require.NoError(t, err)
+	_, err = db.GetClubByID(ctx, 1)
+	require.NoError(t, err)
+	driver, err := db.GetDriverByID(ctx, 101)
+	require.NoError(t, err)
+	assert.Equal(t, `Jean "JJ" O'Neil`, driver.Name)
+
+	results, err := db.GetRaceWeekResultsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	stats, err := db.GetRaceStatsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	assert.Equal(t, 14, stats.Laps)
+	length, err := db.GetAverageRaceLengthBySeasonID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(14*1234567*100)*time.Microsecond, length)
+	length, err = db.GetAverageRaceLengthBySeasonID(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, length)
+
+	raceResults, err := db.GetRaceResultsBySubsessionID(ctx, 43774896)
+	require.NoError(t, err)
+	assert.Len(t, raceResults, 2)
+	raceResults, err = db.GetRaceResultsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, raceResults, 2)
+	_, err = db.GetRaceResultBySubsessionIDAndDriverID(ctx, 43774896, 100)
+	require.NoError(t, err)
+
+	rankings, err := db.GetTimeRankingsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, rankings, 2)
+	ttResults, err := db.GetTimeTrialResultsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, ttResults, 2)
+	ttResults, err = db.GetTimeTrialResultsBySeasonIDWeekAndCarClass(ctx, season.SeasonID, 3, 74)
+	require.NoError(t, err)
+	assert.Len(t, ttResults, 2)
+	laptimes, err := db.GetFastestTimeTrialSessionsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, laptimes, 2)
+	laptimes, err = db.GetFastestRaceLaptimesBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, laptimes, 2)
+
+	metrics, err := db.GetRaceWeekMetricsBySeasonID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	metric, err := db.GetRaceWeekMetricsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, metric.AvgCautions)
+	seasonMetrics, err := db.GetSeasonMetricsBySeriesID(ctx, season.SeriesID)
+	require.NoError(t, err)
+	assert.Len(t, seasonMetrics, 1)
+
+	points, err := db.GetPointsBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+	points, err = db.GetPointsBySeasonIDAndWeekAndTrackCategory(ctx, season.SeasonID, 3, "road")
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	summaries, err := db.GetDriverSummariesBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	assert.Len(t, summaries, 2)
+	_, err = db.GetDriverSummariesBySeasonIDAndWeekAndTeam(ctx, season.SeasonID, 3, "")
+	require.NoError(t, err)
+	_, err = db.GetDriverSummariesBySeasonIDAndTeam(ctx, season.SeasonID, "")
+	require.NoError(t, err)
+
+	driverResults, err := db.GetRaceResultsByDriverID(ctx, 101, DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, driverResults, 1)
+	assert.Equal(t, season.SeriesID, driverResults[0].SeriesID)
+	assert.Equal(t, 3, driverResults[0].RaceWeek)
+	assert.Equal(t, `Jean "JJ" O'Neil`, driverResults[0].Result.Driver.Name)
+	week, carClass, otherClass := 3, 74, 75
+	driverResults, err = db.GetRaceResultsByDriverID(ctx, 101, DriverFilter{SeasonID: &season.SeasonID, Week: &week, CarClassID: &carClass})
+	require.NoError(t, err)
+	assert.Len(t, driverResults, 1)
+	driverResults, err = db.GetRaceResultsByDriverID(ctx, 101, DriverFilter{CarClassID: &otherClass})
+	require.NoError(t, err)
+	assert.Empty(t, driverResults)
+	driverResults, err = db.GetRaceResultsByDriverID(ctx, 101, DriverFilter{Offset: 1})
+	require.NoError(t, err)
+	assert.Empty(t, driverResults)
+
+	driverSummaries, err := db.GetDriverSeasonSummariesByDriverID(ctx, 100, DriverFilter{SeriesID: &season.SeriesID})
+	require.NoError(t, err)
+	require.Len(t, driverSummaries, 1)
+	assert.Equal(t, 1, driverSummaries[0].Races)
+	assert.Equal(t, 1, driverSummaries[0].Wins)
+	assert.Equal(t, 1.0, driverSummaries[0].AvgFinishPosition)
+	assert.Equal(t, 50, driverSummaries[0].IRatingGain)
+	assert.Equal(t, Laptime(1200000), driverSummaries[0].BestLaptime)
+	assert.Equal(t, 1, driverSummaries[0].TimeRankingWeeks)
+	assert.Equal(t, 50, driverSummaries[0].TimeTrialPoints)
+
+	iratings, err := db.GetRatingHistoryByDriverID(ctx, 101, DriverFilter{})
+	require.NoError(t, err)
+	require.Len(t, iratings, 1)
+	assert.Equal(t, 1500, iratings[0].IRatingBefore)
+	assert.Equal(t, 1450, iratings[0].IRatingAfter)
+
+	timeSeries, err := db.GetRatingTimeSeriesByDriverID(ctx, 100, DriverFilter{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, timeSeries, 1)
+	assert.Equal(t, 1550, timeSeries[0].IRatingAfter)
+	assert.Equal(t, "road", timeSeries[0].Category)
+
+	// renaming a driver keeps the former name around
+	renamed := time.Date(2022, 3, 1, 19, 0, 0, 0, time.UTC)
+	require.NoError(t, db.UpsertDriver(ctx, Driver{DriverID: 100, Name: "Jack Example", Club: Club{ClubID: 1}}, renamed))
+	names, err := db.GetDriverNamesByDriverID(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+	assert.Equal(t, "Jack Example", names[0].Name)
+	assert.Equal(t, "Jack", names[1].Name)
+
+	// backfilling an older race neither bumps the former name nor makes it the current one again
+	backfilled := time.Date(2021, 6, 1, 19, 0, 0, 0, time.UTC)
+	require.NoError(t, db.UpsertDriver(ctx, Driver{DriverID: 100, Name: "Jack", Club: Club{ClubID: 1}}, backfilled))
+	names, err = db.GetDriverNamesByDriverID(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+	assert.Equal(t, "Jack Example", names[0].Name)
+	assert.True(t, renamed.Equal(names[0].LastSeen))
+	assert.Equal(t, "Jack", names[1].Name)
+	assert.True(t, backfilled.Equal(names[1].FirstSeen))
+	assert.True(t, time.Date(2022, 1, 10, 7, 0, 0, 0, time.UTC).Equal(names[1].LastSeen))
+	driver, err = db.GetDriverByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Jack Example", driver.Name)
+	profile, err := db.GetDriverProfileByDriverID(ctx, 100)
+	require.NoError(t, err)
+	assert.Len(t, profile.Names, 2)
+
+	matches, err := db.SearchDriversByName(ctx, "JACK")
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	for _, match := range matches {
+		assert.Equal(t, 100, match.DriverID)
+		assert.Equal(t, "Jack Example", match.CurrentName)
+	}
+	matches, err = db.SearchDriversByName(ctx, "o'neil")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 101, matches[0].DriverID)
+
+	// wildcards in the search only match themselves
+	require.NoError(t, db.UpsertDriver(ctx, Driver{DriverID: 102, Name: `Max_100%\Power`, Club: Club{ClubID: 1}}, time.Now()))
+	for _, search := range []string{"%", "_", `\`, "x_1", "00%"} {
+		matches, err = db.SearchDriversByName(ctx, search)
+		require.NoError(t, err)
+		if assert.Len(t, matches, 1, search) {
+			assert.Equal(t, 102, matches[0].DriverID)
+		}
+	}
+	matches, err = db.SearchDriversByName(ctx, "j_ck")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func Test_Database_Teams(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	season, _ := seedTestDatabase(t, db)
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	alpha, err := db.InsertTeam(ctx, Team{Name: "Alpha"})
+	require.NoError(t, err)
+	beta, err := db.InsertTeam(ctx, Team{Name: "Beta"})
+	require.NoError(t, err)
+	assert.NotEqual(t, alpha.TeamID, beta.TeamID)
+	teams, err := db.GetTeams(ctx)
+	require.NoError(t, err)
+	assert.Len(t, teams, 2)
+
+	// driver 100 switches from alpha to beta an hour after the race
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: alpha.TeamID, DriverID: 100, ValidFrom: date(2021, 1, 1, 0)})
+	require.NoError(t, err)
+	switched, err := db.InsertTeamMembership(ctx, TeamMembership{TeamID: beta.TeamID, DriverID: 100, ValidFrom: date(2022, 1, 10, 8)})
+	require.NoError(t, err)
+	assert.Equal(t, "Beta", switched.TeamName)
+	assert.Equal(t, "Jack", switched.DriverName)
+	memberships, err := db.GetTeamMembershipsByDriverID(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+	assert.Nil(t, memberships[0].ValidTo)
+	require.NotNil(t, memberships[1].ValidTo)
+	assert.True(t, date(2022, 1, 10, 8).Equal(*memberships[1].ValidTo))
+	driver, err := db.GetDriverByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Beta", driver.Team)
+
+	summaries, err := db.GetDriverSummariesBySeasonIDAndWeekAndTeam(ctx, season.SeasonID, 3, "Alpha")
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 100, summaries[0].Driver.DriverID)
+	assert.Equal(t, "Alpha", summaries[0].Driver.Team)
+	summaries, err = db.GetDriverSummariesBySeasonIDAndTeam(ctx, season.SeasonID, "Beta")
+	require.NoError(t, err)
+	assert.Len(t, summaries, 0)
+	summaries, err = db.GetDriverSummariesBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	driverTeams := make(map[int]string)
+	for _, summary := range summaries {
+		driverTeams[summary.Driver.DriverID] = summary.Driver.Team
+	}
+	assert.Equal(t, "Alpha", driverTeams[100])
+
+	points, err := db.GetTeamPointsBySeasonID(ctx, season.SeasonID)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, TeamPoints{RaceWeek: 3, TeamID: alpha.TeamID, TeamName: "Alpha", DriverID: 100, DriverName: "Jack", ChampPoints: 100}, points[0])
+	rules, err := db.GetTeamRulesBySeriesID(ctx, season.SeriesID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTeamRules(season.SeriesID), rules)
+	rules.TopDrivers = 2
+	require.NoError(t, db.UpsertTeamRules(ctx, rules))
+	rules.DropWeeks = 1
+	require.NoError(t, db.UpsertTeamRules(ctx, rules))
+	rules, err = db.GetTeamRulesBySeriesID(ctx, season.SeriesID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rules.TopDrivers)
+	assert.Equal(t, 1, rules.DropWeeks)
+
+	// a driver can only be part of one team at a time
+	closed, err := db.InsertTeamMembership(ctx, TeamMembership{TeamID: alpha.TeamID, DriverID: 101, ValidFrom: date(2021, 1, 1, 0), ValidTo: timePtr(date(2023, 1, 1, 0))})
+	require.NoError(t, err)
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: beta.TeamID, DriverID: 101, ValidFrom: date(2022, 1, 1, 0)})
+	assert.Error(t, err)
+	// only open-ended memberships end the open one, a limited one would leave the driver without a team afterwards
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: alpha.TeamID, DriverID: 100, ValidFrom: date(2023, 1, 1, 0), ValidTo: timePtr(date(2023, 2, 1, 0))})
+	assert.True(t, errors.Is(err, ErrInvalid))
+	memberships, err = db.GetTeamMembershipsByDriverID(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+	assert.Nil(t, memberships[0].ValidTo)
+	switched.ValidFrom = date(2022, 1, 10, 6)
+	assert.Error(t, db.UpdateTeamMembership(ctx, switched))
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: beta.TeamID, DriverID: 101, ValidFrom: date(2022, 1, 1, 0), ValidTo: timePtr(date(2021, 1, 1, 0))})
+	assert.Error(t, err)
+
+	closed.ValidTo = timePtr(date(2022, 1, 10, 6))
+	require.NoError(t, db.UpdateTeamMembership(ctx, closed))
+	summaries, err = db.GetDriverSummariesBySeasonIDAndWeekAndTeam(ctx, season.SeasonID, 3, "Alpha")
+	require.NoError(t, err)
+	assert.Len(t, summaries, 1)
+
+	beta.Name = "Gamma"
+	require.NoError(t, db.UpdateTeam(ctx, beta))
+	driver, err = db.GetDriverByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Gamma", driver.Team)
+	require.NoError(t, db.DeleteTeam(ctx, beta.TeamID))
+	driver, err = db.GetDriverByID(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "", driver.Team)
+
+	require.NoError(t, db.DeleteTeamMembership(ctx, closed.MembershipID))
+	memberships, err = db.GetTeamMembershipsByTeamID(ctx, alpha.TeamID)
+	require.NoError(t, err)
+	assert.Len(t, memberships, 1)
+}
+
+func Test_Database_TeamChangeDuringWeek(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	season, raceweek := seedTestDatabase(t, db)
+	date := func(day, hour int) time.Time {
+		return time.Date(2022, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	alpha, err := db.InsertTeam(ctx, Team{Name: "Alpha"})
+	require.NoError(t, err)
+	beta, err := db.InsertTeam(ctx, Team{Name: "Beta"})
+	require.NoError(t, err)
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: alpha.TeamID, DriverID: 100, ValidFrom: date(1, 0)})
+	require.NoError(t, err)
+	_, err = db.InsertTeamMembership(ctx, TeamMembership{TeamID: beta.TeamID, DriverID: 100, ValidFrom: date(10, 8)})
+	require.NoError(t, err)
+
+	// a second race in the same week, after the driver switched to Beta
+	start := date(11, 7)
+	rwr, err := db.InsertRaceWeekResult(ctx, RaceWeekResult{
+		RaceWeekID: raceweek.RaceWeekID, StartTime: start, TrackID: 413,
+		SessionID: 2, SubsessionID: 43774897, Official: true, SizeOfField: 1, StrengthOfField: 1500,
+	})
+	require.NoError(t, err)
+	_, err = db.InsertRaceStats(ctx, RaceStats{SubsessionID: rwr.SubsessionID, StartTime: start, SimulatedStartTime: start, Laps: 14})
+	require.NoError(t, err)
+	require.NoError(t, db.UpsertSimsession(ctx, Simsession{
+		SubsessionID: rwr.SubsessionID, SimsessionNumber: 0, SimsessionType: 6, SimsessionTypeName: "Race", SimsessionName: "RACE",
+	}))
+	_, err = db.InsertRaceResult(ctx, RaceResult{
+		SubsessionID: rwr.SubsessionID, Driver: Driver{DriverID: 100},
+		IRatingBefore: 1550, IRatingAfter: 1570, ChampPoints: 90, CarID: 13, CarClassID: 74,
+		Position: 1, AvgLaptime: Laptime(1234567), BestLaptime: Laptime(1190000),
+		LapsCompleted: 14, ReasonOut: "Running", SessionStartTime: start.Unix() * 1000,
+	})
+	require.NoError(t, err)
+
+	// summed up per driver, with the team of the last race of the week
+	summaries, err := db.GetDriverSummariesBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	var found int
+	for _, summary := range summaries {
+		if summary.Driver.DriverID == 100 {
+			found++
+			assert.Equal(t, "Beta", summary.Driver.Team)
+			assert.Equal(t, 2, summary.NumberOfRaces)
+		}
+	}
+	assert.Equal(t, 1, found)
+
+	laptimes, err := db.GetFastestRaceLaptimesBySeasonIDAndWeek(ctx, season.SeasonID, 3)
+	require.NoError(t, err)
+	found = 0
+	for _, laptime := range laptimes {
+		if laptime.Driver.DriverID == 100 {
+			found++
+			assert.Equal(t, "Beta", laptime.Driver.Team)
+		}
+	}
+	assert.Equal(t, 1, found)
+}
+
+func Test_Database_Jobs(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	seasonID := 3492
+	job, err := db.InsertJob(ctx, Job{Kind: "season", SeasonID: &seasonID, Status: "pending", Unit: "weeks"})
+	require.NoError(t, err)
+	again, err := db.InsertJob(ctx, Job{Kind: "season", SeasonID: &seasonID, Status: "pending", Unit: "weeks"})
+	require.NoError(t, err)
+	assert.Equal(t, job.JobID, again.JobID)
+
+	// running jobs are not queued twice either
+	claimed, err := db.ClaimJob(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, job.JobID, claimed.JobID)
+	assert.Equal(t, "running", claimed.Status)
+	again, err = db.InsertJob(ctx, Job{Kind: "season", SeasonID: &seasonID, Status: "pending", Unit: "weeks"})
+	require.NoError(t, err)
+	assert.Equal(t, job.JobID, again.JobID)
+
+	// cancelled jobs stay cancelled and free the queue for an identical one
+	claimed.Status = "cancelled"
+	require.NoError(t, db.UpdateJob(ctx, claimed))
+	claimed.Status = "done"
+	require.NoError(t, db.UpdateJob(ctx, claimed))
+	job, err = db.GetJobByID(ctx, job.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", job.Status)
+	again, err = db.InsertJob(ctx, Job{Kind: "season", SeasonID: &seasonID, Status: "pending", Unit: "weeks"})
+	require.NoError(t, err)
+	assert.NotEqual(t, job.JobID, again.JobID)
+	assert.Equal(t, "pending", again.Status)
+}
+
+func Test_Database_TeamStandings(t *testing.T) {
+	points := []TeamPoints{
+		{RaceWeek: 0, TeamID: 1, TeamName: "Alpha", DriverID: 1, ChampPoints: 100},
+		{RaceWeek: 0, TeamID: 1, TeamName: "Alpha", DriverID: 2, ChampPoints: 80},
+		{RaceWeek: 0, TeamID: 1, TeamName: "Alpha", DriverID: 3, ChampPoints: 90},
+		{RaceWeek: 1, TeamID: 1, TeamName: "Alpha", DriverID: 1, ChampPoints: 10},
+		{RaceWeek: 2, TeamID: 1, TeamName: "Alpha", DriverID: 1, ChampPoints: 60},
+		{RaceWeek: 0, TeamID: 2, TeamName: "Beta", DriverID: 4, ChampPoints: 150},
+		{RaceWeek: 1, TeamID: 2, TeamName: "Beta", DriverID: 4, ChampPoints: 150},
+		{RaceWeek: 2, TeamID: 2, TeamName: "Beta", DriverID: 4, ChampPoints: 20},
+		{RaceWeek: 1, TeamID: 3, TeamName: "Gamma", DriverID: 5, ChampPoints: 500},
+	}
+
+	standings := TeamStandings(points, TeamRules{TopDrivers: 2, DropWeeks: 1, MinWeeks: 2})
+	require.Len(t, standings, 3)
+
+	// beta: 150 + 150 + 20, dropping the 20
+	assert.Equal(t, "Beta", standings[0].Team.Name)
+	assert.Equal(t, 1, standings[0].Position)
+	assert.Equal(t, 300, standings[0].Points)
+	assert.True(t, standings[0].RaceWeeks[2].Dropped)
+
+	// alpha: 190 + 10 + 60, dropping the 10
+	assert.Equal(t, "Alpha", standings[1].Team.Name)
+	assert.Equal(t, 2, standings[1].Position)
+	assert.Equal(t, 250, standings[1].Points)
+	assert.Equal(t, 3, standings[1].Weeks)
+	require.Len(t, standings[1].RaceWeeks, 3)
+	assert.Equal(t, 190, standings[1].RaceWeeks[0].Points)
+	require.Len(t, standings[1].RaceWeeks[0].Drivers, 3)
+	assert.Equal(t, 90, standings[1].RaceWeeks[0].Drivers[1].ChampPoints)
+	assert.True(t, standings[1].RaceWeeks[0].Drivers[1].Counted)
+	assert.False(t, standings[1].RaceWeeks[0].Drivers[2].Counted)
+	assert.True(t, standings[1].RaceWeeks[1].Dropped)
+
+	// gamma only scored once, which is too little participation, and raceweek 0 is dropped in favour of raceweek 2
+	assert.Equal(t, "Gamma", standings[2].Team.Name)
+	assert.Equal(t, 0, standings[2].Position)
+	assert.False(t, standings[2].Classified)
+	assert.Equal(t, 500, standings[2].Points)
+	assert.True(t, standings[2].RaceWeeks[0].Dropped)
+	assert.Empty(t, standings[2].RaceWeeks[0].Drivers)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func Test_Database_DownsampleRatings(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2021, time.June, d, h, 0, 0, 0, time.UTC) }
+	points := []RatingPoint{ // 2021-06-06 is a sunday
+		{SeasonID: 1, Category: "road", StartTime: day(6, 10), IRatingBefore: 1500, IRatingAfter: 1520, SafetyRatingAfter: 350},
+		{SeasonID: 2, Category: "road", StartTime: day(7, 10), IRatingBefore: 1520, IRatingAfter: 1490, SafetyRatingAfter: 340},
+		{SeasonID: 3, Category: "oval", StartTime: day(7, 12), IRatingBefore: 1200, IRatingAfter: 1250, SafetyRatingAfter: 420},
+		{SeasonID: 2, Category: "road", StartTime: day(7, 18), IRatingBefore: 1490, IRatingAfter: 1510, SafetyRatingAfter: 360},
+		{SeasonID: 1, Category: "road", StartTime: day(8, 18), IRatingBefore: 1510, IRatingAfter: 1500, SafetyRatingAfter: 360},
+		{SeasonID: 2, Category: "road", StartTime: day(9, 18), IRatingBefore: 1500, IRatingAfter: 1540, SafetyRatingAfter: 370},
+	}
+
+	races, err := DownsampleRatings(points, RatingIntervalRace)
+	require.NoError(t, err)
+	require.Len(t, races, 2)
+	assert.Equal(t, "road", races[0].Category)
+	assert.Len(t, races[0].Samples, 5)
+	assert.Equal(t, "oval", races[1].Category)
+	assert.Len(t, races[1].Samples, 1)
+
+	// the oval race in between does not show up in the road trajectory
+	days, err := DownsampleRatings(points, RatingIntervalDay)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	road := days[0].Samples
+	require.Len(t, road, 4)
+	assert.Equal(t, day(7, 0), road[1].Period)
+	assert.Equal(t, 2, road[1].Races)
+	assert.Equal(t, 1510, road[1].IRating)
+	assert.Equal(t, 1490, road[1].IRatingMin)
+	assert.Equal(t, 1510, road[1].IRatingMax)
+	assert.Equal(t, -10, road[1].IRatingChange)
+	assert.Equal(t, 360, road[1].SafetyRating)
+	// seasons raced in parallel only start once
+	assert.True(t, road[0].SeasonStart)
+	assert.True(t, road[1].SeasonStart)
+	assert.False(t, road[2].SeasonStart)
+	assert.False(t, road[3].SeasonStart)
+	assert.True(t, days[1].Samples[0].SeasonStart)
+	assert.Equal(t, 1250, days[1].Samples[0].IRating)
+
+	weeks, err := DownsampleRatings(points, RatingIntervalWeek)
+	require.NoError(t, err)
+	require.Len(t, weeks[0].Samples, 2)
+	assert.Equal(t, 1540, weeks[0].Samples[1].IRating)
+	assert.Equal(t, 4, weeks[0].Samples[1].Races)
+	assert.Equal(t, 1490, weeks[0].Samples[1].IRatingMin)
+	assert.Equal(t, day(7, 0), weeks[0].Samples[1].Period)
+
+	_, err = DownsampleRatings(points, "month")
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// all queries are written with postgres style $1..$n bind parameters,
+// sqlite needs them as ?1..?n to keep the ordinal positions intact
+var bindParameterRx = regexp.MustCompile(`\$(\d+)`)
+
+func (db *database) rebind(query string) string {
+	if db.DatabaseType == "sqlite" {
+		return bindParameterRx.ReplaceAllString(query, "?$1")
+	}
+	return query
+}
+
+func (db *database) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.DB.SelectContext(ctx, dest, db.rebind(query), args...)
+}
+
+func (db *database) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.DB.GetContext(ctx, dest, db.rebind(query), args...)
+}
+
+func (db *database) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return db.DB.QueryxContext(ctx, db.rebind(query), args...)
+}
+
+func (db *database) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return db.DB.QueryRowxContext(ctx, db.rebind(query), args...)
+}
+
+func (db *database) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.rebind(query), args...)
+}
+
+func (db *database) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return db.DB.PreparexContext(ctx, db.rebind(query))
+}
+
+func (db *database) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*tx, error) {
+	t, err := db.DB.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{t, db}, nil
+}
+
+type tx struct {
+	*sqlx.Tx
+	db *database
+}
+
+func (t *tx) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return t.Tx.PreparexContext(ctx, t.db.rebind(query))
+}
+
+func (t *tx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.Tx.GetContext(ctx, dest, t.db.rebind(query), args...)
+}
+
+func (t *tx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.Tx.SelectContext(ctx, dest, t.db.rebind(query), args...)
+}
+
+func (t *tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.db.rebind(query), args...)
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+)
+
+// driver filters shared by all queries below, bind parameters $2 to $5 are season, week, series and car class
+const driverFilterConditions = `
+		and (cast($2 as integer) is null or rw.fk_season_id = $2)
+		and (cast($3 as integer) is null or rw.raceweek = $3)
+		and (cast($4 as integer) is null or s.fk_series_id = $4)
+		and (cast($5 as integer) is null or r.car_class_id = $5)`
+
+// GetRaceResultsByDriverID returns the feature race results of a driver, newest first
+func (db *database) GetRaceResultsByDriverID(ctx context.Context, driverID int, filter DriverFilter) ([]DriverRaceResult, error) {
+	results := make([]DriverRaceResult, 0)
+	rows, err := db.QueryxContext(ctx, `
+		select
+			s.fk_series_id,
+			rw.fk_season_id,
+			rw.raceweek,
+			rwr.fk_track_id,
+			rwr.starttime,
+			rwr.size,
+			rwr.sof,
+			r.fk_subsession_id,
+			r.simsession_number,
+			c.pk_club_id,
+			c.name,
+			d.pk_driver_id,
+			d.name,
+			`+teamOf("r.fk_driver_id", "r.session_starttime")+`,
+			r.division,
+			r.old_irating,
+			r.new_irating,
+			r.old_license_level,
+			r.new_license_level,
+			r.old_safety_rating,
+			r.new_safety_rating,
+			r.old_cpi,
+			r.new_cpi,
+			r.aggregate_champpoints,
+			r.champpoints,
+			r.clubpoints,
+			r.fk_car_id,
+			r.car_class_id,
+			r.starting_position,
+			r.position,
+			r.finishing_position,
+			r.finishing_position_in_class,
+			r.division,
+			r.interval,
+			r.class_interval,
+			r.avg_laptime,
+			r.best_laptime,
+			r.laps_completed,
+			r.laps_lead,
+			r.incidents,
+			r.reason_out,
+			r.session_starttime
+		from race_results r
+			join raceweek_results rwr on (rwr.subsession_id = r.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rwr.fk_raceweek_id)
+			join seasons s on (s.pk_season_id = rw.fk_season_id)
+			join drivers d on (r.fk_driver_id = d.pk_driver_id)
+			join clubs c on (d.fk_club_id = c.pk_club_id)
+		where r.fk_driver_id = $1
+		and r.simsession_number = 0`+driverFilterConditions+`
+		order by rwr.starttime desc, r.fk_subsession_id desc
+		limit $6 offset $7`,
+		driverID, filter.SeasonID, filter.Week, filter.SeriesID, filter.CarClassID, filter.limit(), filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dr := DriverRaceResult{}
+		r := &dr.Result
+		if err := rows.Scan(
+			&dr.SeriesID, &dr.SeasonID, &dr.RaceWeek, &dr.TrackID, &dr.StartTime, &dr.SizeOfField, &dr.StrengthOfField,
+			&r.SubsessionID, &r.SimsessionNumber,
+			&r.Driver.Club.ClubID, &r.Driver.Club.Name,
+			&r.Driver.DriverID, &r.Driver.Name, &r.Driver.Team, &r.Driver.Division,
+			&r.IRatingBefore, &r.IRatingAfter, &r.LicenseLevelBefore, &r.LicenseLevelAfter,
+			&r.SafetyRatingBefore, &r.SafetyRatingAfter, &r.CPIBefore, &r.CPIAfter,
+			&r.AggregateChampPoints, &r.ChampPoints, &r.ClubPoints,
+			&r.CarID, &r.CarClassID,
+			&r.StartingPosition, &r.Position, &r.FinishingPosition, &r.FinishingPositionInClass,
+			&r.Division, &r.Interval, &r.ClassInterval, &r.AvgLaptime, &r.BestLaptime,
+			&r.LapsCompleted, &r.LapsLead, &r.Incidents, &r.ReasonOut, &r.SessionStartTime,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, dr)
+	}
+	return results, rows.Err()
+}
+
+// GetDriverSeasonSummariesByDriverID sums up the races, time rankings and time trials of a driver per season, newest first
+func (db *database) GetDriverSeasonSummariesByDriverID(ctx context.Context, driverID int, filter DriverFilter) ([]DriverSeasonSummary, error) {
+	summaries := make([]DriverSeasonSummary, 0)
+	if err := db.SelectContext(ctx, &summaries, `
+		select
+			s.fk_series_id as series_id,
+			s.pk_season_id as season_id,
+			s.year,
+			s.quarter,
+			s.name as season_name,
+			count(*) as races,
+			sum(case when r.finishing_position = 0 then 1 else 0 end) as wins,
+			sum(case when r.finishing_position < 5 then 1 else 0 end) as top5,
+			sum(case when r.starting_position = 0 then 1 else 0 end) as poles,
+			avg(r.starting_position)+1 as avg_start_position,
+			avg(r.finishing_position)+1 as avg_finish_position,
+			avg(r.incidents) as avg_incidents,
+			sum(r.laps_completed) as laps_completed,
+			sum(r.laps_lead) as laps_lead,
+			sum(r.champpoints) as champpoints,
+			coalesce(min(case when r.best_laptime > 0 then r.best_laptime end), 0) as best_laptime,
+			sum(r.new_irating - r.old_irating) as irating_gain,
+			(select count(distinct tr.fk_raceweek_id)
+				from time_rankings tr
+					join raceweeks rw2 on (rw2.pk_raceweek_id = tr.fk_raceweek_id)
+				where tr.fk_driver_id = $1
+				and rw2.fk_season_id = s.pk_season_id) as time_ranking_weeks,
+			(select count(distinct ttr.fk_raceweek_id)
+				from time_trial_results ttr
+					join raceweeks rw2 on (rw2.pk_raceweek_id = ttr.fk_raceweek_id)
+				where ttr.fk_driver_id = $1
+				and rw2.fk_season_id = s.pk_season_id
+				and (cast($5 as integer) is null or ttr.car_class_id = $5)) as time_trial_weeks,
+			(select coalesce(sum(ttr.points), 0)
+				from time_trial_results ttr
+					join raceweeks rw2 on (rw2.pk_raceweek_id = ttr.fk_raceweek_id)
+				where ttr.fk_driver_id = $1
+				and rw2.fk_season_id = s.pk_season_id
+				and (cast($5 as integer) is null or ttr.car_class_id = $5)) as time_trial_points
+		from race_results r
+			join raceweek_results rwr on (rwr.subsession_id = r.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rwr.fk_raceweek_id)
+			join seasons s on (s.pk_season_id = rw.fk_season_id)
+		where r.fk_driver_id = $1
+		and r.simsession_number = 0`+driverFilterConditions+`
+		group by s.fk_series_id, s.pk_season_id, s.year, s.quarter, s.name
+		order by s.year desc, s.quarter desc, s.pk_season_id desc
+		limit $6 offset $7`,
+		driverID, filter.SeasonID, filter.Week, filter.SeriesID, filter.CarClassID, filter.limit(), filter.Offset); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GetRatingHistoryByDriverID returns the iRating, safety rating and license level of a driver before and after each feature race, oldest first
+func (db *database) GetRatingHistoryByDriverID(ctx context.Context, driverID int, filter DriverFilter) ([]RatingPoint, error) {
+	return db.getRatingHistory(ctx, driverID, filter, true)
+}
+
+// GetRatingTimeSeriesByDriverID returns the whole rating history of a driver, limit and offset of the filter are ignored
+func (db *database) GetRatingTimeSeriesByDriverID(ctx context.Context, driverID int, filter DriverFilter) ([]RatingPoint, error) {
+	return db.getRatingHistory(ctx, driverID, filter, false)
+}
+
+func (db *database) getRatingHistory(ctx context.Context, driverID int, filter DriverFilter, paginate bool) ([]RatingPoint, error) {
+	query := `
+		select
+			r.fk_subsession_id as subsession_id,
+			rw.fk_season_id as season_id,
+			rwr.starttime,
+			r.car_class_id,
+			lower(tr.category) as category,
+			r.old_irating,
+			r.new_irating,
+			r.old_safety_rating,
+			r.new_safety_rating,
+			r.old_license_level,
+			r.new_license_level
+		from race_results r
+			join raceweek_results rwr on (rwr.subsession_id = r.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rwr.fk_raceweek_id)
+			join seasons s on (s.pk_season_id = rw.fk_season_id)
+			join tracks tr on (tr.pk_track_id = rwr.fk_track_id)
+		where r.fk_driver_id = $1
+		and r.simsession_number = 0` + driverFilterConditions + `
+		order by rwr.starttime asc, r.fk_subsession_id asc`
+	args := []interface{}{driverID, filter.SeasonID, filter.Week, filter.SeriesID, filter.CarClassID}
+	if paginate {
+		query += `
+		limit $6 offset $7`
+		args = append(args, filter.limit(), filter.Offset)
+	}
+
+	points := make([]RatingPoint, 0)
+	if err := db.SelectContext(ctx, &points, query, args...); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
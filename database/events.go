@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+)
+
+// UpsertRaceEvents stores all event log entries of a subsession in one transaction
+func (db *database) UpsertRaceEvents(ctx context.Context, events []RaceEvent) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into race_events
+			(fk_subsession_id, simsession_number, event_seq, lap, session_time,
+			fk_driver_id, driver_name, event_code, event_type, description, message)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		on conflict (fk_subsession_id, simsession_number, event_seq) do update
+		set lap = excluded.lap,
+			session_time = excluded.session_time,
+			fk_driver_id = excluded.fk_driver_id,
+			driver_name = excluded.driver_name,
+			event_code = excluded.event_code,
+			event_type = excluded.event_type,
+			description = excluded.description,
+			message = excluded.message`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		if _, err = stmt.ExecContext(ctx,
+			event.SubsessionID, event.SimsessionNumber, event.EventSeq, event.Lap, event.SessionTime,
+			event.DriverID, event.DriverName, event.EventCode, event.EventType, event.Description, event.Message); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetIncidentTimelineBySubsessionID returns all incidents and cautions of a subsession in the order they happened
+func (db *database) GetIncidentTimelineBySubsessionID(ctx context.Context, subsessionID int) ([]RaceEvent, error) {
+	events := make([]RaceEvent, 0)
+	if err := db.SelectContext(ctx, &events, `
+		select
+			e.fk_subsession_id,
+			e.simsession_number,
+			e.event_seq,
+			e.lap,
+			e.session_time,
+			e.fk_driver_id,
+			e.driver_name,
+			e.event_code,
+			e.event_type,
+			e.description,
+			e.message
+		from race_events e
+		where e.fk_subsession_id = $1
+		and e.event_type in ('incident', 'caution')
+		order by e.session_time asc, e.event_seq asc`, subsessionID); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetIncidentTimelineBySeasonIDAndWeek returns all incidents and cautions of a raceweek, race by race
+func (db *database) GetIncidentTimelineBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]RaceEvent, error) {
+	events := make([]RaceEvent, 0)
+	if err := db.SelectContext(ctx, &events, `
+		select
+			e.fk_subsession_id,
+			e.simsession_number,
+			e.event_seq,
+			e.lap,
+			e.session_time,
+			e.fk_driver_id,
+			e.driver_name,
+			e.event_code,
+			e.event_type,
+			e.description,
+			e.message
+		from race_events e
+			join raceweek_results rwr on (rwr.subsession_id = e.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rwr.fk_raceweek_id)
+		where rw.fk_season_id = $1
+		and rw.raceweek = $2
+		and e.event_type in ('incident', 'caution')
+		order by rwr.starttime asc, e.fk_subsession_id asc, e.session_time asc, e.event_seq asc`, seasonID, week); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (db *database) GetPitStopSummariesBySubsessionID(ctx context.Context, subsessionID int) ([]PitStopSummary, error) {
+	return db.getPitStopSummaries(ctx, `
+		select
+			e.fk_subsession_id as subsession_id,
+			d.pk_driver_id,
+			d.name,
+			coalesce(t.name, ''),
+			1 as races,
+			count(*) as pit_stops,
+			min(e.lap) as first_pit_lap,
+			max(e.lap) as last_pit_lap
+		from race_events e
+			join raceweek_results rwr on (rwr.subsession_id = e.fk_subsession_id)
+			join drivers d on (e.fk_driver_id = d.pk_driver_id)`+joinTeamAt("left join", "e.fk_driver_id", "date_part('epoch', rwr.starttime) * 1000")+`
+		where e.fk_subsession_id = $1
+		and e.event_type = 'pit'
+		group by e.fk_subsession_id, d.pk_driver_id, d.name, t.name
+		order by pit_stops desc, d.name asc`, subsessionID)
+}
+
+func (db *database) GetPitStopSummariesBySeasonIDAndWeek(ctx context.Context, seasonID, week int) ([]PitStopSummary, error) {
+	return db.getPitStopSummaries(ctx, `
+		select
+			0 as subsession_id,
+			d.pk_driver_id,
+			d.name,
+			`+teamOfWeek+`,
+			(select count(distinct rr.fk_subsession_id)
+				from race_results rr
+					join raceweek_results rwr2 on (rwr2.subsession_id = rr.fk_subsession_id)
+				where rr.fk_driver_id = d.pk_driver_id
+				and rr.simsession_number = 0
+				and rwr2.fk_raceweek_id = rw.pk_raceweek_id) as races,
+			count(*) as pit_stops,
+			min(e.lap) as first_pit_lap,
+			max(e.lap) as last_pit_lap
+		from race_events e
+			join raceweek_results rwr on (rwr.subsession_id = e.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rwr.fk_raceweek_id)
+			join drivers d on (e.fk_driver_id = d.pk_driver_id)
+		where rw.fk_season_id = $1
+		and rw.raceweek = $2
+		and e.event_type = 'pit'
+		group by rw.pk_raceweek_id, d.pk_driver_id, d.name
+		order by pit_stops desc, d.name asc`, seasonID, week)
+}
+
+func (db *database) getPitStopSummaries(ctx context.Context, query string, args ...interface{}) ([]PitStopSummary, error) {
+	summaries := make([]PitStopSummary, 0)
+	rows, err := db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := PitStopSummary{}
+		if err := rows.Scan(
+			&s.SubsessionID, &s.Driver.DriverID, &s.Driver.Name, &s.Driver.Team,
+			&s.Races, &s.PitStops, &s.FirstPitLap, &s.LastPitLap,
+		); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
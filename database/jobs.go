@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const jobColumns = `
+			j.pk_job_id,
+			j.kind,
+			j.season_id,
+			j.week,
+			j.params,
+			j.status,
+			j.attempts,
+			j.unit,
+			j.done,
+			j.total,
+			j.errors,
+			j.created_at,
+			j.scheduled_at,
+			j.started_at,
+			j.finished_at`
+
+// InsertJob queues a new job, unless an identical one is still pending or running, in which case that one is returned
+func (db *database) InsertJob(ctx context.Context, job Job) (Job, error) {
+	for {
+		// the uq_jobs_queued index lets only one of several identical jobs into the queue
+		var id int
+		err := db.QueryRowxContext(ctx, `
+			insert into jobs
+				(kind, season_id, week, params, status, attempts, unit, done, total, errors, created_at, scheduled_at)
+			values ($1, $2, $3, $4, $5, 0, $6, 0, 0, '', $7, $7)
+			on conflict do nothing
+			returning pk_job_id`,
+			job.Kind, job.SeasonID, job.Week, job.Params, job.Status, job.Unit, time.Now().UTC()).Scan(&id)
+		if err == nil {
+			return db.GetJobByID(ctx, id)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return Job{}, err
+		}
+
+		existing := Job{}
+		err = db.GetContext(ctx, &existing, `
+			select`+jobColumns+`
+			from jobs j
+			where j.kind = $1
+			and coalesce(j.season_id, -1) = coalesce(cast($2 as integer), -1)
+			and coalesce(j.week, -1) = coalesce(cast($3 as integer), -1)
+			and j.params = $4
+			and j.status in ('pending', 'running')`, job.Kind, job.SeasonID, job.Week, job.Params)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return Job{}, err
+		}
+		// the identical job finished in the meantime, try again
+	}
+}
+
+func (db *database) GetJobByID(ctx context.Context, id int) (Job, error) {
+	job := Job{}
+	if err := db.GetContext(ctx, &job, `
+		select`+jobColumns+`
+		from jobs j
+		where j.pk_job_id = $1`, id); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+func (db *database) GetJobs(ctx context.Context) ([]Job, error) {
+	jobs := make([]Job, 0)
+	if err := db.SelectContext(ctx, &jobs, `
+		select`+jobColumns+`
+		from jobs j
+		order by j.pk_job_id desc
+		limit 250`); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClaimJob marks the oldest pending job that is due as running and returns it, sql.ErrNoRows if there is none
+func (db *database) ClaimJob(ctx context.Context) (Job, error) {
+	for {
+		now := time.Now().UTC()
+		job := Job{}
+		if err := db.GetContext(ctx, &job, `
+			select`+jobColumns+`
+			from jobs j
+			where j.status = 'pending'
+			and j.scheduled_at <= $1
+			order by j.scheduled_at, j.pk_job_id
+			limit 1`, now); err != nil {
+			return job, err
+		}
+
+		result, err := db.ExecContext(ctx, `
+			update jobs
+			set status = 'running',
+				attempts = attempts + 1,
+				started_at = $1
+			where pk_job_id = $2
+			and status = 'pending'`, now, job.JobID)
+		if err != nil {
+			return Job{}, err
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return Job{}, err
+		} else if rows == 0 {
+			continue // someone else got it first
+		}
+		return db.GetJobByID(ctx, job.JobID)
+	}
+}
+
+// UpdateJob stores status and progress of a job, cancelled jobs stay cancelled
+func (db *database) UpdateJob(ctx context.Context, job Job) error {
+	stmt, err := db.PreparexContext(ctx, `
+		update jobs
+		set status = $1,
+			done = $2,
+			total = $3,
+			errors = $4,
+			scheduled_at = $5,
+			finished_at = $6
+		where pk_job_id = $7
+		and status <> 'cancelled'`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var finishedAt *time.Time
+	if job.FinishedAt != nil {
+		t := job.FinishedAt.UTC()
+		finishedAt = &t
+	}
+	if _, err := stmt.ExecContext(ctx,
+		job.Status, job.Done, job.Total, job.Errors, job.ScheduledAt.UTC(), finishedAt, job.JobID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetRunningJobs puts jobs that were interrupted by a shutdown back into the queue
+func (db *database) ResetRunningJobs(ctx context.Context) error {
+	_, err := db.ExecContext(ctx, `
+		update jobs
+		set status = 'pending'
+		where status = 'running'`)
+	return err
+}
+
+func (db *database) DeleteJob(ctx context.Context, id int) error {
+	_, err := db.ExecContext(ctx, `
+		delete from jobs
+		where pk_job_id = $1`, id)
+	return err
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+)
+
+// UpsertRaceLaps stores all laps of a subsession in one transaction
+func (db *database) UpsertRaceLaps(ctx context.Context, laps []RaceLap) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into race_laps
+			(fk_subsession_id, fk_driver_id, lap, laptime, position, interval,
+			session_time, flags, pitted, off_track, incident, events)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		on conflict (fk_subsession_id, fk_driver_id, lap) do update
+		set laptime = excluded.laptime,
+			position = excluded.position,
+			interval = excluded.interval,
+			session_time = excluded.session_time,
+			flags = excluded.flags,
+			pitted = excluded.pitted,
+			off_track = excluded.off_track,
+			incident = excluded.incident,
+			events = excluded.events`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, lap := range laps {
+		if _, err = stmt.ExecContext(ctx,
+			lap.SubsessionID, lap.Driver.DriverID, lap.Lap, lap.Laptime, lap.Position, lap.Interval,
+			lap.SessionTime, lap.Flags, lap.Pitted, lap.OffTrack, lap.Incident, lap.Events); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *database) GetRaceLapsBySubsessionID(ctx context.Context, subsessionID int) ([]RaceLap, error) {
+	laps := make([]RaceLap, 0)
+	rows, err := db.QueryxContext(ctx, `
+		select
+			l.fk_subsession_id,
+			d.pk_driver_id,
+			d.name,
+			l.lap,
+			l.laptime,
+			l.position,
+			l.interval,
+			l.session_time,
+			l.flags,
+			l.pitted,
+			l.off_track,
+			l.incident,
+			l.events
+		from race_laps l
+			join drivers d on (l.fk_driver_id = d.pk_driver_id)
+		where l.fk_subsession_id = $1
+		order by l.lap asc, l.position asc`, subsessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := RaceLap{}
+		if err := rows.Scan(
+			&l.SubsessionID, &l.Driver.DriverID, &l.Driver.Name, &l.Lap, &l.Laptime, &l.Position, &l.Interval,
+			&l.SessionTime, &l.Flags, &l.Pitted, &l.OffTrack, &l.Incident, &l.Events,
+		); err != nil {
+			return nil, err
+		}
+		laps = append(laps, l)
+	}
+	return laps, rows.Err()
+}
@@ -0,0 +1,2 @@
+-- tokens
+DROP TABLE IF EXISTS tokens;
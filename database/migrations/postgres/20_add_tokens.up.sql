@@ -0,0 +1,12 @@
+-- tokens
+CREATE TABLE IF NOT EXISTS tokens (
+    pk_name                     TEXT PRIMARY KEY,
+    access_token                TEXT NOT NULL,
+    refresh_token               TEXT NOT NULL,
+    token_type                  TEXT NOT NULL,
+    scope                       TEXT NOT NULL,
+    access_token_expires_at     TIMESTAMPTZ NOT NULL,
+    refresh_token_expires_at    TIMESTAMPTZ NOT NULL,
+    last_login                  TIMESTAMPTZ NOT NULL,
+    last_update                 TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- raw_payloads
+DROP INDEX IF EXISTS idx_raw_payloads_endpoint;
+DROP TABLE IF EXISTS raw_payloads;
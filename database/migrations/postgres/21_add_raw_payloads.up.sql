@@ -0,0 +1,9 @@
+-- raw_payloads
+CREATE TABLE IF NOT EXISTS raw_payloads (
+    pk_raw_payload_id   SERIAL PRIMARY KEY,
+    endpoint            TEXT NOT NULL,
+    params              TEXT NOT NULL,
+    fetched_at          TIMESTAMPTZ NOT NULL,
+    payload             BYTEA NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_raw_payloads_endpoint ON raw_payloads (endpoint, params, fetched_at);
@@ -0,0 +1,3 @@
+-- jobs
+DROP INDEX IF EXISTS idx_jobs_status;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,18 @@
+-- jobs
+CREATE TABLE IF NOT EXISTS jobs (
+    pk_job_id       SERIAL PRIMARY KEY,
+    kind            TEXT NOT NULL,
+    season_id       INTEGER,
+    week            INTEGER,
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    unit            TEXT NOT NULL,
+    done            INTEGER NOT NULL DEFAULT 0,
+    total           INTEGER NOT NULL DEFAULT 0,
+    errors          TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    scheduled_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at      TIMESTAMPTZ,
+    finished_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status, scheduled_at);
@@ -0,0 +1,2 @@
+-- jobs
+ALTER TABLE jobs ADD COLUMN params TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+-- race_laps
+CREATE TABLE IF NOT EXISTS race_laps (
+    fk_subsession_id    INTEGER NOT NULL,
+    fk_driver_id        INTEGER NOT NULL,
+    lap                 INTEGER NOT NULL,
+    laptime             INTEGER NOT NULL,
+    position            INTEGER NOT NULL,
+    interval            INTEGER NOT NULL,
+    session_time        INTEGER NOT NULL,
+    flags               INTEGER NOT NULL,
+    pitted              BOOLEAN NOT NULL,
+    off_track           BOOLEAN NOT NULL,
+    incident            BOOLEAN NOT NULL,
+    events              TEXT NOT NULL,
+    PRIMARY KEY (fk_subsession_id, fk_driver_id, lap),
+    FOREIGN KEY (fk_subsession_id) REFERENCES race_stats (fk_subsession_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- race_results of the feature race only
+ALTER TABLE race_results
+    DROP CONSTRAINT fk_race_result_simsession;
+DELETE FROM race_results WHERE simsession_number <> 0;
+ALTER TABLE race_results
+    DROP CONSTRAINT uniq_race_result;
+ALTER TABLE race_results
+    ADD CONSTRAINT uniq_race_result UNIQUE (fk_subsession_id, fk_driver_id);
+ALTER TABLE race_results
+    DROP COLUMN simsession_number;
+
+-- simsessions
+DROP TABLE simsessions;
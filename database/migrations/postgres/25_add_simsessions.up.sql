@@ -0,0 +1,26 @@
+-- simsessions
+CREATE TABLE IF NOT EXISTS simsessions (
+    fk_subsession_id        INTEGER NOT NULL,
+    simsession_number       INTEGER NOT NULL,
+    simsession_type         INTEGER NOT NULL,
+    simsession_type_name    TEXT NOT NULL,
+    simsession_subtype      INTEGER NOT NULL,
+    simsession_name         TEXT NOT NULL,
+    PRIMARY KEY (fk_subsession_id, simsession_number),
+    FOREIGN KEY (fk_subsession_id) REFERENCES raceweek_results (subsession_id) ON DELETE CASCADE
+);
+
+-- all existing race results belong to the feature race
+INSERT INTO simsessions (fk_subsession_id, simsession_number, simsession_type, simsession_type_name, simsession_subtype, simsession_name)
+SELECT DISTINCT fk_subsession_id, 0, 6, 'Race', 0, 'RACE' FROM race_results;
+
+-- race_results per simsession
+ALTER TABLE race_results
+    ADD COLUMN simsession_number INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE race_results
+    DROP CONSTRAINT uniq_race_result;
+ALTER TABLE race_results
+    ADD CONSTRAINT uniq_race_result UNIQUE (fk_subsession_id, simsession_number, fk_driver_id);
+ALTER TABLE race_results
+    ADD CONSTRAINT fk_race_result_simsession FOREIGN KEY (fk_subsession_id, simsession_number)
+    REFERENCES simsessions (fk_subsession_id, simsession_number) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+-- race_events
+DROP TABLE race_events;
@@ -0,0 +1,8 @@
+-- driver_career_stats
+DROP TABLE driver_career_stats;
+
+-- driver_licenses
+DROP TABLE driver_licenses;
+
+-- driver_profiles
+DROP TABLE driver_profiles;
@@ -0,0 +1,47 @@
+-- driver_profiles
+CREATE TABLE IF NOT EXISTS driver_profiles (
+    fk_driver_id    INTEGER PRIMARY KEY,
+    member_since    TIMESTAMPTZ,
+    last_login      TIMESTAMPTZ,
+    refreshed_at    TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE
+);
+
+-- driver_licenses
+CREATE TABLE IF NOT EXISTS driver_licenses (
+    fk_driver_id    INTEGER NOT NULL,
+    category_id     INTEGER NOT NULL,
+    category        TEXT NOT NULL,
+    license_level   INTEGER NOT NULL,
+    group_name      TEXT NOT NULL,
+    safety_rating   DECIMAL NOT NULL,
+    cpi             DECIMAL NOT NULL,
+    irating         INTEGER NOT NULL,
+    tt_rating       INTEGER NOT NULL,
+    color           TEXT NOT NULL,
+    PRIMARY KEY (fk_driver_id, category_id),
+    FOREIGN KEY (fk_driver_id) REFERENCES driver_profiles (fk_driver_id) ON DELETE CASCADE
+);
+
+-- driver_career_stats
+CREATE TABLE IF NOT EXISTS driver_career_stats (
+    fk_driver_id            INTEGER NOT NULL,
+    category_id             INTEGER NOT NULL,
+    category                TEXT NOT NULL,
+    starts                  INTEGER NOT NULL,
+    wins                    INTEGER NOT NULL,
+    top5                    INTEGER NOT NULL,
+    poles                   INTEGER NOT NULL,
+    avg_start_position      DECIMAL NOT NULL,
+    avg_finish_position     DECIMAL NOT NULL,
+    laps                    INTEGER NOT NULL,
+    laps_led                INTEGER NOT NULL,
+    avg_incidents           DECIMAL NOT NULL,
+    avg_points              DECIMAL NOT NULL,
+    win_percentage          DECIMAL NOT NULL,
+    top5_percentage         DECIMAL NOT NULL,
+    laps_led_percentage     DECIMAL NOT NULL,
+    total_club_points       INTEGER NOT NULL,
+    PRIMARY KEY (fk_driver_id, category_id),
+    FOREIGN KEY (fk_driver_id) REFERENCES driver_profiles (fk_driver_id) ON DELETE CASCADE
+);
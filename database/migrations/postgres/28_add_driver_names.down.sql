@@ -0,0 +1,3 @@
+-- driver_names
+DROP INDEX IF EXISTS idx_driver_names_name;
+DROP TABLE driver_names;
@@ -0,0 +1,14 @@
+-- driver_names
+CREATE TABLE IF NOT EXISTS driver_names (
+    fk_driver_id    INTEGER NOT NULL,
+    name            TEXT NOT NULL,
+    first_seen      TIMESTAMPTZ NOT NULL,
+    last_seen       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (fk_driver_id, name),
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_driver_names_name ON driver_names (lower(name));
+
+INSERT INTO driver_names (fk_driver_id, name, first_seen, last_seen)
+SELECT pk_driver_id, name, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM drivers;
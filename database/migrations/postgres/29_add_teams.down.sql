@@ -0,0 +1,6 @@
+-- team_memberships
+DROP INDEX IF EXISTS idx_team_memberships_driver;
+DROP TABLE team_memberships;
+
+-- teams
+DROP TABLE teams;
@@ -0,0 +1,2 @@
+-- team_rules
+DROP TABLE team_rules;
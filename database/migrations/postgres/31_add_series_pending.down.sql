@@ -0,0 +1,3 @@
+-- remove pending column from series
+ALTER TABLE series
+DROP COLUMN IF EXISTS pending;
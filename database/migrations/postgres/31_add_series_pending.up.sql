@@ -0,0 +1,3 @@
+-- add pending column to series, for discovered series waiting for approval
+ALTER TABLE series
+ADD COLUMN pending BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+-- remove season_schedules and the schedule columns of seasons
+DROP TABLE IF EXISTS season_schedules;
+ALTER TABLE seasons
+DROP COLUMN IF EXISTS max_weeks;
+ALTER TABLE seasons
+DROP COLUMN IF EXISTS drop_weeks;
+ALTER TABLE seasons
+DROP COLUMN IF EXISTS car_class_ids;
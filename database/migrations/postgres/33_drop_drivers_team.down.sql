@@ -0,0 +1,13 @@
+-- add team column to drivers again, filled with the current team of each driver
+ALTER TABLE drivers
+ADD COLUMN team TEXT;
+
+UPDATE drivers
+SET team = (
+    SELECT t.name
+    FROM team_memberships m
+        JOIN teams t ON (t.pk_team_id = m.fk_team_id)
+    WHERE m.fk_driver_id = drivers.pk_driver_id
+    AND m.valid_from <= CURRENT_TIMESTAMP
+    AND (m.valid_to IS NULL OR m.valid_to > CURRENT_TIMESTAMP)
+);
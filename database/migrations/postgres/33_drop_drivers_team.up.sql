@@ -0,0 +1,3 @@
+-- remove team from drivers, teams are resolved through team_memberships at the time of each race
+ALTER TABLE drivers
+DROP COLUMN IF EXISTS team;
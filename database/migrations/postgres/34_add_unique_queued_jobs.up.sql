@@ -0,0 +1,15 @@
+-- identical jobs can only be queued once while they are pending or running
+UPDATE jobs
+SET status = 'cancelled'
+WHERE status IN ('pending', 'running')
+AND EXISTS (
+    SELECT 1 FROM jobs older
+    WHERE older.kind = jobs.kind
+    AND COALESCE(older.season_id, -1) = COALESCE(jobs.season_id, -1)
+    AND COALESCE(older.week, -1) = COALESCE(jobs.week, -1)
+    AND older.params = jobs.params
+    AND older.status IN ('pending', 'running')
+    AND older.pk_job_id < jobs.pk_job_id
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_jobs_queued ON jobs (kind, COALESCE(season_id, -1), COALESCE(week, -1), params)
+WHERE status IN ('pending', 'running');
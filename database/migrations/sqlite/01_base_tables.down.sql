@@ -0,0 +1,35 @@
+-- time_trial_results
+DROP TABLE time_trial_results;
+
+-- time_rankings
+DROP TABLE time_rankings;
+
+-- race_results
+DROP TABLE race_results;
+
+-- drivers
+DROP TABLE drivers;
+
+-- clubs
+DROP TABLE clubs;
+
+-- race_stats
+DROP TABLE race_stats;
+
+-- raceweek_results
+DROP TABLE raceweek_results;
+
+-- raceweeks
+DROP TABLE raceweeks;
+
+-- seasons
+DROP TABLE seasons;
+
+-- cars
+DROP TABLE cars;
+
+-- tracks
+DROP TABLE tracks;
+
+-- series
+DROP TABLE series;
@@ -0,0 +1,192 @@
+-- series
+CREATE TABLE IF NOT EXISTS series (
+    pk_series_id    INTEGER PRIMARY KEY AUTOINCREMENT,
+    name            TEXT NOT NULL UNIQUE,
+    short_name      TEXT NOT NULL UNIQUE,
+    regex           TEXT NOT NULL UNIQUE,
+    active          BOOLEAN DEFAULT TRUE,
+    colorscheme     TEXT,
+    api_series_id   INTEGER
+);
+
+-- tracks
+CREATE TABLE IF NOT EXISTS tracks (
+    pk_track_id             INTEGER PRIMARY KEY,
+    name                    TEXT NOT NULL,
+    config                  TEXT NOT NULL,
+    category                TEXT NOT NULL,
+    banner_image            TEXT NOT NULL,
+    panel_image             TEXT NOT NULL,
+    logo_image              TEXT NOT NULL,
+    map_image               TEXT NOT NULL,
+    config_image            TEXT NOT NULL,
+    free_with_subscription  BOOLEAN,
+    retired                 BOOLEAN,
+    is_dirt                 BOOLEAN,
+    is_oval                 BOOLEAN,
+    CONSTRAINT uniq_track UNIQUE (pk_track_id, name, config)
+);
+
+-- cars
+CREATE TABLE IF NOT EXISTS cars (
+    pk_car_id               INTEGER PRIMARY KEY,
+    name                    TEXT NOT NULL,
+    description             TEXT NOT NULL,
+    model                   TEXT NOT NULL,
+    make                    TEXT NOT NULL,
+    panel_image             TEXT NOT NULL,
+    logo_image              TEXT NOT NULL,
+    car_image               TEXT NOT NULL,
+    free_with_subscription  BOOLEAN,
+    retired                 BOOLEAN,
+    abbreviation            TEXT
+);
+
+-- seasons
+CREATE TABLE IF NOT EXISTS seasons (
+    pk_season_id    INTEGER PRIMARY KEY,
+    year            INTEGER NOT NULL,
+    quarter         INTEGER NOT NULL CHECK (quarter < 5),
+    category        TEXT NOT NULL,
+    name            TEXT NOT NULL UNIQUE,
+    short_name      TEXT NOT NULL,
+    banner_image    TEXT NOT NULL,
+    panel_image     TEXT NOT NULL,
+    logo_image      TEXT NOT NULL,
+    timeslots       TEXT,
+    startdate       TIMESTAMP,
+    fk_series_id    INTEGER NOT NULL,
+    FOREIGN KEY (fk_series_id) REFERENCES series (pk_series_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_season UNIQUE (fk_series_id, year, quarter)
+);
+
+-- raceweeks
+CREATE TABLE IF NOT EXISTS raceweeks (
+    pk_raceweek_id  INTEGER PRIMARY KEY AUTOINCREMENT,
+    raceweek        INTEGER NOT NULL CHECK (raceweek < 14),
+    fk_track_id     INTEGER NOT NULL,
+    fk_season_id    INTEGER NOT NULL,
+    last_update     TIMESTAMP,
+    FOREIGN KEY (fk_track_id) REFERENCES tracks (pk_track_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_season_id) REFERENCES seasons (pk_season_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_raceweek UNIQUE (fk_season_id, raceweek)
+);
+
+-- raceweek_results
+CREATE TABLE IF NOT EXISTS raceweek_results (
+    starttime       TIMESTAMP NOT NULL,
+    session_id      INTEGER NOT NULL,
+    subsession_id   INTEGER NOT NULL UNIQUE,
+    official        BOOLEAN NOT NULL,
+    size            INTEGER NOT NULL,
+    sof             INTEGER NOT NULL,
+    fk_track_id     INTEGER NOT NULL,
+    fk_raceweek_id  INTEGER NOT NULL,
+    FOREIGN KEY (fk_track_id) REFERENCES tracks (pk_track_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_raceweek_id) REFERENCES raceweeks (pk_raceweek_id) ON DELETE CASCADE
+);
+
+-- race_stats
+CREATE TABLE IF NOT EXISTS race_stats (
+    fk_subsession_id    INTEGER NOT NULL UNIQUE,
+    starttime           TIMESTAMP NOT NULL,
+    simulated_starttime TIMESTAMP NOT NULL,
+    lead_changes        INTEGER NOT NULL,
+    laps                INTEGER NOT NULL,
+    cautions            INTEGER NOT NULL,
+    caution_laps        INTEGER NOT NULL,
+    corners_per_lap     INTEGER NOT NULL,
+    avg_laptime         INTEGER NOT NULL,
+    avg_quali_laps      INTEGER NOT NULL,
+    weather_rh          INTEGER NOT NULL,
+    weather_temp        INTEGER NOT NULL,
+    FOREIGN KEY (fk_subsession_id) REFERENCES raceweek_results (subsession_id) ON DELETE CASCADE
+);
+
+-- clubs
+CREATE TABLE IF NOT EXISTS clubs (
+    pk_club_id          INTEGER PRIMARY KEY,
+    name                TEXT NOT NULL
+);
+
+-- drivers
+CREATE TABLE IF NOT EXISTS drivers (
+    pk_driver_id        INTEGER PRIMARY KEY,
+    name                TEXT NOT NULL,
+    fk_club_id          INTEGER NOT NULL,
+    team                TEXT,
+    FOREIGN KEY (fk_club_id) REFERENCES clubs (pk_club_id) ON DELETE CASCADE
+);
+
+-- race_results
+CREATE TABLE IF NOT EXISTS race_results (
+    fk_subsession_id                INTEGER NOT NULL,
+    fk_driver_id                    INTEGER NOT NULL,
+    old_irating                     INTEGER NOT NULL,
+    new_irating                     INTEGER NOT NULL,
+    old_license_level               INTEGER NOT NULL,
+    new_license_level               INTEGER NOT NULL,
+    old_safety_rating               INTEGER NOT NULL,
+    new_safety_rating               INTEGER NOT NULL,
+    old_cpi                         REAL NOT NULL,
+    new_cpi                         REAL NOT NULL,
+    aggregate_champpoints           INTEGER NOT NULL,
+    champpoints                     INTEGER NOT NULL,
+    clubpoints                      INTEGER NOT NULL,
+    fk_car_id                       INTEGER NOT NULL,
+    car_class_id                    INTEGER NOT NULL,
+    starting_position               INTEGER NOT NULL,
+    position                        INTEGER NOT NULL,
+    finishing_position              INTEGER NOT NULL,
+    finishing_position_in_class     INTEGER NOT NULL,
+    division                        INTEGER NOT NULL,
+    interval                        INTEGER NOT NULL,
+    class_interval                  INTEGER NOT NULL,
+    avg_laptime                     INTEGER NOT NULL,
+    best_laptime                    INTEGER,
+    laps_completed                  INTEGER NOT NULL,
+    laps_lead                       INTEGER NOT NULL,
+    incidents                       INTEGER NOT NULL,
+    reason_out                      TEXT NOT NULL,
+    session_starttime               BIGINT NOT NULL,
+    FOREIGN KEY (fk_subsession_id) REFERENCES raceweek_results (subsession_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_car_id) REFERENCES cars (pk_car_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_race_result UNIQUE (fk_subsession_id, fk_driver_id)
+);
+
+-- time_rankings
+CREATE TABLE IF NOT EXISTS time_rankings (
+    fk_driver_id                INTEGER NOT NULL,
+    fk_raceweek_id              INTEGER NOT NULL,
+    fk_car_id                   INTEGER NOT NULL,
+    race                        INTEGER,
+    time_trial                  INTEGER,
+    time_trial_fastest_lap      INTEGER,
+    time_trial_subsession_id    INTEGER,
+    license_class               TEXT NOT NULL,
+    irating                     INTEGER NOT NULL,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_raceweek_id) REFERENCES raceweeks (pk_raceweek_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_car_id) REFERENCES cars (pk_car_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_time_ranking UNIQUE (fk_driver_id, fk_raceweek_id, fk_car_id)
+);
+
+-- time_trial_results
+CREATE TABLE IF NOT EXISTS time_trial_results (
+    fk_raceweek_id  INTEGER NOT NULL,
+    fk_driver_id    INTEGER NOT NULL,
+    car_class_id    INTEGER NOT NULL,
+    rank            INTEGER NOT NULL,
+    position        INTEGER NOT NULL,
+    points          INTEGER NOT NULL,
+    starts          INTEGER NOT NULL,
+    wins            INTEGER NOT NULL,
+    weeks           INTEGER NOT NULL,
+    dropped         INTEGER NOT NULL,
+    division        INTEGER NOT NULL,
+    last_update     TIMESTAMP,
+    FOREIGN KEY (fk_raceweek_id) REFERENCES raceweeks (pk_raceweek_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_time_trial_results UNIQUE (fk_driver_id, fk_raceweek_id, car_class_id)
+);
@@ -0,0 +1,2 @@
+-- series
+DELETE FROM series WHERE regex IN ('Formula 3\.5', 'Indy Pro 2000', 'F3 Championship', 'Radical Racing');
@@ -0,0 +1,9 @@
+-- series
+INSERT INTO series (name, short_name, regex, colorscheme)
+VALUES ('iRacing Formula 3.5 Championship', 'iRacing Formula 3.5 Championship', 'Formula 3\.5', 'yellow');
+INSERT INTO series (name, short_name, regex, colorscheme)
+VALUES ('Indy Pro 2000 Championship', 'Indy Pro 2000 Championship', 'Indy Pro 2000', 'indypro');
+INSERT INTO series (name, short_name, regex, colorscheme)
+VALUES ('iRacing F3 Championship', 'iRacing F3 Championship', 'F3 Championship', 'apex');
+INSERT INTO series (name, short_name, regex, colorscheme)
+VALUES ('Radical Racing Challenge C', 'Radical Racing Challenge', 'Radical Racing', 'radical');
@@ -0,0 +1,12 @@
+-- tokens
+CREATE TABLE IF NOT EXISTS tokens (
+    pk_name                     TEXT PRIMARY KEY,
+    access_token                TEXT NOT NULL,
+    refresh_token               TEXT NOT NULL,
+    token_type                  TEXT NOT NULL,
+    scope                       TEXT NOT NULL,
+    access_token_expires_at     TIMESTAMP NOT NULL,
+    refresh_token_expires_at    TIMESTAMP NOT NULL,
+    last_login                  TIMESTAMP NOT NULL,
+    last_update                 TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- jobs
+ALTER TABLE jobs DROP COLUMN params;
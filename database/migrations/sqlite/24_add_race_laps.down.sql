@@ -0,0 +1,2 @@
+-- race_laps
+DROP TABLE IF EXISTS race_laps;
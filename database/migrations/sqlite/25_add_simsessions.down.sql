@@ -0,0 +1,43 @@
+-- race_results of the feature race only
+CREATE TABLE race_results_old (
+    fk_subsession_id                INTEGER NOT NULL,
+    fk_driver_id                    INTEGER NOT NULL,
+    old_irating                     INTEGER NOT NULL,
+    new_irating                     INTEGER NOT NULL,
+    old_license_level               INTEGER NOT NULL,
+    new_license_level               INTEGER NOT NULL,
+    old_safety_rating               INTEGER NOT NULL,
+    new_safety_rating               INTEGER NOT NULL,
+    old_cpi                         REAL NOT NULL,
+    new_cpi                         REAL NOT NULL,
+    aggregate_champpoints           INTEGER NOT NULL,
+    champpoints                     INTEGER NOT NULL,
+    clubpoints                      INTEGER NOT NULL,
+    fk_car_id                       INTEGER NOT NULL,
+    car_class_id                    INTEGER NOT NULL,
+    starting_position               INTEGER NOT NULL,
+    position                        INTEGER NOT NULL,
+    finishing_position              INTEGER NOT NULL,
+    finishing_position_in_class     INTEGER NOT NULL,
+    division                        INTEGER NOT NULL,
+    interval                        INTEGER NOT NULL,
+    class_interval                  INTEGER NOT NULL,
+    avg_laptime                     INTEGER NOT NULL,
+    best_laptime                    INTEGER,
+    laps_completed                  INTEGER NOT NULL,
+    laps_lead                       INTEGER NOT NULL,
+    incidents                       INTEGER NOT NULL,
+    reason_out                      TEXT NOT NULL,
+    session_starttime               BIGINT NOT NULL,
+    FOREIGN KEY (fk_subsession_id) REFERENCES raceweek_results (subsession_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_car_id) REFERENCES cars (pk_car_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_race_result UNIQUE (fk_subsession_id, fk_driver_id)
+);
+INSERT INTO race_results_old (fk_subsession_id, fk_driver_id, old_irating, new_irating, old_license_level, new_license_level, old_safety_rating, new_safety_rating, old_cpi, new_cpi, aggregate_champpoints, champpoints, clubpoints, fk_car_id, car_class_id, starting_position, position, finishing_position, finishing_position_in_class, division, interval, class_interval, avg_laptime, best_laptime, laps_completed, laps_lead, incidents, reason_out, session_starttime)
+SELECT fk_subsession_id, fk_driver_id, old_irating, new_irating, old_license_level, new_license_level, old_safety_rating, new_safety_rating, old_cpi, new_cpi, aggregate_champpoints, champpoints, clubpoints, fk_car_id, car_class_id, starting_position, position, finishing_position, finishing_position_in_class, division, interval, class_interval, avg_laptime, best_laptime, laps_completed, laps_lead, incidents, reason_out, session_starttime FROM race_results WHERE simsession_number = 0;
+DROP TABLE race_results;
+ALTER TABLE race_results_old RENAME TO race_results;
+
+-- simsessions
+DROP TABLE simsessions;
@@ -0,0 +1,17 @@
+-- race_events
+CREATE TABLE IF NOT EXISTS race_events (
+    fk_subsession_id    INTEGER NOT NULL,
+    simsession_number   INTEGER NOT NULL,
+    event_seq           INTEGER NOT NULL,
+    lap                 INTEGER NOT NULL,
+    session_time        INTEGER NOT NULL,
+    fk_driver_id        INTEGER,
+    driver_name         TEXT NOT NULL,
+    event_code          INTEGER NOT NULL,
+    event_type          TEXT NOT NULL,
+    description         TEXT NOT NULL,
+    message             TEXT NOT NULL,
+    PRIMARY KEY (fk_subsession_id, simsession_number, event_seq),
+    FOREIGN KEY (fk_subsession_id) REFERENCES race_stats (fk_subsession_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE SET NULL
+);
@@ -0,0 +1,28 @@
+-- teams
+CREATE TABLE IF NOT EXISTS teams (
+    pk_team_id      INTEGER PRIMARY KEY AUTOINCREMENT,
+    name            TEXT NOT NULL UNIQUE
+);
+
+-- team_memberships, valid_to is exclusive and open-ended if null
+CREATE TABLE IF NOT EXISTS team_memberships (
+    pk_membership_id    INTEGER PRIMARY KEY AUTOINCREMENT,
+    fk_team_id          INTEGER NOT NULL,
+    fk_driver_id        INTEGER NOT NULL,
+    valid_from          TIMESTAMP NOT NULL,
+    valid_to            TIMESTAMP,
+    FOREIGN KEY (fk_team_id) REFERENCES teams (pk_team_id) ON DELETE CASCADE,
+    FOREIGN KEY (fk_driver_id) REFERENCES drivers (pk_driver_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_team_memberships_driver ON team_memberships (fk_driver_id, valid_from);
+
+-- carry over the teams set by hand on drivers.team, valid since forever
+INSERT INTO teams (name)
+SELECT DISTINCT team
+FROM drivers
+WHERE team IS NOT NULL AND team <> '';
+
+INSERT INTO team_memberships (fk_team_id, fk_driver_id, valid_from)
+SELECT t.pk_team_id, d.pk_driver_id, '1970-01-01 00:00:00'
+FROM drivers d
+    JOIN teams t ON (t.name = d.team);
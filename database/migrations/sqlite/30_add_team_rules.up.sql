@@ -0,0 +1,8 @@
+-- team_rules, series without rules use the defaults
+CREATE TABLE IF NOT EXISTS team_rules (
+    fk_series_id    INTEGER PRIMARY KEY,
+    top_drivers     INTEGER NOT NULL DEFAULT 3,
+    drop_weeks      INTEGER NOT NULL DEFAULT 0,
+    min_weeks       INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (fk_series_id) REFERENCES series (pk_series_id) ON DELETE CASCADE
+);
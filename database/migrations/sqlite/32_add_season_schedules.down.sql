@@ -0,0 +1,8 @@
+-- remove season_schedules and the schedule columns of seasons
+DROP TABLE IF EXISTS season_schedules;
+ALTER TABLE seasons
+DROP COLUMN max_weeks;
+ALTER TABLE seasons
+DROP COLUMN drop_weeks;
+ALTER TABLE seasons
+DROP COLUMN car_class_ids;
@@ -0,0 +1,23 @@
+-- add car classes and drop weeks of a season, as announced by iRacing
+ALTER TABLE seasons
+ADD COLUMN car_class_ids TEXT NOT NULL DEFAULT '';
+ALTER TABLE seasons
+ADD COLUMN drop_weeks INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seasons
+ADD COLUMN max_weeks INTEGER NOT NULL DEFAULT 12;
+
+-- season_schedules, the track of every raceweek of a season, known before any race has run
+-- tracks are referenced by id and name only, since a schedule can be stored before the track itself has been collected
+CREATE TABLE IF NOT EXISTS season_schedules (
+    fk_season_id    INTEGER NOT NULL,
+    raceweek        INTEGER NOT NULL CHECK (raceweek < 14),
+    start_date      TIMESTAMP NOT NULL,
+    fk_track_id     INTEGER NOT NULL,
+    track_name      TEXT NOT NULL,
+    track_config    TEXT NOT NULL,
+    track_category  TEXT NOT NULL,
+    race_laps       INTEGER NOT NULL DEFAULT 0,
+    race_time       INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (fk_season_id) REFERENCES seasons (pk_season_id) ON DELETE CASCADE,
+    CONSTRAINT uniq_season_schedule UNIQUE (fk_season_id, raceweek)
+);
@@ -1,37 +1,40 @@
 package database
 
 import (
+	"database/sql/driver"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Series struct {
-	SeriesID        int    `db:"pk_series_id"`
-	SeriesName      string `db:"name"`
-	SeriesNameShort string `db:"short_name"`
-	SeriesRegex     string `db:"regex"`
-	ColorScheme     string `db:"colorscheme"`
-	Active          string `db:"active"`
-	APISeriesID     int    `db:"api_series_id"`
-	CurrentSeason   string `db:"current_season"`
-	CurrentSeasonID int    `db:"current_season_id"`
-	CurrentWeek     int    `db:"current_week"`
+	SeriesID        int    `db:"pk_series_id" json:"pk_series_id"`
+	SeriesName      string `db:"name" json:"name"`
+	SeriesNameShort string `db:"short_name" json:"short_name"`
+	SeriesRegex     string `db:"regex" json:"regex"`
+	ColorScheme     string `db:"colorscheme" json:"colorscheme"`
+	Active          string `db:"active" json:"active"`
+	Pending         bool   `db:"pending" json:"pending"` // discovered automatically, waiting for approval
+	APISeriesID     int    `db:"api_series_id" json:"api_series_id"`
+	CurrentSeason   string `db:"current_season" json:"current_season"`
+	CurrentSeasonID int    `db:"current_season_id" json:"current_season_id"`
+	CurrentWeek     int    `db:"current_week" json:"current_week"`
 }
 
 type Track struct {
-	TrackID     int    `db:"pk_track_id"`
-	Name        string `db:"name"`
-	Config      string `db:"config"`
-	Category    string `db:"category"`
-	Free        bool   `db:"free_with_subscription"`
-	Retired     bool   `db:"retired"`
-	IsDirt      bool   `db:"is_dirt"`
-	IsOval      bool   `db:"is_oval"`
-	BannerImage string `db:"banner_image"`
-	PanelImage  string `db:"panel_image"`
-	LogoImage   string `db:"logo_image"`
-	MapImage    string `db:"map_image"`
-	ConfigImage string `db:"config_image"`
+	TrackID     int    `db:"pk_track_id" json:"pk_track_id"`
+	Name        string `db:"name" json:"name"`
+	Config      string `db:"config" json:"config"`
+	Category    string `db:"category" json:"category"`
+	Free        bool   `db:"free_with_subscription" json:"free_with_subscription"`
+	Retired     bool   `db:"retired" json:"retired"`
+	IsDirt      bool   `db:"is_dirt" json:"is_dirt"`
+	IsOval      bool   `db:"is_oval" json:"is_oval"`
+	BannerImage string `db:"banner_image" json:"banner_image"`
+	PanelImage  string `db:"panel_image" json:"panel_image"`
+	LogoImage   string `db:"logo_image" json:"logo_image"`
+	MapImage    string `db:"map_image" json:"map_image"`
+	ConfigImage string `db:"config_image" json:"config_image"`
 }
 
 func (t Track) String() string {
@@ -39,17 +42,17 @@ func (t Track) String() string {
 }
 
 type Car struct {
-	CarID        int    `db:"pk_car_id"`
-	Name         string `db:"name"`
-	Description  string `db:"description"`
-	Model        string `db:"model"`
-	Make         string `db:"make"`
-	PanelImage   string `db:"panel_image"`
-	LogoImage    string `db:"logo_image"`
-	CarImage     string `db:"car_image"`
-	Abbreviation string `db:"abbreviation"`
-	Free         bool   `db:"free_with_subscription"`
-	Retired      bool   `db:"retired"`
+	CarID        int    `db:"pk_car_id" json:"pk_car_id"`
+	Name         string `db:"name" json:"name"`
+	Description  string `db:"description" json:"description"`
+	Model        string `db:"model" json:"model"`
+	Make         string `db:"make" json:"make"`
+	PanelImage   string `db:"panel_image" json:"panel_image"`
+	LogoImage    string `db:"logo_image" json:"logo_image"`
+	CarImage     string `db:"car_image" json:"car_image"`
+	Abbreviation string `db:"abbreviation" json:"abbreviation"`
+	Free         bool   `db:"free_with_subscription" json:"free_with_subscription"`
+	Retired      bool   `db:"retired" json:"retired"`
 }
 
 func (c Car) String() string {
@@ -57,87 +60,112 @@ func (c Car) String() string {
 }
 
 type Season struct {
-	SeriesID          int       `db:"fk_series_id"` // foreign-key to Series.SeriesID
-	SeasonID          int       `db:"pk_season_id"`
-	Year              int       `db:"year"`
-	Quarter           int       `db:"quarter"`
-	Category          string    `db:"category"`
-	SeasonName        string    `db:"name"`
-	SeasonNameShort   string    `db:"short_name"`
-	BannerImage       string    `db:"banner_image"`
-	PanelImage        string    `db:"panel_image"`
-	LogoImage         string    `db:"logo_image"`
-	Timeslots         string    `db:"timeslots"`
-	StartDate         time.Time `db:"startdate"`
-	SeriesColorScheme string    `db:"series_colorscheme"` // data from Series.ColorScheme
+	SeriesID          int       `db:"fk_series_id" json:"fk_series_id"` // foreign-key to Series.SeriesID
+	SeasonID          int       `db:"pk_season_id" json:"pk_season_id"`
+	Year              int       `db:"year" json:"year"`
+	Quarter           int       `db:"quarter" json:"quarter"`
+	Category          string    `db:"category" json:"category"`
+	SeasonName        string    `db:"name" json:"name"`
+	SeasonNameShort   string    `db:"short_name" json:"short_name"`
+	BannerImage       string    `db:"banner_image" json:"banner_image"`
+	PanelImage        string    `db:"panel_image" json:"panel_image"`
+	LogoImage         string    `db:"logo_image" json:"logo_image"`
+	Timeslots         string    `db:"timeslots" json:"timeslots"`
+	StartDate         time.Time `db:"startdate" json:"startdate"`
+	SeriesColorScheme string    `db:"series_colorscheme" json:"series_colorscheme"` // data from Series.ColorScheme
+}
+
+// SeasonSchedule is the schedule of a season as announced by iRacing, stored before any race has run
+type SeasonSchedule struct {
+	SeasonID    int            `json:"season_id"`
+	CarClassIDs []int          `json:"car_class_ids"`
+	DropWeeks   int            `json:"drop_weeks"`
+	MaxWeeks    int            `json:"max_weeks"`
+	Weeks       []ScheduleWeek `json:"weeks"`
+}
+
+func (s SeasonSchedule) String() string {
+	return fmt.Sprintf("[ SeasonID: %d, CarClasses: %v, DropWeeks: %d, Weeks: %d ]", s.SeasonID, s.CarClassIDs, s.DropWeeks, len(s.Weeks))
+}
+
+type ScheduleWeek struct {
+	SeasonID      int       `db:"fk_season_id" json:"fk_season_id"` // foreign-key to Season.SeasonID
+	RaceWeek      int       `db:"raceweek" json:"raceweek"`
+	StartDate     time.Time `db:"start_date" json:"start_date"`
+	TrackID       int       `db:"fk_track_id" json:"fk_track_id"` // Track.TrackID, the track might not be collected yet
+	TrackName     string    `db:"track_name" json:"track_name"`
+	TrackConfig   string    `db:"track_config" json:"track_config"`
+	TrackCategory string    `db:"track_category" json:"track_category"`
+	RaceLaps      int       `db:"race_laps" json:"race_laps"` // 0 if the race length is timed
+	RaceTime      int       `db:"race_time" json:"race_time"` // minutes, 0 if the race length is a number of laps
 }
 
 type SeasonMetrics struct {
-	SeriesID                       int    `db:"series_id"` // foreign-key to Series.SeriesID
-	Year                           int    `db:"year"`
-	Quarter                        int    `db:"quarter"`
-	Timeslots                      string `db:"timeslots"`
-	Weeks                          int    `db:"weeks"`
-	Sessions                       int    `db:"nof_sessions"`
-	AvgSize                        int    `db:"avg_size"`
-	AvgSOF                         int    `db:"avg_sof"`
-	Drivers                        int    `db:"nof_drivers"`
-	UniqueDrivers                  int    `db:"nof_unique_drivers"`
-	UniqueRoadDrivers              int    `db:"nof_unique_road_drivers"`
-	UniqueCommittedRoadOnlyDrivers int    `db:"nof_unique_committed_road_only_drivers"`
-	UniqueOvalDrivers              int    `db:"nof_unique_oval_drivers"`
-	UniqueCommittedOvalOnlyDrivers int    `db:"nof_unique_committed_oval_only_drivers"`
-	UniqueBothDrivers              int    `db:"nof_unique_both_drivers"`
-	UniqueEightWeeksDrivers        int    `db:"nof_unique_eight_weeks_drivers"`
-	UniqueFullSeasonDrivers        int    `db:"nof_unique_full_season_drivers"`
+	SeriesID                       int    `db:"series_id" json:"series_id"` // foreign-key to Series.SeriesID
+	Year                           int    `db:"year" json:"year"`
+	Quarter                        int    `db:"quarter" json:"quarter"`
+	Timeslots                      string `db:"timeslots" json:"timeslots"`
+	Weeks                          int    `db:"weeks" json:"weeks"`
+	Sessions                       int    `db:"nof_sessions" json:"nof_sessions"`
+	AvgSize                        int    `db:"avg_size" json:"avg_size"`
+	AvgSOF                         int    `db:"avg_sof" json:"avg_sof"`
+	Drivers                        int    `db:"nof_drivers" json:"nof_drivers"`
+	UniqueDrivers                  int    `db:"nof_unique_drivers" json:"nof_unique_drivers"`
+	UniqueRoadDrivers              int    `db:"nof_unique_road_drivers" json:"nof_unique_road_drivers"`
+	UniqueCommittedRoadOnlyDrivers int    `db:"nof_unique_committed_road_only_drivers" json:"nof_unique_committed_road_only_drivers"`
+	UniqueOvalDrivers              int    `db:"nof_unique_oval_drivers" json:"nof_unique_oval_drivers"`
+	UniqueCommittedOvalOnlyDrivers int    `db:"nof_unique_committed_oval_only_drivers" json:"nof_unique_committed_oval_only_drivers"`
+	UniqueBothDrivers              int    `db:"nof_unique_both_drivers" json:"nof_unique_both_drivers"`
+	UniqueEightWeeksDrivers        int    `db:"nof_unique_eight_weeks_drivers" json:"nof_unique_eight_weeks_drivers"`
+	UniqueFullSeasonDrivers        int    `db:"nof_unique_full_season_drivers" json:"nof_unique_full_season_drivers"`
 }
 
 type RaceWeek struct {
-	SeasonID   int       `db:"fk_season_id"` // foreign-key to Season.SeasonID
-	RaceWeekID int       `db:"pk_raceweek_id"`
-	RaceWeek   int       `db:"raceweek"`
-	TrackID    int       `db:"fk_track_id"` // foreign-key to Track.TrackID
-	LastUpdate time.Time `db:"last_update"`
+	SeasonID   int       `db:"fk_season_id" json:"fk_season_id"` // foreign-key to Season.SeasonID
+	RaceWeekID int       `db:"pk_raceweek_id" json:"pk_raceweek_id"`
+	RaceWeek   int       `db:"raceweek" json:"raceweek"`
+	TrackID    int       `db:"fk_track_id" json:"fk_track_id"` // foreign-key to Track.TrackID
+	LastUpdate time.Time `db:"last_update" json:"last_update"`
 }
 
 type RaceWeekResult struct {
-	RaceWeekID      int       `db:"fk_raceweek_id"` // foreign-key to RaceWeek.RaceWeekID
-	StartTime       time.Time `db:"starttime"`
-	TrackID         int       `db:"fk_track_id"` // foreign-key to Track.TrackID
-	SessionID       int       `db:"session_id"`
-	SubsessionID    int       `db:"subsession_id"`
-	Official        bool      `db:"official"`
-	SizeOfField     int       `db:"size"`
-	StrengthOfField int       `db:"sof"`
+	RaceWeekID      int       `db:"fk_raceweek_id" json:"fk_raceweek_id"` // foreign-key to RaceWeek.RaceWeekID
+	StartTime       time.Time `db:"starttime" json:"starttime"`
+	TrackID         int       `db:"fk_track_id" json:"fk_track_id"` // foreign-key to Track.TrackID
+	SessionID       int       `db:"session_id" json:"session_id"`
+	SubsessionID    int       `db:"subsession_id" json:"subsession_id"`
+	Official        bool      `db:"official" json:"official"`
+	SizeOfField     int       `db:"size" json:"size"`
+	StrengthOfField int       `db:"sof" json:"sof"`
 }
 
 type RaceWeekMetrics struct {
-	SeasonID       int       `db:"season_id"` // foreign-key to Season.SeasonID
-	RaceWeek       int       `db:"raceweek"`
-	TimeOfDay      time.Time `db:"time_of_day"`
-	Laps           int       `db:"laps"`
-	AvgCautions    int       `db:"avg_cautions"`
-	AvgLaptime     Laptime   `db:"avg_laptime"`
-	FastestLaptime Laptime   `db:"fastest_laptime"`
-	MaxSOF         int       `db:"max_sof"`
-	MinSOF         int       `db:"min_sof"`
-	AvgSOF         int       `db:"avg_sof"`
-	AvgSize        int       `db:"avg_size"`
+	SeasonID       int       `db:"season_id" json:"season_id"` // foreign-key to Season.SeasonID
+	RaceWeek       int       `db:"raceweek" json:"raceweek"`
+	TimeOfDay      time.Time `db:"time_of_day" json:"time_of_day"`
+	Laps           int       `db:"laps" json:"laps"`
+	AvgCautions    int       `db:"avg_cautions" json:"avg_cautions"`
+	AvgLaptime     Laptime   `db:"avg_laptime" json:"avg_laptime"`
+	FastestLaptime Laptime   `db:"fastest_laptime" json:"fastest_laptime"`
+	MaxSOF         int       `db:"max_sof" json:"max_sof"`
+	MinSOF         int       `db:"min_sof" json:"min_sof"`
+	AvgSOF         int       `db:"avg_sof" json:"avg_sof"`
+	AvgSize        int       `db:"avg_size" json:"avg_size"`
 }
 
 type RaceStats struct {
-	SubsessionID       int       `db:"fk_subsession_id"` // foreign-key to RaceWeekResult.SubsessionID
-	StartTime          time.Time `db:"starttime"`
-	SimulatedStartTime time.Time `db:"simulated_starttime"`
-	LeadChanges        int       `db:"lead_changes"`
-	Laps               int       `db:"laps"`
-	Cautions           int       `db:"cautions"`
-	CautionLaps        int       `db:"caution_laps"`
-	CornersPerLap      int       `db:"corners_per_lap"`
-	AvgLaptime         Laptime   `db:"avg_laptime"`
-	AvgQualiLaps       int       `db:"avg_quali_laps"`
-	WeatherRH          int       `db:"weather_rh"`
-	WeatherTemp        int       `db:"weather_temp"`
+	SubsessionID       int       `db:"fk_subsession_id" json:"fk_subsession_id"` // foreign-key to RaceWeekResult.SubsessionID
+	StartTime          time.Time `db:"starttime" json:"starttime"`
+	SimulatedStartTime time.Time `db:"simulated_starttime" json:"simulated_starttime"`
+	LeadChanges        int       `db:"lead_changes" json:"lead_changes"`
+	Laps               int       `db:"laps" json:"laps"`
+	Cautions           int       `db:"cautions" json:"cautions"`
+	CautionLaps        int       `db:"caution_laps" json:"caution_laps"`
+	CornersPerLap      int       `db:"corners_per_lap" json:"corners_per_lap"`
+	AvgLaptime         Laptime   `db:"avg_laptime" json:"avg_laptime"`
+	AvgQualiLaps       int       `db:"avg_quali_laps" json:"avg_quali_laps"`
+	WeatherRH          int       `db:"weather_rh" json:"weather_rh"`
+	WeatherTemp        int       `db:"weather_temp" json:"weather_temp"`
 }
 
 func (rs RaceStats) String() string {
@@ -145,48 +173,49 @@ func (rs RaceStats) String() string {
 }
 
 type Club struct {
-	ClubID int    `db:"pk_club_id"`
-	Name   string `db:"name"`
+	ClubID int    `db:"pk_club_id" json:"pk_club_id"`
+	Name   string `db:"name" json:"name"`
 }
 
 type Driver struct {
-	DriverID int    `db:"pk_driver_id"`
-	Name     string `db:"name"`
-	Division int
-	Club     Club
-	Team     string `db:"team"`
+	DriverID int    `db:"pk_driver_id" json:"pk_driver_id"`
+	Name     string `db:"name" json:"name"`
+	Division int    `json:"division"`
+	Club     Club   `json:"club"`
+	Team     string `db:"team" json:"team"`
 }
 
 type RaceResult struct {
-	SubsessionID             int `db:"fk_subsession_id"` // foreign-key to RaceWeekResult.SubsessionID
-	Driver                   Driver
-	IRatingBefore            int     `db:"old_irating"`
-	IRatingAfter             int     `db:"new_irating"`
-	LicenseLevelBefore       int     `db:"old_license_level"`
-	LicenseLevelAfter        int     `db:"new_license_level"`
-	SafetyRatingBefore       int     `db:"old_safety_rating"`
-	SafetyRatingAfter        int     `db:"new_safety_rating"`
-	CPIBefore                float64 `db:"old_cpi"`
-	CPIAfter                 float64 `db:"new_cpi"`
-	AggregateChampPoints     int     `db:"aggregate_champpoints"`
-	ChampPoints              int     `db:"champpoints"`
-	ClubPoints               int     `db:"clubpoints"`
-	CarID                    int     `db:"fk_car_id"`
-	CarClassID               int     `db:"car_class_id"`
-	StartingPosition         int     `db:"starting_position"`
-	Position                 int     `db:"position"`
-	FinishingPosition        int     `db:"finishing_position"`
-	FinishingPositionInClass int     `db:"finishing_position_in_class"`
-	Division                 int     `db:"division"`
-	Interval                 int     `db:"interval"`
-	ClassInterval            int     `db:"class_interval"`
-	AvgLaptime               Laptime `db:"avg_laptime"`
-	BestLaptime              Laptime `db:"best_laptime"`
-	LapsCompleted            int     `db:"laps_completed"`
-	LapsLead                 int     `db:"laps_lead"`
-	Incidents                int     `db:"incidents"`
-	ReasonOut                string  `db:"reason_out"`
-	SessionStartTime         int64   `db:"session_starttime"`
+	SubsessionID             int     `db:"fk_subsession_id" json:"fk_subsession_id"`   // foreign-key to RaceWeekResult.SubsessionID
+	SimsessionNumber         int     `db:"simsession_number" json:"simsession_number"` // 0 is the feature race, negative numbers are heats, qualifying and practice
+	Driver                   Driver  `json:"driver"`
+	IRatingBefore            int     `db:"old_irating" json:"old_irating"`
+	IRatingAfter             int     `db:"new_irating" json:"new_irating"`
+	LicenseLevelBefore       int     `db:"old_license_level" json:"old_license_level"`
+	LicenseLevelAfter        int     `db:"new_license_level" json:"new_license_level"`
+	SafetyRatingBefore       int     `db:"old_safety_rating" json:"old_safety_rating"`
+	SafetyRatingAfter        int     `db:"new_safety_rating" json:"new_safety_rating"`
+	CPIBefore                float64 `db:"old_cpi" json:"old_cpi"`
+	CPIAfter                 float64 `db:"new_cpi" json:"new_cpi"`
+	AggregateChampPoints     int     `db:"aggregate_champpoints" json:"aggregate_champpoints"`
+	ChampPoints              int     `db:"champpoints" json:"champpoints"`
+	ClubPoints               int     `db:"clubpoints" json:"clubpoints"`
+	CarID                    int     `db:"fk_car_id" json:"fk_car_id"`
+	CarClassID               int     `db:"car_class_id" json:"car_class_id"`
+	StartingPosition         int     `db:"starting_position" json:"starting_position"`
+	Position                 int     `db:"position" json:"position"`
+	FinishingPosition        int     `db:"finishing_position" json:"finishing_position"`
+	FinishingPositionInClass int     `db:"finishing_position_in_class" json:"finishing_position_in_class"`
+	Division                 int     `db:"division" json:"division"`
+	Interval                 int     `db:"interval" json:"interval"`
+	ClassInterval            int     `db:"class_interval" json:"class_interval"`
+	AvgLaptime               Laptime `db:"avg_laptime" json:"avg_laptime"`
+	BestLaptime              Laptime `db:"best_laptime" json:"best_laptime"`
+	LapsCompleted            int     `db:"laps_completed" json:"laps_completed"`
+	LapsLead                 int     `db:"laps_lead" json:"laps_lead"`
+	Incidents                int     `db:"incidents" json:"incidents"`
+	ReasonOut                string  `db:"reason_out" json:"reason_out"`
+	SessionStartTime         int64   `db:"session_starttime" json:"session_starttime"`
 }
 
 func (rr RaceResult) String() string {
@@ -195,10 +224,23 @@ func (rr RaceResult) String() string {
 		rr.IRatingAfter, rr.Incidents, rr.ChampPoints, rr.ClubPoints, rr.ReasonOut)
 }
 
+type Simsession struct {
+	SubsessionID       int    `db:"fk_subsession_id" json:"fk_subsession_id"` // foreign-key to RaceWeekResult.SubsessionID
+	SimsessionNumber   int    `db:"simsession_number" json:"simsession_number"`
+	SimsessionType     int    `db:"simsession_type" json:"simsession_type"`
+	SimsessionTypeName string `db:"simsession_type_name" json:"simsession_type_name"` // Open Practice, Lone Qualifying, Race
+	SimsessionSubtype  int    `db:"simsession_subtype" json:"simsession_subtype"`
+	SimsessionName     string `db:"simsession_name" json:"simsession_name"` // PRACTICE, QUALIFY, RACE
+}
+
+func (s Simsession) String() string {
+	return fmt.Sprintf("[ SubsessionID: %d, Number: %d, Name: %s, Type: %s ]", s.SubsessionID, s.SimsessionNumber, s.SimsessionName, s.SimsessionTypeName)
+}
+
 type Points struct {
-	SubsessionID int `db:"subsession_id"`
-	Driver       Driver
-	ChampPoints  int `db:"champ_points"`
+	SubsessionID int    `db:"subsession_id" json:"subsession_id"`
+	Driver       Driver `json:"driver"`
+	ChampPoints  int    `db:"champ_points" json:"champ_points"`
 }
 
 func (p Points) String() string {
@@ -207,23 +249,23 @@ func (p Points) String() string {
 }
 
 type Summary struct {
-	Driver                 Driver
-	Division               int
-	HighestIRatingGain     int
-	TotalIRatingGain       int
-	TotalSafetyRatingGain  int
-	AverageIncidentsPerLap float64
-	LapsCompleted          int
-	LapsLead               int
-	Poles                  int
-	Wins                   int
-	Podiums                int
-	Top5                   int
-	TotalPositionsGained   int
-	AverageChampPoints     int
-	HighestChampPoints     int
-	TotalClubPoints        int
-	NumberOfRaces          int
+	Driver                 Driver  `json:"driver"`
+	Division               int     `json:"division"`
+	HighestIRatingGain     int     `json:"highest_irating_gain"`
+	TotalIRatingGain       int     `json:"total_irating_gain"`
+	TotalSafetyRatingGain  int     `json:"total_safety_rating_gain"`
+	AverageIncidentsPerLap float64 `json:"avg_incidents_per_lap"`
+	LapsCompleted          int     `json:"laps_completed"`
+	LapsLead               int     `json:"laps_lead"`
+	Poles                  int     `json:"poles"`
+	Wins                   int     `json:"wins"`
+	Podiums                int     `json:"podiums"`
+	Top5                   int     `json:"top5"`
+	TotalPositionsGained   int     `json:"total_positions_gained"`
+	AverageChampPoints     int     `json:"avg_champpoints"`
+	HighestChampPoints     int     `json:"highest_champpoints"`
+	TotalClubPoints        int     `json:"total_clubpoints"`
+	NumberOfRaces          int     `json:"nof_races"`
 }
 
 func (s Summary) String() string {
@@ -232,15 +274,15 @@ func (s Summary) String() string {
 }
 
 type TimeRanking struct {
-	Driver                Driver
-	RaceWeek              RaceWeek
-	Car                   Car
-	TimeTrialSubsessionID int     `db:"time_trial_subsession_id"`
-	TimeTrialFastestLap   Laptime `db:"time_trial_fastest_lap"`
-	TimeTrial             Laptime `db:"time_trial"`
-	Race                  Laptime `db:"race"`
-	LicenseClass          string  `db:"license_class"`
-	IRating               int     `db:"irating"`
+	Driver                Driver   `json:"driver"`
+	RaceWeek              RaceWeek `json:"raceweek"`
+	Car                   Car      `json:"car"`
+	TimeTrialSubsessionID int      `db:"time_trial_subsession_id" json:"time_trial_subsession_id"`
+	TimeTrialFastestLap   Laptime  `db:"time_trial_fastest_lap" json:"time_trial_fastest_lap"`
+	TimeTrial             Laptime  `db:"time_trial" json:"time_trial"`
+	Race                  Laptime  `db:"race" json:"race"`
+	LicenseClass          string   `db:"license_class" json:"license_class"`
+	IRating               int      `db:"irating" json:"irating"`
 }
 
 func (r TimeRanking) String() string {
@@ -248,17 +290,17 @@ func (r TimeRanking) String() string {
 }
 
 type TimeTrialResult struct {
-	RaceWeek   RaceWeek
-	Driver     Driver
-	CarClassID int `db:"car_class_id"`
-	Rank       int `db:"rank"`
-	Position   int `db:"pos"`
-	Points     int `db:"points"`
-	Starts     int `db:"starts"`
-	Wins       int `db:"wins"`
-	Weeks      int `db:"week"`
-	Dropped    int `db:"dropped"`
-	Division   int `db:"division"`
+	RaceWeek   RaceWeek `json:"raceweek"`
+	Driver     Driver   `json:"driver"`
+	CarClassID int      `db:"car_class_id" json:"car_class_id"`
+	Rank       int      `db:"rank" json:"rank"`
+	Position   int      `db:"pos" json:"pos"`
+	Points     int      `db:"points" json:"points"`
+	Starts     int      `db:"starts" json:"starts"`
+	Wins       int      `db:"wins" json:"wins"`
+	Weeks      int      `db:"week" json:"week"`
+	Dropped    int      `db:"dropped" json:"dropped"`
+	Division   int      `db:"division" json:"division"`
 }
 
 func (t TimeTrialResult) String() string {
@@ -267,10 +309,348 @@ func (t TimeTrialResult) String() string {
 }
 
 type FastestLaptime struct {
-	Driver  Driver
-	Laptime Laptime `db:"laptime"`
+	Driver  Driver  `json:"driver"`
+	Laptime Laptime `db:"laptime" json:"laptime"`
 }
 
 func (r FastestLaptime) String() string {
 	return fmt.Sprintf("[ Name: %s, Laptime: %s ]", r.Driver.Name, r.Laptime)
 }
+
+type Token struct {
+	Name                  string    `db:"pk_name" json:"name"`
+	AccessToken           string    `db:"access_token" json:"access_token"`
+	RefreshToken          string    `db:"refresh_token" json:"refresh_token"`
+	TokenType             string    `db:"token_type" json:"token_type"`
+	Scope                 string    `db:"scope" json:"scope"`
+	AccessTokenExpiresAt  time.Time `db:"access_token_expires_at" json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `db:"refresh_token_expires_at" json:"refresh_token_expires_at"`
+	LastLogin             time.Time `db:"last_login" json:"last_login"`
+	LastUpdate            time.Time `db:"last_update" json:"last_update"`
+}
+
+type RawPayload struct {
+	RawPayloadID int       `db:"pk_raw_payload_id" json:"raw_payload_id"`
+	Endpoint     string    `db:"endpoint" json:"endpoint"`
+	Params       string    `db:"params" json:"params"`
+	FetchedAt    time.Time `db:"fetched_at" json:"fetched_at"`
+	Payload      []byte    `db:"payload" json:"-"`
+}
+
+type Job struct {
+	JobID       int        `db:"pk_job_id" json:"job_id"`
+	Kind        string     `db:"kind" json:"kind"` // seasons, season, week
+	SeasonID    *int       `db:"season_id" json:"season_id,omitempty"`
+	Week        *int       `db:"week" json:"week,omitempty"`
+	Params      string     `db:"params" json:"params,omitempty"` // url encoded, for jobs that need more than season and week
+	Status      string     `db:"status" json:"status"`           // pending, running, done, failed, cancelled
+	Attempts    int        `db:"attempts" json:"attempts"`
+	Unit        string     `db:"unit" json:"unit"` // what Done and Total are counting, weeks or subsessions
+	Done        int        `db:"done" json:"done"`
+	Total       int        `db:"total" json:"total"`
+	Errors      JobErrors  `db:"errors" json:"errors"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	ScheduledAt time.Time  `db:"scheduled_at" json:"scheduled_at"`
+	StartedAt   *time.Time `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt  *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+func (j Job) String() string {
+	return fmt.Sprintf("[ JobID: %d, Kind: %s, Status: %s, Progress: %d/%d %s ]", j.JobID, j.Kind, j.Status, j.Done, j.Total, j.Unit)
+}
+
+// JobErrors are stored newline separated in the jobs table
+type JobErrors []string
+
+func (e JobErrors) Value() (driver.Value, error) {
+	return strings.Join(e, "\n"), nil
+}
+
+func (e *JobErrors) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into JobErrors", src)
+	}
+	*e = JobErrors{}
+	if len(s) > 0 {
+		*e = strings.Split(s, "\n")
+	}
+	return nil
+}
+
+type RaceLap struct {
+	SubsessionID int     `db:"fk_subsession_id" json:"fk_subsession_id"` // foreign-key to RaceStats.SubsessionID
+	Driver       Driver  `json:"driver"`
+	Lap          int     `db:"lap" json:"lap"`
+	Laptime      Laptime `db:"laptime" json:"laptime"` // 0 for laps without a valid time
+	Position     int     `db:"position" json:"position"`
+	Interval     int     `db:"interval" json:"interval"`
+	SessionTime  int     `db:"session_time" json:"session_time"`
+	Flags        int     `db:"flags" json:"flags"`
+	Pitted       bool    `db:"pitted" json:"pitted"`
+	OffTrack     bool    `db:"off_track" json:"off_track"`
+	Incident     bool    `db:"incident" json:"incident"`
+	Events       string  `db:"events" json:"events"`
+}
+
+func (l RaceLap) String() string {
+	return fmt.Sprintf("[ SubsessionID: %d, Driver: %d, Lap: %d, Laptime: %s, Position: %d ]", l.SubsessionID, l.Driver.DriverID, l.Lap, l.Laptime, l.Position)
+}
+
+type RaceEvent struct {
+	SubsessionID     int    `db:"fk_subsession_id" json:"fk_subsession_id"` // foreign-key to RaceStats.SubsessionID
+	SimsessionNumber int    `db:"simsession_number" json:"simsession_number"`
+	EventSeq         int    `db:"event_seq" json:"event_seq"`
+	Lap              int    `db:"lap" json:"lap"`
+	SessionTime      int    `db:"session_time" json:"session_time"`
+	DriverID         *int   `db:"fk_driver_id" json:"fk_driver_id,omitempty"` // nil for events without a (known) driver
+	DriverName       string `db:"driver_name" json:"driver_name"`
+	EventCode        int    `db:"event_code" json:"event_code"`
+	EventType        string `db:"event_type" json:"event_type"` // caution, incident, pit or other
+	Description      string `db:"description" json:"description"`
+	Message          string `db:"message" json:"message"`
+}
+
+func (e RaceEvent) String() string {
+	return fmt.Sprintf("[ SubsessionID: %d, Seq: %d, Lap: %d, Driver: %s, Type: %s, Description: %s ]", e.SubsessionID, e.EventSeq, e.Lap, e.DriverName, e.EventType, e.Description)
+}
+
+type PitStopSummary struct {
+	SubsessionID int    `db:"subsession_id" json:"subsession_id"` // 0 if summarized over a whole raceweek
+	Driver       Driver `json:"driver"`
+	Races        int    `db:"races" json:"races"`
+	PitStops     int    `db:"pit_stops" json:"pit_stops"`
+	FirstPitLap  int    `db:"first_pit_lap" json:"first_pit_lap"`
+	LastPitLap   int    `db:"last_pit_lap" json:"last_pit_lap"`
+}
+
+type DriverName struct {
+	DriverID  int       `db:"fk_driver_id" json:"fk_driver_id"`
+	Name      string    `db:"name" json:"name"`
+	FirstSeen time.Time `db:"first_seen" json:"first_seen"`
+	LastSeen  time.Time `db:"last_seen" json:"last_seen"`
+}
+
+// DriverNameMatch is a driver found by one of its current or former names
+type DriverNameMatch struct {
+	DriverID    int       `db:"fk_driver_id" json:"fk_driver_id"`
+	Name        string    `db:"name" json:"name"`
+	CurrentName string    `db:"current_name" json:"current_name"`
+	FirstSeen   time.Time `db:"first_seen" json:"first_seen"`
+	LastSeen    time.Time `db:"last_seen" json:"last_seen"`
+}
+
+type Team struct {
+	TeamID int    `db:"pk_team_id" json:"pk_team_id"`
+	Name   string `db:"name" json:"name"`
+}
+
+func (t Team) String() string {
+	return fmt.Sprintf("[ TeamID: %d, Name: %s ]", t.TeamID, t.Name)
+}
+
+// TeamMembership is a driver being part of a team from ValidFrom until ValidTo (exclusive), or until further notice if ValidTo is nil
+type TeamMembership struct {
+	MembershipID int        `db:"pk_membership_id" json:"pk_membership_id"`
+	TeamID       int        `db:"fk_team_id" json:"fk_team_id"`
+	TeamName     string     `db:"team_name" json:"team_name"`
+	DriverID     int        `db:"fk_driver_id" json:"fk_driver_id"`
+	DriverName   string     `db:"driver_name" json:"driver_name"`
+	ValidFrom    time.Time  `db:"valid_from" json:"valid_from"`
+	ValidTo      *time.Time `db:"valid_to" json:"valid_to"`
+}
+
+// Covers tells whether the membership is valid at the given time
+func (m TeamMembership) Covers(t time.Time) bool {
+	return !t.Before(m.ValidFrom) && (m.ValidTo == nil || t.Before(*m.ValidTo))
+}
+
+// Overlaps tells whether both memberships are valid at some point in time
+func (m TeamMembership) Overlaps(o TeamMembership) bool {
+	return (o.ValidTo == nil || m.ValidFrom.Before(*o.ValidTo)) &&
+		(m.ValidTo == nil || o.ValidFrom.Before(*m.ValidTo))
+}
+
+// TeamRules decide how the champpoints of the drivers of a team add up to the team standings of a series
+type TeamRules struct {
+	SeriesID   int `db:"fk_series_id" json:"fk_series_id"`
+	TopDrivers int `db:"top_drivers" json:"top_drivers"` // best scoring drivers of a team counted per raceweek
+	DropWeeks  int `db:"drop_weeks" json:"drop_weeks"`   // worst raceweeks of a team that are not counted
+	MinWeeks   int `db:"min_weeks" json:"min_weeks"`     // raceweeks a team needs to have scored in to be classified
+}
+
+func DefaultTeamRules(seriesID int) TeamRules {
+	return TeamRules{SeriesID: seriesID, TopDrivers: 3, DropWeeks: 0, MinWeeks: 1}
+}
+
+func (r TeamRules) String() string {
+	return fmt.Sprintf("[ SeriesID: %d, TopDrivers: %d, DropWeeks: %d, MinWeeks: %d ]", r.SeriesID, r.TopDrivers, r.DropWeeks, r.MinWeeks)
+}
+
+// TeamPoints are the champpoints of the best official race of a driver in a raceweek, for the team the driver was part of back then
+type TeamPoints struct {
+	RaceWeek    int    `db:"raceweek" json:"raceweek"`
+	TeamID      int    `db:"team_id" json:"team_id"`
+	TeamName    string `db:"team_name" json:"team_name"`
+	DriverID    int    `db:"driver_id" json:"driver_id"`
+	DriverName  string `db:"driver_name" json:"driver_name"`
+	ChampPoints int    `db:"champ_points" json:"champ_points"`
+	Counted     bool   `json:"counted"` // among the top drivers of the team that raceweek
+}
+
+type TeamRaceWeek struct {
+	RaceWeek int          `json:"raceweek"`
+	Points   int          `json:"points"`
+	Dropped  bool         `json:"dropped"`
+	Drivers  []TeamPoints `json:"drivers"`
+}
+
+type TeamStanding struct {
+	Position   int            `json:"position"` // 0 if not classified
+	Team       Team           `json:"team"`
+	Points     int            `json:"points"`
+	Weeks      int            `json:"weeks"` // raceweeks the team scored in
+	Classified bool           `json:"classified"`
+	RaceWeeks  []TeamRaceWeek `json:"raceweeks"`
+}
+
+type DriverProfile struct {
+	Driver      Driver              `json:"driver"`
+	MemberSince *time.Time          `db:"member_since" json:"member_since,omitempty"`
+	LastLogin   *time.Time          `db:"last_login" json:"last_login,omitempty"`
+	RefreshedAt *time.Time          `db:"refreshed_at" json:"refreshed_at,omitempty"` // nil if not enriched yet
+	Names       []DriverName        `json:"names"`                                    // every name the driver has been seen with, most recent first
+	Licenses    []DriverLicense     `json:"licenses"`
+	CareerStats []DriverCareerStats `json:"career_stats"`
+}
+
+func (p DriverProfile) String() string {
+	return fmt.Sprintf("[ Driver: %d, Name: %s, Licenses: %d, CareerStats: %d ]", p.Driver.DriverID, p.Driver.Name, len(p.Licenses), len(p.CareerStats))
+}
+
+type DriverLicense struct {
+	DriverID     int     `db:"fk_driver_id" json:"fk_driver_id"` // foreign-key to Driver.DriverID
+	CategoryID   int     `db:"category_id" json:"category_id"`
+	Category     string  `db:"category" json:"category"` // oval, road, dirt_oval, dirt_road
+	LicenseLevel int     `db:"license_level" json:"license_level"`
+	Group        string  `db:"group_name" json:"group_name"` // Rookie, Class D, ..., Pro/WC
+	SafetyRating float64 `db:"safety_rating" json:"safety_rating"`
+	CPI          float64 `db:"cpi" json:"cpi"`
+	IRating      int     `db:"irating" json:"irating"`
+	TTRating     int     `db:"tt_rating" json:"tt_rating"`
+	Color        string  `db:"color" json:"color"`
+}
+
+type DriverCareerStats struct {
+	DriverID          int     `db:"fk_driver_id" json:"fk_driver_id"` // foreign-key to Driver.DriverID
+	CategoryID        int     `db:"category_id" json:"category_id"`
+	Category          string  `db:"category" json:"category"`
+	Starts            int     `db:"starts" json:"starts"`
+	Wins              int     `db:"wins" json:"wins"`
+	Top5              int     `db:"top5" json:"top5"`
+	Poles             int     `db:"poles" json:"poles"`
+	AvgStartPosition  float64 `db:"avg_start_position" json:"avg_start_position"`
+	AvgFinishPosition float64 `db:"avg_finish_position" json:"avg_finish_position"`
+	Laps              int     `db:"laps" json:"laps"`
+	LapsLed           int     `db:"laps_led" json:"laps_led"`
+	AvgIncidents      float64 `db:"avg_incidents" json:"avg_incidents"`
+	AvgPoints         float64 `db:"avg_points" json:"avg_points"`
+	WinPercentage     float64 `db:"win_percentage" json:"win_percentage"`
+	Top5Percentage    float64 `db:"top5_percentage" json:"top5_percentage"`
+	LapsLedPercentage float64 `db:"laps_led_percentage" json:"laps_led_percentage"`
+	TotalClubPoints   int     `db:"total_club_points" json:"total_club_points"`
+}
+
+// DriverFilter narrows down the results of a driver, nil fields are not filtered on
+type DriverFilter struct {
+	SeasonID   *int
+	Week       *int
+	SeriesID   *int
+	CarClassID *int
+	Limit      int // defaults to 100, at most 1000
+	Offset     int
+}
+
+func (f DriverFilter) limit() int {
+	switch {
+	case f.Limit <= 0:
+		return 100
+	case f.Limit > 1000:
+		return 1000
+	}
+	return f.Limit
+}
+
+type DriverRaceResult struct {
+	SeriesID        int        `db:"series_id" json:"series_id"`
+	SeasonID        int        `db:"season_id" json:"season_id"`
+	RaceWeek        int        `db:"raceweek" json:"raceweek"`
+	TrackID         int        `db:"track_id" json:"track_id"`
+	StartTime       time.Time  `db:"starttime" json:"starttime"`
+	SizeOfField     int        `db:"size" json:"size"`
+	StrengthOfField int        `db:"sof" json:"sof"`
+	Result          RaceResult `json:"result"`
+}
+
+type DriverSeasonSummary struct {
+	SeriesID          int     `db:"series_id" json:"series_id"`
+	SeasonID          int     `db:"season_id" json:"season_id"`
+	Year              int     `db:"year" json:"year"`
+	Quarter           int     `db:"quarter" json:"quarter"`
+	SeasonName        string  `db:"season_name" json:"season_name"`
+	Races             int     `db:"races" json:"races"`
+	Wins              int     `db:"wins" json:"wins"`
+	Top5              int     `db:"top5" json:"top5"`
+	Poles             int     `db:"poles" json:"poles"`
+	AvgStartPosition  float64 `db:"avg_start_position" json:"avg_start_position"`
+	AvgFinishPosition float64 `db:"avg_finish_position" json:"avg_finish_position"`
+	AvgIncidents      float64 `db:"avg_incidents" json:"avg_incidents"`
+	LapsCompleted     int     `db:"laps_completed" json:"laps_completed"`
+	LapsLead          int     `db:"laps_lead" json:"laps_lead"`
+	ChampPoints       int     `db:"champpoints" json:"champpoints"`
+	BestLaptime       Laptime `db:"best_laptime" json:"best_laptime"`
+	IRatingGain       int     `db:"irating_gain" json:"irating_gain"`
+	TimeRankingWeeks  int     `db:"time_ranking_weeks" json:"time_ranking_weeks"` // weeks with a time ranking entry
+	TimeTrialWeeks    int     `db:"time_trial_weeks" json:"time_trial_weeks"`
+	TimeTrialPoints   int     `db:"time_trial_points" json:"time_trial_points"`
+}
+
+type RatingPoint struct {
+	SubsessionID       int       `db:"subsession_id" json:"subsession_id"`
+	SeasonID           int       `db:"season_id" json:"season_id"`
+	StartTime          time.Time `db:"starttime" json:"starttime"`
+	CarClassID         int       `db:"car_class_id" json:"car_class_id"`
+	Category           string    `db:"category" json:"category"` // license category the ratings belong to, taken from the track: road, oval, dirt road, dirt oval
+	IRatingBefore      int       `db:"old_irating" json:"old_irating"`
+	IRatingAfter       int       `db:"new_irating" json:"new_irating"`
+	SafetyRatingBefore int       `db:"old_safety_rating" json:"old_safety_rating"`
+	SafetyRatingAfter  int       `db:"new_safety_rating" json:"new_safety_rating"`
+	LicenseLevelBefore int       `db:"old_license_level" json:"old_license_level"`
+	LicenseLevelAfter  int       `db:"new_license_level" json:"new_license_level"`
+}
+
+// RatingTrajectory is the downsampled rating history of a driver in one license category, iRating and safety rating are separate per category
+type RatingTrajectory struct {
+	Category string         `json:"category"`
+	Samples  []RatingSample `json:"samples"`
+}
+
+// RatingSample is a downsampled point of a drivers rating trajectory, values are taken from the last race within the period
+type RatingSample struct {
+	Period        time.Time `json:"period"` // start of the day or week, or the starttime of the race
+	SeasonID      int       `json:"season_id"`
+	SeasonStart   bool      `json:"season_start"` // first sample of a season within the license category
+	Races         int       `json:"races"`
+	IRating       int       `json:"irating"`
+	IRatingMin    int       `json:"irating_min"`
+	IRatingMax    int       `json:"irating_max"`
+	IRatingChange int       `json:"irating_change"`
+	SafetyRating  int       `json:"safety_rating"`
+	LicenseLevel  int       `json:"license_level"`
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// likeEscaper escapes the wildcards of a like pattern, so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetDriverNamesByDriverID returns every name a driver has been seen with, most recent first
+func (db *database) GetDriverNamesByDriverID(ctx context.Context, driverID int) ([]DriverName, error) {
+	names := make([]DriverName, 0)
+	if err := db.SelectContext(ctx, &names, `
+		select
+			n.fk_driver_id,
+			n.name,
+			n.first_seen,
+			n.last_seen
+		from driver_names n
+		where n.fk_driver_id = $1
+		order by n.last_seen desc, n.first_seen desc`, driverID); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// SearchDriversByName returns the drivers with a current or former name containing the given text, case-insensitive
+func (db *database) SearchDriversByName(ctx context.Context, name string) ([]DriverNameMatch, error) {
+	matches := make([]DriverNameMatch, 0)
+	if err := db.SelectContext(ctx, &matches, `
+		select
+			n.fk_driver_id,
+			n.name,
+			n.first_seen,
+			n.last_seen,
+			d.name as current_name
+		from driver_names n
+			join drivers d on (d.pk_driver_id = n.fk_driver_id)
+		where lower(n.name) like '%' || lower($1) || '%' escape '\'
+		order by d.name asc, n.fk_driver_id asc, n.last_seen desc
+		limit 100`, likeEscaper.Replace(name)); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
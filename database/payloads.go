@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+)
+
+func (db *database) InsertRawPayload(ctx context.Context, payload RawPayload) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into raw_payloads
+			(endpoint, params, fetched_at, payload)
+		values ($1, $2, $3, $4)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		payload.Endpoint, payload.Params, payload.FetchedAt.UTC(), payload.Payload); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *database) GetLatestRawPayload(ctx context.Context, endpoint, params string) (RawPayload, error) {
+	payload := RawPayload{}
+	if err := db.GetContext(ctx, &payload, `
+		select
+			p.pk_raw_payload_id,
+			p.endpoint,
+			p.params,
+			p.fetched_at,
+			p.payload
+		from raw_payloads p
+		where p.endpoint = $1
+		and p.params = $2
+		order by p.fetched_at desc, p.pk_raw_payload_id desc
+		limit 1`, endpoint, params); err != nil {
+		return payload, err
+	}
+	return payload, nil
+}
+
+func (db *database) GetRawPayloadParams(ctx context.Context, endpoint string) ([]string, error) {
+	params := make([]string, 0)
+	if err := db.SelectContext(ctx, &params, `
+		select distinct p.params
+		from raw_payloads p
+		where p.endpoint = $1
+		order by p.params`, endpoint); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// UpsertDriverProfile stores the member profile of a driver, replacing all previously stored licenses and career stats
+func (db *database) UpsertDriverProfile(ctx context.Context, profile DriverProfile) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into driver_profiles
+			(fk_driver_id, member_since, last_login, refreshed_at)
+		values ($1, $2, $3, $4)
+		on conflict (fk_driver_id) do update
+		set member_since = excluded.member_since,
+			last_login = excluded.last_login,
+			refreshed_at = excluded.refreshed_at`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	if _, err = stmt.ExecContext(ctx,
+		profile.Driver.DriverID, profile.MemberSince, profile.LastLogin, profile.RefreshedAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, query := range []string{
+		`delete from driver_licenses where fk_driver_id = $1`,
+		`delete from driver_career_stats where fk_driver_id = $1`,
+	} {
+		stmt, err := tx.PreparexContext(ctx, query)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		defer stmt.Close()
+		if _, err = stmt.ExecContext(ctx, profile.Driver.DriverID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	licenseStmt, err := tx.PreparexContext(ctx, `
+		insert into driver_licenses
+			(fk_driver_id, category_id, category, license_level, group_name,
+			safety_rating, cpi, irating, tt_rating, color)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer licenseStmt.Close()
+	for _, l := range profile.Licenses {
+		if _, err = licenseStmt.ExecContext(ctx,
+			profile.Driver.DriverID, l.CategoryID, l.Category, l.LicenseLevel, l.Group,
+			l.SafetyRating, l.CPI, l.IRating, l.TTRating, l.Color); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	statsStmt, err := tx.PreparexContext(ctx, `
+		insert into driver_career_stats
+			(fk_driver_id, category_id, category, starts, wins, top5, poles,
+			avg_start_position, avg_finish_position, laps, laps_led, avg_incidents, avg_points,
+			win_percentage, top5_percentage, laps_led_percentage, total_club_points)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer statsStmt.Close()
+	for _, s := range profile.CareerStats {
+		if _, err = statsStmt.ExecContext(ctx,
+			profile.Driver.DriverID, s.CategoryID, s.Category, s.Starts, s.Wins, s.Top5, s.Poles,
+			s.AvgStartPosition, s.AvgFinishPosition, s.Laps, s.LapsLed, s.AvgIncidents, s.AvgPoints,
+			s.WinPercentage, s.Top5Percentage, s.LapsLedPercentage, s.TotalClubPoints); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDriverProfileByDriverID returns a driver with its member profile, licenses and career stats.
+// Drivers that have not been enriched yet are returned without them.
+func (db *database) GetDriverProfileByDriverID(ctx context.Context, driverID int) (DriverProfile, error) {
+	profile := DriverProfile{
+		Names:       make([]DriverName, 0),
+		Licenses:    make([]DriverLicense, 0),
+		CareerStats: make([]DriverCareerStats, 0),
+	}
+	driver, err := db.GetDriverByID(ctx, driverID)
+	if err != nil {
+		return profile, err
+	}
+	profile.Driver = driver
+	if profile.Names, err = db.GetDriverNamesByDriverID(ctx, driverID); err != nil {
+		return profile, err
+	}
+
+	if err := db.QueryRowxContext(ctx, `
+		select
+			p.member_since,
+			p.last_login,
+			p.refreshed_at
+		from driver_profiles p
+		where p.fk_driver_id = $1`, driverID).Scan(
+		&profile.MemberSince, &profile.LastLogin, &profile.RefreshedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return profile, nil
+		}
+		return profile, err
+	}
+
+	if err := db.SelectContext(ctx, &profile.Licenses, `
+		select
+			l.fk_driver_id,
+			l.category_id,
+			l.category,
+			l.license_level,
+			l.group_name,
+			l.safety_rating,
+			l.cpi,
+			l.irating,
+			l.tt_rating,
+			l.color
+		from driver_licenses l
+		where l.fk_driver_id = $1
+		order by l.category_id asc`, driverID); err != nil {
+		return profile, err
+	}
+
+	if err := db.SelectContext(ctx, &profile.CareerStats, `
+		select
+			s.fk_driver_id,
+			s.category_id,
+			s.category,
+			s.starts,
+			s.wins,
+			s.top5,
+			s.poles,
+			s.avg_start_position,
+			s.avg_finish_position,
+			s.laps,
+			s.laps_led,
+			s.avg_incidents,
+			s.avg_points,
+			s.win_percentage,
+			s.top5_percentage,
+			s.laps_led_percentage,
+			s.total_club_points
+		from driver_career_stats s
+		where s.fk_driver_id = $1
+		order by s.category_id asc`, driverID); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+// GetDriverIDsToEnrich returns all drivers without a profile, or with one last refreshed before the given time, oldest first
+func (db *database) GetDriverIDsToEnrich(ctx context.Context, refreshedBefore time.Time) ([]int, error) {
+	ids := make([]int, 0)
+	if err := db.SelectContext(ctx, &ids, `
+		select
+			d.pk_driver_id
+		from drivers d
+			left join driver_profiles p on (p.fk_driver_id = d.pk_driver_id)
+		where p.refreshed_at is null
+		or p.refreshed_at < $1
+		order by case when p.refreshed_at is null then 0 else 1 end asc, p.refreshed_at asc, d.pk_driver_id asc`, refreshedBefore); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RatingIntervalRace = "race"
+	RatingIntervalDay  = "day"
+	RatingIntervalWeek = "week"
+)
+
+// DownsampleRatings aggregates the rating history of a driver into one sample per race, day or week (starting on monday, UTC).
+// iRating and safety rating are separate per license category, so each category gets a trajectory of its own.
+func DownsampleRatings(points []RatingPoint, interval string) ([]RatingTrajectory, error) {
+	var period func(time.Time) time.Time
+	switch interval {
+	case RatingIntervalRace:
+		period = func(t time.Time) time.Time { return t.UTC() }
+	case RatingIntervalDay:
+		period = func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+	case RatingIntervalWeek:
+		period = func(t time.Time) time.Time {
+			t = t.UTC()
+			day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		}
+	default:
+		return nil, fmt.Errorf("%w: rating interval %s", ErrInvalid, interval)
+	}
+
+	trajectories := make([]RatingTrajectory, 0)
+	index := make(map[string]int)
+	for _, category := range ratingCategories(points) {
+		index[category] = len(trajectories)
+		trajectories = append(trajectories, RatingTrajectory{Category: category, Samples: make([]RatingSample, 0)})
+	}
+
+	seasons := make(map[string]map[int]bool)
+	for _, point := range points {
+		trajectory := &trajectories[index[point.Category]]
+		p := period(point.StartTime)
+		if seasons[point.Category] == nil {
+			seasons[point.Category] = make(map[int]bool)
+		}
+		seasonStart := !seasons[point.Category][point.SeasonID]
+		seasons[point.Category][point.SeasonID] = true
+
+		// races with the same starttime are still separate samples when not downsampling
+		samples := trajectory.Samples
+		if len(samples) == 0 || !samples[len(samples)-1].Period.Equal(p) || interval == RatingIntervalRace {
+			trajectory.Samples = append(trajectory.Samples, RatingSample{
+				Period:     p,
+				IRatingMin: point.IRatingAfter,
+				IRatingMax: point.IRatingAfter,
+			})
+		}
+		sample := &trajectory.Samples[len(trajectory.Samples)-1]
+		sample.SeasonID = point.SeasonID
+		sample.SeasonStart = sample.SeasonStart || seasonStart
+		sample.Races++
+		sample.IRating = point.IRatingAfter
+		if point.IRatingAfter < sample.IRatingMin {
+			sample.IRatingMin = point.IRatingAfter
+		}
+		if point.IRatingAfter > sample.IRatingMax {
+			sample.IRatingMax = point.IRatingAfter
+		}
+		sample.IRatingChange += point.IRatingAfter - point.IRatingBefore
+		sample.SafetyRating = point.SafetyRatingAfter
+		sample.LicenseLevel = point.LicenseLevelAfter
+	}
+	return trajectories, nil
+}
+
+// ratingCategories returns the license categories of a rating history, in the order a driver first raced in them
+func ratingCategories(points []RatingPoint) []string {
+	categories := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, point := range points {
+		if !seen[point.Category] {
+			seen[point.Category] = true
+			categories = append(categories, point.Category)
+		}
+	}
+	return categories
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+func (db *database) GetSeasonScheduleBySeasonID(ctx context.Context, seasonID int) (SeasonSchedule, error) {
+	schedule := SeasonSchedule{}
+	var classIDs string
+	if err := db.QueryRowxContext(ctx, `
+		select
+			s.pk_season_id,
+			s.car_class_ids,
+			s.drop_weeks,
+			s.max_weeks
+		from seasons s
+		where s.pk_season_id = $1`, seasonID).Scan(
+		&schedule.SeasonID, &classIDs, &schedule.DropWeeks, &schedule.MaxWeeks,
+	); err != nil {
+		return SeasonSchedule{}, err
+	}
+
+	schedule.CarClassIDs = make([]int, 0)
+	for _, id := range strings.Split(classIDs, ",") {
+		if classID, err := strconv.Atoi(strings.TrimSpace(id)); err == nil {
+			schedule.CarClassIDs = append(schedule.CarClassIDs, classID)
+		}
+	}
+
+	schedule.Weeks = make([]ScheduleWeek, 0)
+	if err := db.SelectContext(ctx, &schedule.Weeks, `
+		select
+			w.fk_season_id,
+			w.raceweek,
+			w.start_date,
+			w.fk_track_id,
+			w.track_name,
+			w.track_config,
+			w.track_category,
+			w.race_laps,
+			w.race_time
+		from season_schedules w
+		where w.fk_season_id = $1
+		order by w.raceweek asc`, seasonID); err != nil {
+		return SeasonSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// UpsertSeasonSchedule stores the car classes, drop weeks and raceweeks of an already existing season
+func (db *database) UpsertSeasonSchedule(ctx context.Context, schedule SeasonSchedule) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	classIDs := make([]string, 0, len(schedule.CarClassIDs))
+	for _, id := range schedule.CarClassIDs {
+		classIDs = append(classIDs, strconv.Itoa(id))
+	}
+	if _, err := tx.ExecContext(ctx, `
+		update seasons
+		set car_class_ids = $2,
+			drop_weeks = $3,
+			max_weeks = $4
+		where pk_season_id = $1`,
+		schedule.SeasonID, strings.Join(classIDs, ","), schedule.DropWeeks, schedule.MaxWeeks); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into season_schedules
+			(fk_season_id, raceweek, start_date, fk_track_id, track_name, track_config, track_category, race_laps, race_time)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		on conflict (fk_season_id, raceweek) do update
+		set start_date = excluded.start_date,
+			fk_track_id = excluded.fk_track_id,
+			track_name = excluded.track_name,
+			track_config = excluded.track_config,
+			track_category = excluded.track_category,
+			race_laps = excluded.race_laps,
+			race_time = excluded.race_time`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, week := range schedule.Weeks {
+		if _, err := stmt.ExecContext(ctx,
+			schedule.SeasonID, week.RaceWeek, week.StartDate.UTC(), week.TrackID,
+			week.TrackName, week.TrackConfig, week.TrackCategory, week.RaceLaps, week.RaceTime); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+)
+
+func (db *database) UpsertSimsession(ctx context.Context, simsession Simsession) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into simsessions
+			(fk_subsession_id, simsession_number, simsession_type, simsession_type_name, simsession_subtype, simsession_name)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (fk_subsession_id, simsession_number) do update
+		set simsession_type = excluded.simsession_type,
+			simsession_type_name = excluded.simsession_type_name,
+			simsession_subtype = excluded.simsession_subtype,
+			simsession_name = excluded.simsession_name`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		simsession.SubsessionID, simsession.SimsessionNumber, simsession.SimsessionType,
+		simsession.SimsessionTypeName, simsession.SimsessionSubtype, simsession.SimsessionName); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *database) GetSimsessionsBySubsessionID(ctx context.Context, subsessionID int) ([]Simsession, error) {
+	simsessions := make([]Simsession, 0)
+	if err := db.SelectContext(ctx, &simsessions, `
+		select
+			s.fk_subsession_id,
+			s.simsession_number,
+			s.simsession_type,
+			s.simsession_type_name,
+			s.simsession_subtype,
+			s.simsession_name
+		from simsessions s
+		where s.fk_subsession_id = $1
+		order by s.simsession_number desc`, subsessionID); err != nil {
+		return nil, err
+	}
+	return simsessions, nil
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// GetTeamRulesBySeriesID returns the team rules of a series, or the defaults if none have been configured
+func (db *database) GetTeamRulesBySeriesID(ctx context.Context, seriesID int) (TeamRules, error) {
+	rules := TeamRules{}
+	if err := db.GetContext(ctx, &rules, `
+		select
+			r.fk_series_id,
+			r.top_drivers,
+			r.drop_weeks,
+			r.min_weeks
+		from team_rules r
+		where r.fk_series_id = $1`, seriesID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DefaultTeamRules(seriesID), nil
+		}
+		return rules, err
+	}
+	return rules, nil
+}
+
+func (db *database) UpsertTeamRules(ctx context.Context, rules TeamRules) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into team_rules
+			(fk_series_id, top_drivers, drop_weeks, min_weeks)
+		values ($1, $2, $3, $4)
+		on conflict (fk_series_id) do update
+		set top_drivers = excluded.top_drivers,
+			drop_weeks = excluded.drop_weeks,
+			min_weeks = excluded.min_weeks`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, rules.SeriesID, rules.TopDrivers, rules.DropWeeks, rules.MinWeeks); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTeamPointsBySeasonID returns the best champpoints of every team member per raceweek,
+// with the team resolved at the starttime of each race
+func (db *database) GetTeamPointsBySeasonID(ctx context.Context, seasonID int) ([]TeamPoints, error) {
+	points := make([]TeamPoints, 0)
+	if err := db.SelectContext(ctx, &points, `
+		select
+			rw.raceweek,
+			t.pk_team_id as team_id,
+			t.name as team_name,
+			d.pk_driver_id as driver_id,
+			d.name as driver_name,
+			max(r.champpoints) as champ_points
+		from race_results r
+			join raceweek_results rr on (rr.subsession_id = r.fk_subsession_id)
+			join raceweeks rw on (rw.pk_raceweek_id = rr.fk_raceweek_id)
+			join drivers d on (d.pk_driver_id = r.fk_driver_id)`+teamAt+`
+		where rw.fk_season_id = $1
+		and r.simsession_number = 0
+		and rr.official = true
+		group by rw.raceweek, t.pk_team_id, t.name, d.pk_driver_id, d.name
+		order by rw.raceweek asc, t.pk_team_id asc, champ_points desc, d.name asc`, seasonID); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// TeamStandings adds up the team points of a season according to the rules.
+// Every team gets an entry for every raceweek anyone scored in, raceweeks a team did not score in count as 0 points.
+func TeamStandings(points []TeamPoints, rules TeamRules) []TeamStanding {
+	weeks := make([]int, 0)
+	seenWeeks := make(map[int]bool)
+	teams := make(map[int]map[int][]TeamPoints) // team -> raceweek -> drivers
+	names := make(map[int]string)
+	for _, p := range points {
+		if !seenWeeks[p.RaceWeek] {
+			seenWeeks[p.RaceWeek] = true
+			weeks = append(weeks, p.RaceWeek)
+		}
+		if teams[p.TeamID] == nil {
+			teams[p.TeamID] = make(map[int][]TeamPoints)
+		}
+		teams[p.TeamID][p.RaceWeek] = append(teams[p.TeamID][p.RaceWeek], p)
+		names[p.TeamID] = p.TeamName
+	}
+	sort.Ints(weeks)
+
+	standings := make([]TeamStanding, 0, len(teams))
+	for teamID, raceweeks := range teams {
+		standing := TeamStanding{
+			Team:      Team{TeamID: teamID, Name: names[teamID]},
+			RaceWeeks: make([]TeamRaceWeek, 0, len(weeks)),
+		}
+		for _, week := range weeks {
+			drivers := append([]TeamPoints{}, raceweeks[week]...)
+			sort.SliceStable(drivers, func(i, j int) bool {
+				return drivers[i].ChampPoints > drivers[j].ChampPoints
+			})
+			raceweek := TeamRaceWeek{RaceWeek: week, Drivers: drivers}
+			for i := range drivers {
+				if i < rules.TopDrivers {
+					drivers[i].Counted = true
+					raceweek.Points += drivers[i].ChampPoints
+				}
+			}
+			if len(drivers) > 0 {
+				standing.Weeks++
+			}
+			standing.RaceWeeks = append(standing.RaceWeeks, raceweek)
+		}
+
+		// drop the worst raceweeks, the earlier one first on a tie
+		worst := make([]int, len(standing.RaceWeeks))
+		for i := range worst {
+			worst[i] = i
+		}
+		sort.SliceStable(worst, func(i, j int) bool {
+			return standing.RaceWeeks[worst[i]].Points < standing.RaceWeeks[worst[j]].Points
+		})
+		for i, w := range worst {
+			if i < rules.DropWeeks {
+				standing.RaceWeeks[w].Dropped = true
+				continue
+			}
+			standing.Points += standing.RaceWeeks[w].Points
+		}
+		standing.Classified = standing.Weeks >= rules.MinWeeks
+		standings = append(standings, standing)
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		switch {
+		case a.Classified != b.Classified:
+			return a.Classified
+		case a.Points != b.Points:
+			return a.Points > b.Points
+		case a.Weeks != b.Weeks:
+			return a.Weeks > b.Weeks
+		}
+		return a.Team.Name < b.Team.Name
+	})
+	for i := range standings {
+		if standings[i].Classified {
+			standings[i].Position = i + 1
+		}
+	}
+	return standings
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const teamMembershipColumns = `
+			m.pk_membership_id,
+			m.fk_team_id,
+			t.name as team_name,
+			m.fk_driver_id,
+			d.name as driver_name,
+			m.valid_from,
+			m.valid_to
+		from team_memberships m
+			join teams t on (t.pk_team_id = m.fk_team_id)
+			join drivers d on (d.pk_driver_id = m.fk_driver_id)`
+
+// teamAt resolves the team a driver was part of at the starttime of a race result
+var teamAt = joinTeamAt("join", "r.fk_driver_id", "r.session_starttime")
+
+// joinTeamAt joins the team t a driver was part of at a time given in epoch milliseconds, with a left join drivers without a team are kept
+func joinTeamAt(join, driverID, millis string) string {
+	return `
+			` + join + ` team_memberships tm on (tm.fk_driver_id = ` + driverID + `
+				and date_part('epoch', tm.valid_from) * 1000 <= ` + millis + `
+				and (tm.valid_to is null or date_part('epoch', tm.valid_to) * 1000 > ` + millis + `))
+			` + join + ` teams t on (t.pk_team_id = tm.fk_team_id)`
+}
+
+// teamOf resolves the name of the team a driver was part of at a time given in epoch milliseconds, empty if none
+func teamOf(driverID, millis string) string {
+	return `coalesce((
+				select team_t.name
+				from team_memberships team_m
+					join teams team_t on (team_t.pk_team_id = team_m.fk_team_id)
+				where team_m.fk_driver_id = ` + driverID + `
+				and date_part('epoch', team_m.valid_from) * 1000 <= ` + millis + `
+				and (team_m.valid_to is null or date_part('epoch', team_m.valid_to) * 1000 > ` + millis + `)), '')`
+}
+
+// currentTeam resolves the team a driver is part of right now
+var currentTeam = teamOf("d.pk_driver_id", "date_part('epoch', current_timestamp) * 1000")
+
+// teamOfWeek resolves the team of driver d at their last feature race in raceweek $2 of season $1,
+// so results summed up per driver show a single team even if the driver changed teams during the week
+var teamOfWeek = teamOf("d.pk_driver_id", `(
+				select max(last_r.session_starttime)
+				from race_results last_r
+					join raceweek_results last_rr on (last_rr.subsession_id = last_r.fk_subsession_id)
+					join raceweeks last_rw on (last_rw.pk_raceweek_id = last_rr.fk_raceweek_id)
+				where last_r.fk_driver_id = d.pk_driver_id
+				and last_r.simsession_number = 0
+				and last_rw.fk_season_id = $1
+				and last_rw.raceweek = $2)`)
+
+func (db *database) GetTeams(ctx context.Context) ([]Team, error) {
+	teams := make([]Team, 0)
+	if err := db.SelectContext(ctx, &teams, `
+		select
+			t.pk_team_id,
+			t.name
+		from teams t
+		order by t.name asc`); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (db *database) GetTeamByID(ctx context.Context, id int) (Team, error) {
+	team := Team{}
+	if err := db.GetContext(ctx, &team, `
+		select
+			t.pk_team_id,
+			t.name
+		from teams t
+		where t.pk_team_id = $1`, id); err != nil {
+		return team, err
+	}
+	return team, nil
+}
+
+func (db *database) InsertTeam(ctx context.Context, team Team) (Team, error) {
+	if err := db.GetContext(ctx, &team.TeamID, `
+		insert into teams (name)
+		values ($1)
+		returning pk_team_id`, team.Name); err != nil {
+		return Team{}, err
+	}
+	return team, nil
+}
+
+func (db *database) UpdateTeam(ctx context.Context, team Team) error {
+	_, err := db.ExecContext(ctx, `
+		update teams
+		set name = $2
+		where pk_team_id = $1`, team.TeamID, team.Name)
+	return err
+}
+
+// DeleteTeam deletes a team together with all of its memberships
+func (db *database) DeleteTeam(ctx context.Context, id int) error {
+	_, err := db.ExecContext(ctx, `
+		delete from teams
+		where pk_team_id = $1`, id)
+	return err
+}
+
+func (db *database) GetTeamMembershipByID(ctx context.Context, id int) (TeamMembership, error) {
+	membership := TeamMembership{}
+	if err := db.GetContext(ctx, &membership, `
+		select`+teamMembershipColumns+`
+		where m.pk_membership_id = $1`, id); err != nil {
+		return membership, err
+	}
+	return membership, nil
+}
+
+func (db *database) GetTeamMembershipsByTeamID(ctx context.Context, teamID int) ([]TeamMembership, error) {
+	memberships := make([]TeamMembership, 0)
+	if err := db.SelectContext(ctx, &memberships, `
+		select`+teamMembershipColumns+`
+		where m.fk_team_id = $1
+		order by m.valid_from desc, d.name asc`, teamID); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (db *database) GetTeamMembershipsByDriverID(ctx context.Context, driverID int) ([]TeamMembership, error) {
+	memberships := make([]TeamMembership, 0)
+	if err := db.SelectContext(ctx, &memberships, `
+		select`+teamMembershipColumns+`
+		where m.fk_driver_id = $1
+		order by m.valid_from desc`, driverID); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// InsertTeamMembership adds a driver to a team. If the new membership is open-ended, a still open membership of the driver
+// that started earlier is ended when the new one starts, so switching teams mid-season only needs the new membership.
+// A membership with an end overlapping an open one is rejected, the driver would be left without a team once it ends.
+func (db *database) InsertTeamMembership(ctx context.Context, membership TeamMembership) (TeamMembership, error) {
+	membership = membership.normalized()
+	if err := membership.validate(); err != nil {
+		return TeamMembership{}, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return TeamMembership{}, err
+	}
+
+	existing, err := tx.teamMembershipsOfDriver(ctx, membership.DriverID)
+	if err != nil {
+		tx.Rollback()
+		return TeamMembership{}, err
+	}
+	for i, m := range existing {
+		if membership.ValidTo == nil && m.ValidTo == nil && m.ValidFrom.Before(membership.ValidFrom) {
+			if _, err := tx.ExecContext(ctx, `
+				update team_memberships
+				set valid_to = $2
+				where pk_membership_id = $1`, m.MembershipID, membership.ValidFrom); err != nil {
+				tx.Rollback()
+				return TeamMembership{}, err
+			}
+			existing[i].ValidTo = &membership.ValidFrom
+		}
+	}
+	if err := membership.checkOverlaps(existing); err != nil {
+		tx.Rollback()
+		return TeamMembership{}, err
+	}
+
+	if err := tx.GetContext(ctx, &membership.MembershipID, `
+		insert into team_memberships
+			(fk_team_id, fk_driver_id, valid_from, valid_to)
+		values ($1, $2, $3, $4)
+		returning pk_membership_id`,
+		membership.TeamID, membership.DriverID, membership.ValidFrom, membership.ValidTo); err != nil {
+		tx.Rollback()
+		return TeamMembership{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TeamMembership{}, err
+	}
+	return db.GetTeamMembershipByID(ctx, membership.MembershipID)
+}
+
+// UpdateTeamMembership changes the validity of a membership
+func (db *database) UpdateTeamMembership(ctx context.Context, membership TeamMembership) error {
+	membership = membership.normalized()
+	if err := membership.validate(); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	current := TeamMembership{}
+	if err := tx.GetContext(ctx, &current, `
+		select`+teamMembershipColumns+`
+		where m.pk_membership_id = $1`, membership.MembershipID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	existing, err := tx.teamMembershipsOfDriver(ctx, current.DriverID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	membership.DriverID = current.DriverID
+	if err := membership.checkOverlaps(existing); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		update team_memberships
+		set valid_from = $2,
+			valid_to = $3
+		where pk_membership_id = $1`, membership.MembershipID, membership.ValidFrom, membership.ValidTo); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *database) DeleteTeamMembership(ctx context.Context, id int) error {
+	_, err := db.ExecContext(ctx, `
+		delete from team_memberships
+		where pk_membership_id = $1`, id)
+	return err
+}
+
+func (t *tx) teamMembershipsOfDriver(ctx context.Context, driverID int) ([]TeamMembership, error) {
+	memberships := make([]TeamMembership, 0)
+	if err := t.SelectContext(ctx, &memberships, `
+		select`+teamMembershipColumns+`
+		where m.fk_driver_id = $1
+		order by m.valid_from asc`, driverID); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (m TeamMembership) normalized() TeamMembership {
+	m.ValidFrom = m.ValidFrom.UTC().Truncate(time.Second)
+	if m.ValidTo != nil {
+		validTo := m.ValidTo.UTC().Truncate(time.Second)
+		m.ValidTo = &validTo
+	}
+	return m
+}
+
+func (m TeamMembership) validate() error {
+	if m.ValidFrom.IsZero() {
+		return fmt.Errorf("%w: team membership needs a valid_from", ErrInvalid)
+	}
+	if m.ValidTo != nil && !m.ValidTo.After(m.ValidFrom) {
+		return fmt.Errorf("%w: team membership valid_to %v must be after valid_from %v", ErrInvalid, *m.ValidTo, m.ValidFrom)
+	}
+	return nil
+}
+
+// checkOverlaps makes sure a driver is never part of two teams at the same time
+func (m TeamMembership) checkOverlaps(memberships []TeamMembership) error {
+	for _, other := range memberships {
+		if other.MembershipID != m.MembershipID && m.Overlaps(other) {
+			return fmt.Errorf("%w: driver %d is already part of team %s at that time, see membership %d",
+				ErrInvalid, m.DriverID, other.TeamName, other.MembershipID)
+		}
+	}
+	return nil
+}
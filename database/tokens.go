@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+func (db *database) GetToken(ctx context.Context, name string) (Token, error) {
+	token := Token{}
+	if err := db.GetContext(ctx, &token, `
+		select
+			t.pk_name,
+			t.access_token,
+			t.refresh_token,
+			t.token_type,
+			t.scope,
+			t.access_token_expires_at,
+			t.refresh_token_expires_at,
+			t.last_login,
+			t.last_update
+		from tokens t
+		where t.pk_name = $1`, name); err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func (db *database) UpsertToken(ctx context.Context, token Token) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		insert into tokens
+			(pk_name, access_token, refresh_token, token_type, scope,
+			access_token_expires_at, refresh_token_expires_at, last_login, last_update)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		on conflict (pk_name) do update
+		set access_token = excluded.access_token,
+			refresh_token = excluded.refresh_token,
+			token_type = excluded.token_type,
+			scope = excluded.scope,
+			access_token_expires_at = excluded.access_token_expires_at,
+			refresh_token_expires_at = excluded.refresh_token_expires_at,
+			last_login = excluded.last_login,
+			last_update = excluded.last_update`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		token.Name, token.AccessToken, token.RefreshToken, token.TokenType, token.Scope,
+		token.AccessTokenExpiresAt.UTC(), token.RefreshTokenExpiresAt.UTC(), token.LastLogin.UTC(), time.Now().UTC()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
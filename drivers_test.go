@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type driverDatabase struct {
+	database.Database
+}
+
+func (db *driverDatabase) GetDriverProfileByDriverID(_ context.Context, id int) (database.DriverProfile, error) {
+	since := time.Date(2015, 3, 2, 0, 0, 0, 0, time.UTC)
+	return database.DriverProfile{
+		Driver:      database.Driver{DriverID: id, Name: "Jack", Club: database.Club{ClubID: 1, Name: "Finland"}},
+		MemberSince: &since,
+		RefreshedAt: &since,
+		Names: []database.DriverName{
+			{DriverID: id, Name: "Jack", FirstSeen: since, LastSeen: since.AddDate(1, 0, 0)},
+			{DriverID: id, Name: "Jack E.", FirstSeen: since, LastSeen: since},
+		},
+		Licenses: []database.DriverLicense{
+			{DriverID: id, CategoryID: 2, Category: "road", LicenseLevel: 17, Group: "Class A", SafetyRating: 3.87, IRating: 1745},
+		},
+		CareerStats: []database.DriverCareerStats{
+			{DriverID: id, CategoryID: 2, Category: "Road", Starts: 231, Wins: 27, AvgIncidents: 3.88},
+		},
+	}, nil
+}
+
+func (db *driverDatabase) SearchDriversByName(_ context.Context, name string) ([]database.DriverNameMatch, error) {
+	return []database.DriverNameMatch{
+		{DriverID: 1, Name: name + " E.", CurrentName: "Jack", FirstSeen: time.Now(), LastSeen: time.Now()},
+	}, nil
+}
+
+func (db *driverDatabase) GetRaceResultsByDriverID(_ context.Context, id int, filter database.DriverFilter) ([]database.DriverRaceResult, error) {
+	if filter.Offset > 0 {
+		return []database.DriverRaceResult{}, nil
+	}
+	return []database.DriverRaceResult{{
+		SeriesID: 1, SeasonID: 2307, RaceWeek: 3, TrackID: 413, StartTime: time.Now(), SizeOfField: 20, StrengthOfField: 1650,
+		Result: database.RaceResult{SubsessionID: 123, Driver: database.Driver{DriverID: id, Name: "Jack"}, FinishingPosition: 2},
+	}}, nil
+}
+
+func (db *driverDatabase) GetDriverSeasonSummariesByDriverID(_ context.Context, _ int, filter database.DriverFilter) ([]database.DriverSeasonSummary, error) {
+	return []database.DriverSeasonSummary{
+		{SeriesID: 1, SeasonID: *filter.SeasonID, Year: 2019, Quarter: 2, Races: 12, Wins: 2, AvgFinishPosition: 4.5, BestLaptime: database.Laptime(1210000)},
+	}, nil
+}
+
+func (db *driverDatabase) GetRatingHistoryByDriverID(context.Context, int, database.DriverFilter) ([]database.RatingPoint, error) {
+	return []database.RatingPoint{
+		{SubsessionID: 122, SeasonID: 2307, StartTime: time.Now().Add(-time.Hour), IRatingBefore: 1500, IRatingAfter: 1540},
+		{SubsessionID: 123, SeasonID: 2307, StartTime: time.Now(), IRatingBefore: 1540, IRatingAfter: 1522},
+	}, nil
+}
+
+func (db *driverDatabase) GetDriverByID(_ context.Context, id int) (database.Driver, error) {
+	return database.Driver{DriverID: id, Name: "Jack Example"}, nil
+}
+
+func (db *driverDatabase) GetRatingTimeSeriesByDriverID(_ context.Context, id int, _ database.DriverFilter) ([]database.RatingPoint, error) {
+	start := time.Date(2021, time.June, 7, 10, 0, 0, 0, time.UTC)
+	return []database.RatingPoint{
+		{SubsessionID: 122, SeasonID: 2307, StartTime: start, Category: "road", IRatingBefore: 1500, IRatingAfter: 1540 + id, SafetyRatingAfter: 350},
+		{SubsessionID: 123, SeasonID: 2307, StartTime: start.Add(time.Hour), Category: "road", IRatingBefore: 1540 + id, IRatingAfter: 1522 + id, SafetyRatingAfter: 360},
+		{SubsessionID: 124, SeasonID: 2310, StartTime: start.Add(2 * time.Hour), Category: "oval", IRatingBefore: 1350, IRatingAfter: 1380, SafetyRatingAfter: 250},
+	}, nil
+}
+
+func Test_DriverEndpoints(t *testing.T) {
+	server, c := testServer(&driverDatabase{})
+	defer server.Close()
+
+	driver, err := c.GetDriver(1)
+	if assert.NoError(t, err) && assert.Len(t, driver.Profile.Licenses, 1) {
+		assert.Equal(t, 1745, driver.Profile.Licenses[0].IRating)
+		assert.Equal(t, 2015, driver.Profile.MemberSince.Year())
+		assert.Len(t, driver.Profile.Names, 2)
+	}
+	drivers, err := c.SearchDrivers("Jack")
+	if assert.NoError(t, err) && assert.Len(t, drivers.Drivers, 1) {
+		assert.Equal(t, 1, drivers.Drivers[0].DriverID)
+		assert.Equal(t, "Jack E.", drivers.Drivers[0].Name)
+		assert.Equal(t, "Jack", drivers.Drivers[0].CurrentName)
+	}
+	season := 2307
+	results, err := c.GetDriverResults(1, database.DriverFilter{SeasonID: &season, Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, results.Results, 1) {
+		assert.Equal(t, 3, results.Results[0].RaceWeek)
+		assert.Equal(t, 1, results.Results[0].Result.Driver.DriverID)
+	}
+	results, err = c.GetDriverResults(1, database.DriverFilter{Offset: 10})
+	if assert.NoError(t, err) {
+		assert.Empty(t, results.Results)
+	}
+	summary, err := c.GetDriverSummary(1, database.DriverFilter{SeasonID: &season})
+	if assert.NoError(t, err) && assert.Len(t, summary.Seasons, 1) {
+		assert.Equal(t, 2307, summary.Seasons[0].SeasonID)
+		assert.Equal(t, 4.5, summary.Seasons[0].AvgFinishPosition)
+	}
+	iratings, err := c.GetDriverIRating(1, database.DriverFilter{})
+	if assert.NoError(t, err) && assert.Len(t, iratings.IRatings, 2) {
+		assert.Equal(t, 1522, iratings.IRatings[1].IRatingAfter)
+	}
+	ratings, err := c.GetRatings([]int{1, 2}, database.RatingIntervalDay, database.DriverFilter{})
+	if assert.NoError(t, err) && assert.Len(t, ratings.Drivers, 2) {
+		assert.Equal(t, 2, ratings.Drivers[1].Driver.DriverID)
+		if assert.Len(t, ratings.Drivers[1].Categories, 2) && assert.Len(t, ratings.Drivers[1].Categories[0].Samples, 1) {
+			assert.Equal(t, "road", ratings.Drivers[1].Categories[0].Category)
+			assert.Equal(t, 1524, ratings.Drivers[1].Categories[0].Samples[0].IRating)
+			assert.Equal(t, 2, ratings.Drivers[1].Categories[0].Samples[0].Races)
+			assert.True(t, ratings.Drivers[1].Categories[0].Samples[0].SeasonStart)
+			assert.Equal(t, 1380, ratings.Drivers[1].Categories[1].Samples[0].IRating)
+		}
+	}
+}
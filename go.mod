@@ -8,7 +8,9 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/lib/pq v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/prometheus/client_golang v1.11.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sebest/logrusly v0.0.0-20180315190218-3235eccb8edc
 	github.com/sirupsen/logrus v1.7.0
 	github.com/stretchr/testify v1.5.1
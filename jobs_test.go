@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/client"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type jobDatabase struct {
+	database.Database
+}
+
+func (db *jobDatabase) InsertJob(_ context.Context, job database.Job) (database.Job, error) {
+	job.JobID = 7
+	return job, nil
+}
+
+func (db *jobDatabase) GetJobs(ctx context.Context) ([]database.Job, error) {
+	job, err := db.GetJobByID(ctx, 7)
+	return []database.Job{job}, err
+}
+
+func (db *jobDatabase) GetJobByID(_ context.Context, id int) (database.Job, error) {
+	seasonID, week := 2307, 3
+	return database.Job{
+		JobID: id, Kind: "week", SeasonID: &seasonID, Week: &week, Status: "running", Attempts: 1,
+		Unit: "subsessions", Done: 3, Total: 10, Errors: database.JobErrors{"could not get race result"},
+		CreatedAt: time.Now(), ScheduledAt: time.Now(),
+	}, nil
+}
+
+func (db *jobDatabase) UpdateJob(context.Context, database.Job) error {
+	return nil
+}
+
+func Test_CollectWeekEndpoint(t *testing.T) {
+	rec := serve(&jobDatabase{}, "POST", "/season/2307/week/3")
+
+	assert.Equal(t, 200, rec.Code)
+	var task client.TaskResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &task)) && assert.NotNil(t, task.JobID) {
+		assert.Equal(t, 7, *task.JobID)
+		assert.Equal(t, 3, *task.Week)
+	}
+}
+
+func Test_BackfillEndpoint(t *testing.T) {
+	rec := serve(&jobDatabase{}, "POST", "/series/4/backfill?from_year=2020&from_quarter=1&to_year=2021&to_quarter=4")
+
+	assert.Equal(t, 200, rec.Code)
+	var task client.TaskResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &task)) && assert.NotNil(t, task.JobID) {
+		assert.Equal(t, 4, *task.SeriesID)
+	}
+
+	for _, query := range []string{
+		"from_year=2020",
+		"from_year=2020&from_quarter=5",
+		"from_year=2020&from_quarter=0",
+		"from_year=2020&from_quarter=1&to_year=2021&to_quarter=7",
+		"from_year=2021&from_quarter=1&to_year=2020&to_quarter=4",
+	} {
+		rec = serve(&jobDatabase{}, "POST", "/series/4/backfill?"+query)
+		assert.Equal(t, 400, rec.Code, query)
+	}
+}
+
+func Test_JobEndpoints(t *testing.T) {
+	server, c := testServer(&jobDatabase{})
+	defer server.Close()
+
+	task, err := c.CollectSeason(2307)
+	if assert.NoError(t, err) && assert.NotNil(t, task.JobID) {
+		assert.Equal(t, 7, *task.JobID)
+	}
+	job, err := c.GetJob(7)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, job.Job.Done)
+		assert.Equal(t, []string{"could not get race result"}, []string(job.Job.Errors))
+	}
+	job, err = c.CancelJob(7)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "cancelled", job.Job.Status)
+		assert.NotNil(t, job.Job.FinishedAt)
+	}
+}
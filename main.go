@@ -1,15 +1,23 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"fmt"
-	"html/template"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/JamesClonk/iRcollector/api"
+	"github.com/JamesClonk/iRcollector/client"
 	"github.com/JamesClonk/iRcollector/collector"
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRcollector/env"
@@ -23,6 +31,11 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		backfillCommand(os.Args[2:])
+		return
+	}
+
 	port := env.Get("PORT", "8080")
 	level := env.Get("LOG_LEVEL", "info")
 	username = env.MustGet("AUTH_USERNAME")
@@ -32,10 +45,96 @@ func main() {
 	log.Infoln("log level:", level)
 	log.Infoln("auth username:", username)
 
+	// check iRacing credentials before doing anything else
+	credentials := credentialsProvider()
+	if _, err := credentials.Credentials(); err != nil {
+		log.Errorln("Could not read iRacing credentials")
+		log.Fatalf("%v", err)
+	}
+
+	// stop everything on SIGTERM / SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	// wait before connecting to DB
-	time.Sleep(time.Second * 44)
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Second * 44):
+	}
 
 	// setup database
+	db := setupDatabase()
+
+	// run collector
+	opts := collectorOptions(credentials, db)
+	archive := payloadArchive(db)
+	replay := archive != nil && env.Get("IR_REPLAY", "false") == "true"
+	if replay {
+		log.Infoln("replaying archived payloads, iRacing will not be contacted")
+		opts = append(opts, api.WithReplay(archive))
+	} else if archive != nil {
+		opts = append(opts, api.WithArchive(archive))
+	}
+	c := collector.New(db, opts...)
+	if criteria := discovery(); criteria != nil && !replay {
+		c.EnableDiscovery(*criteria)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if replay {
+			c.Replay(ctx, archive)
+		} else {
+			c.Run(ctx)
+		}
+	}()
+	if refresh := driverRefresh(); refresh > 0 && !replay {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ScheduleDriverEnrichment(ctx, refresh)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		c.RunJobs(ctx)
+	}()
+	collectorDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(collectorDone)
+	}()
+
+	// start listener
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router(c),
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalln(err)
+		}
+	}()
+
+	// graceful shutdown, let the HTTP server drain and the collector finish its current step
+	<-ctx.Done()
+	log.Infoln("shutting down ...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("could not shut down HTTP server: %v", err)
+	}
+	select {
+	case <-collectorDone:
+	case <-shutdownCtx.Done():
+		log.Errorln("collector did not stop in time")
+	}
+}
+
+// setupDatabase connects to the database and runs all migrations
+func setupDatabase() database.Database {
 	adapter := database.NewAdapter()
 	if err := adapter.RunMigrations("database/migrations"); err != nil {
 		if !strings.Contains(err.Error(), "no change") {
@@ -43,36 +142,183 @@ func main() {
 			log.Fatalf("%v", err)
 		}
 	}
-	db := database.NewDatabase(adapter)
+	return database.NewDatabase(adapter)
+}
 
-	// run collector
-	c := collector.New(db)
-	go c.Run()
+// collectorOptions configures the iRacing API client of the collector
+func collectorOptions(credentials api.CredentialsProvider, db database.Database) []api.Option {
+	opts := append(apiOptions(), api.WithCredentials(credentials))
+	if store := tokenStore(db); store != nil {
+		opts = append(opts, api.WithTokenStore(store))
+	}
+	return opts
+}
 
-	// start listener
-	log.Fatalln(http.ListenAndServe(":"+port, router(c)))
+// credentialsProvider reads iRacing credentials from a mounted secret volume or file if IR_CREDENTIALS_PATH is set, from ENV otherwise
+func credentialsProvider() api.CredentialsProvider {
+	if path := env.Get("IR_CREDENTIALS_PATH", ""); len(path) > 0 {
+		log.Infoln("iRacing credentials path:", path)
+		return api.FileCredentials{Path: path}
+	}
+	return api.EnvCredentials{}
+}
+
+// tokenStore persists oauth tokens across restarts, either in a file if IR_TOKEN_FILE is set, or in the database if IR_TOKEN_STORE=database
+func tokenStore(db database.Database) api.TokenStore {
+	if path := env.Get("IR_TOKEN_FILE", ""); len(path) > 0 {
+		log.Infoln("oauth token file:", path)
+		return api.FileTokenStore{Path: path}
+	}
+	if strings.ToLower(env.Get("IR_TOKEN_STORE", "")) == "database" {
+		log.Infoln("oauth token store: database")
+		return collector.NewDatabaseTokenStore(db)
+	}
+	return nil
+}
+
+// payloadArchive keeps every raw API response, either in a directory if IR_ARCHIVE_DIR is set, or in the database if IR_ARCHIVE=database
+func payloadArchive(db database.Database) api.Archive {
+	if path := env.Get("IR_ARCHIVE_DIR", ""); len(path) > 0 {
+		log.Infoln("payload archive directory:", path)
+		return api.DirArchive{Path: path}
+	}
+	if strings.ToLower(env.Get("IR_ARCHIVE", "")) == "database" {
+		log.Infoln("payload archive: database")
+		return collector.NewDatabaseArchive(db)
+	}
+	return nil
+}
+
+// driverRefresh is how old driver profiles can get before they are refreshed from iRacing, IR_DRIVER_REFRESH=0 disables the enrichment
+func driverRefresh() time.Duration {
+	refresh, err := time.ParseDuration(env.Get("IR_DRIVER_REFRESH", "168h"))
+	if err != nil {
+		log.Errorln("Could not parse IR_DRIVER_REFRESH")
+		log.Fatalf("%v", err)
+	}
+	log.Infoln("driver profile refresh:", refresh)
+	return refresh
+}
+
+// discovery reads the criteria for discovering new series, IR_DISCOVERY=true enables it.
+// IR_DISCOVERY_LICENSE_GROUPS and IR_DISCOVERY_CAR_CLASSES are comma separated IDs, IR_DISCOVERY_CATEGORIES and IR_DISCOVERY_TRACK_TYPES comma separated names,
+// IR_DISCOVERY_OFFICIAL and IR_DISCOVERY_FIXED_SETUP booleans. Criteria left empty match anything.
+func discovery() *collector.DiscoveryCriteria {
+	if env.Get("IR_DISCOVERY", "false") != "true" {
+		return nil
+	}
+
+	criteria := &collector.DiscoveryCriteria{}
+	for key, value := range map[string]*[]int{
+		"IR_DISCOVERY_LICENSE_GROUPS": &criteria.LicenseGroups,
+		"IR_DISCOVERY_CAR_CLASSES":    &criteria.CarClasses,
+	} {
+		for _, id := range splitList(env.Get(key, "")) {
+			v, err := strconv.Atoi(id)
+			if err != nil {
+				log.Errorf("Could not parse %s", key)
+				log.Fatalf("%v", err)
+			}
+			*value = append(*value, v)
+		}
+	}
+	criteria.Categories = splitList(env.Get("IR_DISCOVERY_CATEGORIES", ""))
+	criteria.TrackTypes = splitList(env.Get("IR_DISCOVERY_TRACK_TYPES", ""))
+	for key, value := range map[string]**bool{
+		"IR_DISCOVERY_OFFICIAL":    &criteria.Official,
+		"IR_DISCOVERY_FIXED_SETUP": &criteria.FixedSetup,
+	} {
+		if len(env.Get(key, "")) == 0 {
+			continue
+		}
+		v, err := strconv.ParseBool(env.Get(key, ""))
+		if err != nil {
+			log.Errorf("Could not parse %s", key)
+			log.Fatalf("%v", err)
+		}
+		*value = &v
+	}
+	log.Infoln("series discovery enabled")
+	return criteria
+}
+
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// apiOptions allows pointing the collector at something other than iRacing, i.e. a local mock server
+func apiOptions() []api.Option {
+	return []api.Option{
+		api.WithBaseURL(env.Get("IR_API_URL", api.DefaultBaseURL)),
+		api.WithOAuthURL(env.Get("IR_OAUTH_URL", api.DefaultOAuthURL)),
+		api.WithImagesURL(env.Get("IR_IMAGES_URL", api.DefaultImagesURL)),
+	}
 }
 
+// router sets up all HTTP routes, collection tasks triggered through it are queued as jobs
 func router(c *collector.Collector) *mux.Router {
 	r := mux.NewRouter()
 	r.PathPrefix("/health").HandlerFunc(showHealth)
 	r.PathPrefix("/metrics").Handler(promhttp.Handler())
+	r.HandleFunc("/openapi.json", showOpenAPI).Methods("GET")
 
 	r.HandleFunc("/series", showSeries(c)).Methods("GET")
+	r.HandleFunc("/series", createSeries(c)).Methods("POST")
+	r.HandleFunc("/series/{seriesID}", updateSeries(c)).Methods("PUT")
+	r.HandleFunc("/series/{seriesID}", deactivateSeries(c)).Methods("DELETE")
+	r.HandleFunc("/series/{seriesID}/backfill", backfillSeries(c)).Methods("POST")
+	r.HandleFunc("/series/{seriesID}/team-rules", showTeamRules(c)).Methods("GET")
+	r.HandleFunc("/series/{seriesID}/team-rules", updateTeamRules(c)).Methods("PUT")
 	r.HandleFunc("/seasons", showSeasons(c)).Methods("GET")
 	r.HandleFunc("/seasons", collectSeasons(c)).Methods("POST", "PUT")
 	r.HandleFunc("/season/{seasonID}", collectSeason(c)).Methods("POST", "PUT")
 	r.HandleFunc("/season/{seasonID}/week/{week}", collectWeek(c)).Methods("POST", "PUT")
 	r.HandleFunc("/season/{seasonID}/week/{week}", showWeek(c)).Methods("GET")
+	r.HandleFunc("/season/{seasonID}/teams", showTeamStandings(c)).Methods("GET")
+	r.HandleFunc("/season/{seasonID}/schedule", showSchedule(c)).Methods("GET")
 	r.HandleFunc("/race/{subsessionID}", showRace(c)).Methods("GET")
+	r.HandleFunc("/race/{subsessionID}/laps", showRaceLaps(c)).Methods("GET")
+	r.HandleFunc("/drivers", searchDrivers(c)).Methods("GET")
+	r.HandleFunc("/driver/{driverID}", showDriver(c)).Methods("GET")
+	r.HandleFunc("/driver/{driverID}/results", showDriverResults(c)).Methods("GET")
+	r.HandleFunc("/driver/{driverID}/summary", showDriverSummary(c)).Methods("GET")
+	r.HandleFunc("/driver/{driverID}/irating", showDriverIRating(c)).Methods("GET")
+	r.HandleFunc("/ratings", showRatings(c)).Methods("GET")
+	r.HandleFunc("/teams", showTeams(c)).Methods("GET")
+	r.HandleFunc("/teams", createTeam(c)).Methods("POST")
+	r.HandleFunc("/teams/{teamID}", showTeam(c)).Methods("GET")
+	r.HandleFunc("/teams/{teamID}", updateTeam(c)).Methods("PUT")
+	r.HandleFunc("/teams/{teamID}", deleteTeam(c)).Methods("DELETE")
+	r.HandleFunc("/teams/{teamID}/members", addTeamMember(c)).Methods("POST")
+	r.HandleFunc("/teams/{teamID}/members/{membershipID}", updateTeamMember(c)).Methods("PUT")
+	r.HandleFunc("/teams/{teamID}/members/{membershipID}", removeTeamMember(c)).Methods("DELETE")
+	r.HandleFunc("/jobs", showJobs(c)).Methods("GET")
+	r.HandleFunc("/jobs/{jobID}", showJob(c)).Methods("GET")
+	r.HandleFunc("/jobs/{jobID}", cancelJob(c)).Methods("DELETE")
 
 	return r
 }
 
+// errBadRequest is wrapped by errors about invalid request parameters
+var errBadRequest = errors.New("bad request")
+
+// failure answers with 400 for invalid input, 404 for unknown IDs and 500 for everything else
 func failure(rw http.ResponseWriter, req *http.Request, err error) {
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(500)
-	_, _ = rw.Write([]byte(fmt.Sprintf(`{ "error": "%v" }`, err.Error())))
+	status := http.StatusInternalServerError
+	var numErr *strconv.NumError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		status = http.StatusNotFound
+	case errors.As(err, &numErr), errors.Is(err, errBadRequest), errors.Is(err, database.ErrInvalid), errors.Is(err, collector.ErrInvalidSeries):
+		status = http.StatusBadRequest
+	}
+	writeJSON(rw, status, client.ErrorResponse{Error: err.Error()})
 }
 
 func showHealth(rw http.ResponseWriter, req *http.Request) {
@@ -83,89 +329,233 @@ func showHealth(rw http.ResponseWriter, req *http.Request) {
 
 func showSeries(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
-		series, err := c.Database().GetSeries()
+		series, err := c.Database().GetSeries(req.Context())
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.SeriesResponse{Series: series})
+	}
+}
+
+// seriesParams applies the name, short_name, regex, colorscheme, api_series_id and active query parameters that are present
+func seriesParams(req *http.Request, series *database.Series) error {
+	query := req.URL.Query()
+	for key, value := range map[string]*string{
+		"name":        &series.SeriesName,
+		"short_name":  &series.SeriesNameShort,
+		"regex":       &series.SeriesRegex,
+		"colorscheme": &series.ColorScheme,
+	} {
+		if _, ok := query[key]; ok {
+			*value = query.Get(key)
+		}
+	}
+	if len(query.Get("api_series_id")) > 0 {
+		id, err := strconv.Atoi(query.Get("api_series_id"))
+		if err != nil {
+			log.Errorf("could not convert api_series_id [%s] to int: %v", query.Get("api_series_id"), err)
+			return err
+		}
+		series.APISeriesID = id
+	}
+	if len(query.Get("active")) > 0 {
+		active, err := strconv.ParseBool(query.Get("active"))
+		if err != nil {
+			log.Errorf("could not convert active [%s] to bool: %v", query.Get("active"), err)
+			return err
+		}
+		series.Active = strconv.FormatBool(active)
+		series.Pending = false // deciding on a discovered series either way approves or rejects it
+	}
+	return nil
+}
+
+func createSeries(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		series := database.Series{Active: "true"}
+		if err := seriesParams(req, &series); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if len(series.SeriesNameShort) == 0 {
+			series.SeriesNameShort = series.SeriesName
+		}
+
+		series, err := c.SaveSeries(req.Context(), series)
 		if err != nil {
 			failure(rw, req, err)
 			return
 		}
+		writeJSON(rw, http.StatusOK, client.SeriesItemResponse{Series: series})
+	}
+}
+
+func updateSeries(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
 
-		seriesTmpl := `[
-{{ range . }}  { "pk_series_id": {{ .SeriesID }}, "name": "{{ .SeriesName }}", "short_name": "{{ .SeriesNameShort }}", "regex": "{{ .SeriesRegex }}" },
-{{ end }}]`
-		serie := template.Must(template.New("result").Parse(seriesTmpl))
-		var buf bytes.Buffer
-		if err := serie.Execute(&buf, series); err != nil {
-			log.Errorf("could not parse result template: %v", err)
+		seriesID, err := pathID(req, "seriesID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		series, err := c.Database().GetSeriesByID(req.Context(), seriesID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if err := seriesParams(req, &series); err != nil {
 			failure(rw, req, err)
 			return
 		}
 
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write(buf.Bytes())
+		series, err = c.SaveSeries(req.Context(), series)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.SeriesItemResponse{Series: series})
 	}
 }
 
-func collectSeasons(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+func deactivateSeries(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		if !verifyBasicAuth(rw, req) {
 			return
 		}
 
-		go c.CollectSeasons()
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write([]byte(`{ "task": "collecting all seasons ..." }`))
+		seriesID, err := pathID(req, "seriesID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if err := c.Database().DeactivateSeries(req.Context(), seriesID); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		series, err := c.Database().GetSeriesByID(req.Context(), seriesID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.SeriesItemResponse{Series: series})
 	}
 }
 
-func collectSeason(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+func backfillSeries(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		if !verifyBasicAuth(rw, req) {
 			return
 		}
 
 		vars := mux.Vars(req)
-		seasonID, err := strconv.Atoi(vars["seasonID"])
+		seriesID, err := strconv.Atoi(vars["seriesID"])
+		if err != nil {
+			log.Errorf("could not convert seriesID [%s] to int: %v", vars["seriesID"], err)
+			failure(rw, req, err)
+			return
+		}
+		query := req.URL.Query()
+		param := func(key string) (int, error) {
+			value, err := strconv.Atoi(query.Get(key))
+			if err != nil {
+				log.Errorf("could not convert %s [%s] to int: %v", key, query.Get(key), err)
+			}
+			return value, err
+		}
+		fromYear, err := param("from_year")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		fromQuarter, err := param("from_quarter")
 		if err != nil {
-			log.Errorf("could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
 			failure(rw, req, err)
 			return
 		}
+		toYear, toQuarter := fromYear, fromQuarter
+		if len(query.Get("to_year")) > 0 {
+			if toYear, err = param("to_year"); err != nil {
+				failure(rw, req, err)
+				return
+			}
+			if toQuarter, err = param("to_quarter"); err != nil {
+				failure(rw, req, err)
+				return
+			}
+		}
+
+		if err := checkSeasonRange(fromYear, fromQuarter, toYear, toQuarter); err != nil {
+			failure(rw, req, fmt.Errorf("%w: %v", errBadRequest, err))
+			return
+		}
 
-		go c.CollectSeason(seasonID)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write([]byte(`{ "season": "` + vars["seasonID"] + `" }`))
+		job, err := c.EnqueueBackfill(req.Context(), seriesID, fromYear, fromQuarter, toYear, toQuarter)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TaskResponse{Task: "backfilling series ...", SeriesID: &seriesID, JobID: &job.JobID})
 	}
 }
 
-func showSeasons(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+func collectSeasons(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		job, err := c.EnqueueJob(req.Context(), collector.JobSeasons, nil, nil)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TaskResponse{Task: "collecting all seasons ...", JobID: &job.JobID})
+	}
+}
+
+func collectSeason(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		if !verifyBasicAuth(rw, req) {
 			return
 		}
 
-		seasons, err := c.Database().GetSeasons()
+		vars := mux.Vars(req)
+		seasonID, err := strconv.Atoi(vars["seasonID"])
 		if err != nil {
+			log.Errorf("could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
 			failure(rw, req, err)
 			return
 		}
 
-		seasonTmpl := `[
-{{ range . }}  { "pk_season_id": {{ .SeasonID }}, "year": {{ .Year }}, "quarter": {{ .Quarter }}, "name": "{{ .SeasonName }}", "category": "{{ .Category}}" },
-{{ end }}]`
-		season := template.Must(template.New("result").Parse(seasonTmpl))
-		var buf bytes.Buffer
-		if err := season.Execute(&buf, seasons); err != nil {
-			log.Errorf("could not parse result template: %v", err)
+		job, err := c.EnqueueJob(req.Context(), collector.JobSeason, &seasonID, nil)
+		if err != nil {
 			failure(rw, req, err)
 			return
 		}
+		writeJSON(rw, http.StatusOK, client.TaskResponse{Task: "collecting season ...", SeasonID: &seasonID, JobID: &job.JobID})
+	}
+}
+
+func showSeasons(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
 
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write(buf.Bytes())
+		seasons, err := c.Database().GetSeasons(req.Context())
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.SeasonsResponse{Seasons: seasons})
 	}
 }
 
@@ -189,10 +579,12 @@ func collectWeek(c *collector.Collector) func(rw http.ResponseWriter, req *http.
 			return
 		}
 
-		go c.CollectRaceWeek(seasonID, week, true)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write([]byte(`{ "season": "` + vars["seasonID"] + `", "week": "` + vars["week"] + `" }`))
+		job, err := c.EnqueueJob(req.Context(), collector.JobWeek, &seasonID, &week)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TaskResponse{Task: "collecting raceweek ...", SeasonID: &seasonID, Week: &week, JobID: &job.JobID})
 	}
 }
 
@@ -216,66 +608,71 @@ func showWeek(c *collector.Collector) func(rw http.ResponseWriter, req *http.Req
 			return
 		}
 
-		results, err := c.Database().GetRaceWeekResultsBySeasonIDAndWeek(seasonID, week)
+		results, err := c.Database().GetRaceWeekResultsBySeasonIDAndWeek(req.Context(), seasonID, week)
 		if err != nil {
 			failure(rw, req, err)
 			return
 		}
-
-		resultTmpl := `[
-{{ range . }}  { "fk_raceweek_id": {{ .RaceWeekID }}, "startime": "{{ .StartTime }}", "subsession_id": {{ .SubsessionID }}, "official": {{ .Official }}, "size": {{ .SizeOfField}}, "sof": {{ .StrengthOfField}} },
-{{ end }}]`
-		result := template.Must(template.New("result").Parse(resultTmpl))
-		var resultsBuf bytes.Buffer
-		if err := result.Execute(&resultsBuf, results); err != nil {
-			log.Errorf("could not parse result template: %v", err)
+		rankings, err := c.Database().GetTimeRankingsBySeasonIDAndWeek(req.Context(), seasonID, week)
+		if err != nil {
 			failure(rw, req, err)
 			return
 		}
-
-		rankings, err := c.Database().GetTimeRankingsBySeasonIDAndWeek(seasonID, week)
+		summaries, err := c.Database().GetDriverSummariesBySeasonIDAndWeek(req.Context(), seasonID, week)
 		if err != nil {
 			failure(rw, req, err)
 			return
 		}
 
-		rankingTmpl := `,[
-{{ range . }}  { "driver": "{{ .Driver.Name }}", "car": "{{ .Car.Name }}", "race": "{{ .Race }}", "time_trial": "{{ .TimeTrial }}", "irating": {{ .IRating }}, "license_class": "{{ .LicenseClass}}" },
-{{ end }}]`
-		ranking := template.Must(template.New("ranking").Parse(rankingTmpl))
-		var rankingsBuf bytes.Buffer
-		if err := ranking.Execute(&rankingsBuf, rankings); err != nil {
-			log.Errorf("could not parse ranking template: %v", err)
-			failure(rw, req, err)
+		writeJSON(rw, http.StatusOK, client.WeekResponse{
+			SeasonID:  seasonID,
+			Week:      week,
+			Results:   results,
+			Rankings:  rankings,
+			Summaries: summaries,
+		})
+	}
+}
+
+func showRace(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
 			return
 		}
 
-		summaries, err := c.Database().GetDriverSummariesBySeasonIDAndWeek(seasonID, week)
+		vars := mux.Vars(req)
+		subsessionID, err := strconv.Atoi(vars["subsessionID"])
 		if err != nil {
+			log.Errorf("could not convert subsessionID [%s] to int: %v", vars["subsessionID"], err)
 			failure(rw, req, err)
 			return
 		}
 
-		summaryTmpl := `,[
-{{ range . }}  { "driver": "{{ .Driver.Name }}", "ir_gained": {{ .TotalIRatingGain }}, "sr_gained": {{ .TotalSafetyRatingGain }}, "poles": {{ .Poles }}, "top5": {{ .Top5 }}, "champ_points": {{ .HighestChampPoints }}, "club_points": {{ .TotalClubPoints }}, "nof_races": {{ .NumberOfRaces }} },
-{{ end }}]`
-		summary := template.Must(template.New("summary").Parse(summaryTmpl))
-		var summariesBuf bytes.Buffer
-		if err := summary.Execute(&summariesBuf, summaries); err != nil {
-			log.Errorf("could not parse summary template: %v", err)
+		stats, err := c.Database().GetRaceStatsBySubsessionID(req.Context(), subsessionID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		simsessions, err := c.Database().GetSimsessionsBySubsessionID(req.Context(), subsessionID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		results, err := c.Database().GetRaceResultsBySubsessionID(req.Context(), subsessionID)
+		if err != nil {
 			failure(rw, req, err)
 			return
 		}
 
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write(resultsBuf.Bytes())
-		_, _ = rw.Write(rankingsBuf.Bytes())
-		_, _ = rw.Write(summariesBuf.Bytes())
+		writeJSON(rw, http.StatusOK, client.RaceResponse{
+			Stats:       stats,
+			Simsessions: simsessions,
+			Results:     results,
+		})
 	}
 }
 
-func showRace(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+func showRaceLaps(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		if !verifyBasicAuth(rw, req) {
 			return
@@ -289,49 +686,668 @@ func showRace(c *collector.Collector) func(rw http.ResponseWriter, req *http.Req
 			return
 		}
 
-		stats, err := c.Database().GetRaceStatsBySubsessionID(subsessionID)
+		laps, err := c.Database().GetRaceLapsBySubsessionID(req.Context(), subsessionID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.RaceLapsResponse{
+			SubsessionID: subsessionID,
+			Laps:         laps,
+		})
+	}
+}
+
+func showDriver(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		vars := mux.Vars(req)
+		driverID, err := strconv.Atoi(vars["driverID"])
+		if err != nil {
+			log.Errorf("could not convert driverID [%s] to int: %v", vars["driverID"], err)
+			failure(rw, req, err)
+			return
+		}
+
+		profile, err := c.Database().GetDriverProfileByDriverID(req.Context(), driverID)
 		if err != nil {
 			failure(rw, req, err)
 			return
 		}
-		results, err := c.Database().GetRaceResultsBySubsessionID(subsessionID)
+		writeJSON(rw, http.StatusOK, client.DriverResponse{Profile: profile})
+	}
+}
+
+func searchDrivers(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		name := strings.TrimSpace(req.URL.Query().Get("name"))
+		if len(name) == 0 {
+			writeJSON(rw, http.StatusOK, client.DriverSearchResponse{Drivers: make([]database.DriverNameMatch, 0)})
+			return
+		}
+		drivers, err := c.Database().SearchDriversByName(req.Context(), name)
 		if err != nil {
 			failure(rw, req, err)
 			return
 		}
+		writeJSON(rw, http.StatusOK, client.DriverSearchResponse{Name: name, Drivers: drivers})
+	}
+}
+
+// driverRequest reads the driverID and the optional season, week, series, car_class, limit and offset query parameters
+func driverRequest(req *http.Request) (int, database.DriverFilter, error) {
+	vars := mux.Vars(req)
+	driverID, err := strconv.Atoi(vars["driverID"])
+	if err != nil {
+		log.Errorf("could not convert driverID [%s] to int: %v", vars["driverID"], err)
+		return 0, database.DriverFilter{}, err
+	}
+	filter, err := driverFilter(req.URL.Query())
+	return driverID, filter, err
+}
+
+// driverFilter reads the optional season, week, series, car_class, limit and offset query parameters
+func driverFilter(query url.Values) (database.DriverFilter, error) {
+	filter := database.DriverFilter{}
+	for key, value := range map[string]**int{
+		"season":    &filter.SeasonID,
+		"week":      &filter.Week,
+		"series":    &filter.SeriesID,
+		"car_class": &filter.CarClassID,
+	} {
+		if len(query.Get(key)) == 0 {
+			continue
+		}
+		v, err := strconv.Atoi(query.Get(key))
+		if err != nil {
+			log.Errorf("could not convert %s [%s] to int: %v", key, query.Get(key), err)
+			return filter, err
+		}
+		*value = &v
+	}
+	for key, value := range map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if len(query.Get(key)) == 0 {
+			continue
+		}
+		v, err := strconv.Atoi(query.Get(key))
+		if err != nil {
+			log.Errorf("could not convert %s [%s] to int: %v", key, query.Get(key), err)
+			return filter, err
+		}
+		*value = v
+	}
+	return filter, nil
+}
+
+func showDriverResults(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
 
-		data := struct {
-			Stats      database.RaceStats
-			ResultRows []database.RaceResult
-		}{
-			Stats:      stats,
-			ResultRows: results,
+		driverID, filter, err := driverRequest(req)
+		if err != nil {
+			failure(rw, req, err)
+			return
 		}
-		raceTmpl := `{
-  "fk_subsession_id": {{ .Stats.SubsessionID }},
-  "startime": "{{ .Stats.StartTime }}", "simulated_starttime": "{{ .Stats.SimulatedStartTime }}",
-  "laps": {{ .Stats.Laps }},
-  "avg_laptime": "{{ .Stats.AvgLaptime }}",
-  "lead_changes": {{ .Stats.LeadChanges }},
-  "cautions": {{ .Stats.Cautions }}, "caution_laps": {{ .Stats.CautionLaps }},
-  "corners_per_lap": {{ .Stats.CornersPerLap }},
-  "cautions": {{ .Stats.AvgQualiLaps }},
-  "weather_rh": {{ .Stats.WeatherRH }}, "weather_temp": {{ .Stats.WeatherTemp }},
-  [
-{{ range .ResultRows }}    { "pos": {{ .FinishingPosition }}, "driver": "{{ .Driver.Name }}", "new_irating": {{ .IRatingAfter }}, "champpoints": {{ .ChampPoints }}, "clubpoints": {{ .ClubPoints }}, "incidents": {{ .Incidents }}, "avg_laptime": "{{ .AvgLaptime }}", "reason_out": "{{ .ReasonOut }}" },
-{{ end }}  ]
-}`
-		race := template.Must(template.New("race").Parse(raceTmpl))
-		var buf bytes.Buffer
-		if err := race.Execute(&buf, data); err != nil {
-			log.Errorf("could not parse result template: %v", err)
+		results, err := c.Database().GetRaceResultsByDriverID(req.Context(), driverID, filter)
+		if err != nil {
 			failure(rw, req, err)
 			return
 		}
+		writeJSON(rw, http.StatusOK, client.DriverResultsResponse{DriverID: driverID, Results: results})
+	}
+}
+
+func showDriverSummary(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
 
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(200)
-		_, _ = rw.Write(buf.Bytes())
+		driverID, filter, err := driverRequest(req)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		summaries, err := c.Database().GetDriverSeasonSummariesByDriverID(req.Context(), driverID, filter)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.DriverSummaryResponse{DriverID: driverID, Seasons: summaries})
+	}
+}
+
+func showDriverIRating(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		driverID, filter, err := driverRequest(req)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		iratings, err := c.Database().GetRatingHistoryByDriverID(req.Context(), driverID, filter)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.DriverIRatingResponse{DriverID: driverID, IRatings: iratings})
+	}
+}
+
+func showRatings(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		query := req.URL.Query()
+		filter, err := driverFilter(query)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		interval := query.Get("interval")
+		if len(interval) == 0 {
+			interval = database.RatingIntervalDay
+		}
+
+		ratings := client.RatingsResponse{Interval: interval, Drivers: make([]client.DriverRatings, 0)}
+		for _, id := range strings.Split(query.Get("drivers"), ",") {
+			if len(strings.TrimSpace(id)) == 0 {
+				continue
+			}
+			driverID, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				log.Errorf("could not convert driverID [%s] to int: %v", id, err)
+				failure(rw, req, err)
+				return
+			}
+
+			driver, err := c.Database().GetDriverByID(req.Context(), driverID)
+			if err != nil {
+				failure(rw, req, err)
+				return
+			}
+			points, err := c.Database().GetRatingTimeSeriesByDriverID(req.Context(), driverID, filter)
+			if err != nil {
+				failure(rw, req, err)
+				return
+			}
+			trajectories, err := database.DownsampleRatings(points, interval)
+			if err != nil {
+				failure(rw, req, err)
+				return
+			}
+			ratings.Drivers = append(ratings.Drivers, client.DriverRatings{Driver: driver, Categories: trajectories})
+		}
+		writeJSON(rw, http.StatusOK, ratings)
+	}
+}
+
+// pathID reads an integer path variable
+func pathID(req *http.Request, name string) (int, error) {
+	vars := mux.Vars(req)
+	id, err := strconv.Atoi(vars[name])
+	if err != nil {
+		log.Errorf("could not convert %s [%s] to int: %v", name, vars[name], err)
+	}
+	return id, err
+}
+
+// timeParam reads an optional RFC3339 timestamp or plain date query parameter
+func timeParam(req *http.Request, key string) (*time.Time, error) {
+	value := req.URL.Query().Get(key)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	err := fmt.Errorf("%w: could not parse %s [%s], expected RFC3339 or YYYY-MM-DD", errBadRequest, key, value)
+	log.Errorln(err)
+	return nil, err
+}
+
+func showTeams(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		teams, err := c.Database().GetTeams(req.Context())
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamsResponse{Teams: teams})
+	}
+}
+
+func createTeam(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		name := strings.TrimSpace(req.URL.Query().Get("name"))
+		if len(name) == 0 {
+			failure(rw, req, fmt.Errorf("%w: a team needs a name", errBadRequest))
+			return
+		}
+		team, err := c.Database().InsertTeam(req.Context(), database.Team{Name: name})
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamResponse{Team: team, Memberships: make([]database.TeamMembership, 0)})
+	}
+}
+
+func showTeam(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		teamID, err := pathID(req, "teamID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		team, err := c.Database().GetTeamByID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		memberships, err := c.Database().GetTeamMembershipsByTeamID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamResponse{Team: team, Memberships: memberships})
+	}
+}
+
+func updateTeam(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		teamID, err := pathID(req, "teamID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		name := strings.TrimSpace(req.URL.Query().Get("name"))
+		if len(name) == 0 {
+			failure(rw, req, fmt.Errorf("%w: a team needs a name", errBadRequest))
+			return
+		}
+		team, err := c.Database().GetTeamByID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		team.Name = name
+		if err := c.Database().UpdateTeam(req.Context(), team); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		memberships, err := c.Database().GetTeamMembershipsByTeamID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamResponse{Team: team, Memberships: memberships})
+	}
+}
+
+func deleteTeam(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		teamID, err := pathID(req, "teamID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		team, err := c.Database().GetTeamByID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		memberships, err := c.Database().GetTeamMembershipsByTeamID(req.Context(), teamID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if err := c.Database().DeleteTeam(req.Context(), teamID); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamResponse{Team: team, Memberships: memberships})
+	}
+}
+
+func addTeamMember(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		teamID, err := pathID(req, "teamID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		driverID, err := strconv.Atoi(req.URL.Query().Get("driver"))
+		if err != nil {
+			log.Errorf("could not convert driver [%s] to int: %v", req.URL.Query().Get("driver"), err)
+			failure(rw, req, err)
+			return
+		}
+		validFrom, err := timeParam(req, "from")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		validTo, err := timeParam(req, "to")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+
+		membership := database.TeamMembership{TeamID: teamID, DriverID: driverID, ValidFrom: time.Now(), ValidTo: validTo}
+		if validFrom != nil {
+			membership.ValidFrom = *validFrom
+		}
+		membership, err = c.Database().InsertTeamMembership(req.Context(), membership)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamMembershipResponse{Membership: membership})
+	}
+}
+
+// teamMembership reads the teamID and membershipID and makes sure they belong together
+func teamMembership(c *collector.Collector, req *http.Request) (database.TeamMembership, error) {
+	teamID, err := pathID(req, "teamID")
+	if err != nil {
+		return database.TeamMembership{}, err
+	}
+	membershipID, err := pathID(req, "membershipID")
+	if err != nil {
+		return database.TeamMembership{}, err
+	}
+	membership, err := c.Database().GetTeamMembershipByID(req.Context(), membershipID)
+	if err != nil {
+		return membership, err
+	}
+	if membership.TeamID != teamID {
+		return membership, fmt.Errorf("membership %d is not part of team %d: %w", membershipID, teamID, sql.ErrNoRows)
+	}
+	return membership, nil
+}
+
+func updateTeamMember(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		membership, err := teamMembership(c, req)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		validFrom, err := timeParam(req, "from")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		validTo, err := timeParam(req, "to")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if validFrom != nil {
+			membership.ValidFrom = *validFrom
+		}
+		membership.ValidTo = validTo // no "to" means open-ended
+
+		if err := c.Database().UpdateTeamMembership(req.Context(), membership); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		membership, err = c.Database().GetTeamMembershipByID(req.Context(), membership.MembershipID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamMembershipResponse{Membership: membership})
+	}
+}
+
+func removeTeamMember(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		membership, err := teamMembership(c, req)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		if err := c.Database().DeleteTeamMembership(req.Context(), membership.MembershipID); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamMembershipResponse{Membership: membership})
+	}
+}
+
+func showTeamRules(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		seriesID, err := pathID(req, "seriesID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		rules, err := c.Database().GetTeamRulesBySeriesID(req.Context(), seriesID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamRulesResponse{Rules: rules})
+	}
+}
+
+func updateTeamRules(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		seriesID, err := pathID(req, "seriesID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		rules, err := c.Database().GetTeamRulesBySeriesID(req.Context(), seriesID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+
+		// rules left out of the query stay as they are
+		query := req.URL.Query()
+		for key, value := range map[string]*int{
+			"top_drivers": &rules.TopDrivers,
+			"drop_weeks":  &rules.DropWeeks,
+			"min_weeks":   &rules.MinWeeks,
+		} {
+			if len(query.Get(key)) == 0 {
+				continue
+			}
+			v, err := strconv.Atoi(query.Get(key))
+			if err != nil {
+				log.Errorf("could not convert %s [%s] to int: %v", key, query.Get(key), err)
+				failure(rw, req, err)
+				return
+			}
+			*value = v
+		}
+		if rules.TopDrivers < 1 || rules.DropWeeks < 0 || rules.MinWeeks < 0 {
+			failure(rw, req, fmt.Errorf("%w: invalid team rules %v", errBadRequest, rules))
+			return
+		}
+
+		if err := c.Database().UpsertTeamRules(req.Context(), rules); err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamRulesResponse{Rules: rules})
+	}
+}
+
+func showTeamStandings(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		seasonID, err := pathID(req, "seasonID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		season, err := c.Database().GetSeasonByID(req.Context(), seasonID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		rules, err := c.Database().GetTeamRulesBySeriesID(req.Context(), season.SeriesID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		points, err := c.Database().GetTeamPointsBySeasonID(req.Context(), seasonID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.TeamStandingsResponse{
+			SeasonID:  seasonID,
+			Rules:     rules,
+			Standings: database.TeamStandings(points, rules),
+		})
+	}
+}
+
+func showSchedule(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		seasonID, err := pathID(req, "seasonID")
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		schedule, err := c.Database().GetSeasonScheduleBySeasonID(req.Context(), seasonID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.ScheduleResponse{Schedule: schedule})
+	}
+}
+
+func showJobs(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		jobs, err := c.Database().GetJobs(req.Context())
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.JobsResponse{Jobs: jobs})
+	}
+}
+
+func showJob(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		vars := mux.Vars(req)
+		jobID, err := strconv.Atoi(vars["jobID"])
+		if err != nil {
+			log.Errorf("could not convert jobID [%s] to int: %v", vars["jobID"], err)
+			failure(rw, req, err)
+			return
+		}
+
+		job, err := c.Database().GetJobByID(req.Context(), jobID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.JobResponse{Job: job})
+	}
+}
+
+func cancelJob(c *collector.Collector) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if !verifyBasicAuth(rw, req) {
+			return
+		}
+
+		vars := mux.Vars(req)
+		jobID, err := strconv.Atoi(vars["jobID"])
+		if err != nil {
+			log.Errorf("could not convert jobID [%s] to int: %v", vars["jobID"], err)
+			failure(rw, req, err)
+			return
+		}
+
+		job, err := c.CancelJob(req.Context(), jobID)
+		if err != nil {
+			failure(rw, req, err)
+			return
+		}
+		writeJSON(rw, http.StatusOK, client.JobResponse{Job: job})
 	}
 }
 
@@ -1,14 +1,38 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/JamesClonk/iRcollector/client"
 	"github.com/JamesClonk/iRcollector/collector"
+	"github.com/JamesClonk/iRcollector/database"
 	"github.com/stretchr/testify/assert"
 )
 
+// serve sends an authenticated request to the router, backed by the given database stub
+func serve(db database.Database, method, url string) *httptest.ResponseRecorder {
+	username, password = "user", "pw"
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, url, nil)
+	req.SetBasicAuth("user", "pw")
+	router(collector.New(db)).ServeHTTP(rec, req)
+	return rec
+}
+
+// testServer runs the router backed by the given database stub, together with a typed client for it
+func testServer(db database.Database) (*httptest.Server, *client.Client) {
+	username, password = "user", "pw"
+	server := httptest.NewServer(router(collector.New(db)))
+	return server, client.New(server.URL, "user", "pw")
+}
+
 func Test_HealthEndpoint(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req, err := http.NewRequest("GET", "/health", nil)
@@ -20,3 +44,61 @@ func Test_HealthEndpoint(t *testing.T) {
 	assert.Equal(t, 200, rec.Code)
 	assert.Equal(t, `{ "status": "ok" }`, rec.Body.String())
 }
+
+func Test_Failure(t *testing.T) {
+	_, numErr := strconv.Atoi("abc")
+	for err, status := range map[error]int{
+		numErr:                                   400,
+		fmt.Errorf("%w: no name", errBadRequest): 400,
+		fmt.Errorf("%w: overlap", database.ErrInvalid): 400,
+		fmt.Errorf("unknown: %w", sql.ErrNoRows):       404,
+		errors.New("connection refused"):               500,
+	} {
+		rec := httptest.NewRecorder()
+		failure(rec, httptest.NewRequest("GET", "/", nil), err)
+		assert.Equal(t, status, rec.Code, err.Error())
+		var body client.ErrorResponse
+		if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body)) {
+			assert.Equal(t, err.Error(), body.Error)
+		}
+	}
+}
+
+func Test_ParseSeason(t *testing.T) {
+	year, quarter, err := parseSeason("2021S4")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2021, year)
+		assert.Equal(t, 4, quarter)
+	}
+	_, _, err = parseSeason("2021s2")
+	assert.NoError(t, err)
+	for _, invalid := range []string{"", "2021", "2021S5", "S1", "2021-S1"} {
+		_, _, err = parseSeason(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_CheckSeasonRange(t *testing.T) {
+	assert.NoError(t, checkSeasonRange(2021, 3, 2021, 3))
+	assert.NoError(t, checkSeasonRange(2020, 4, 2021, 1))
+	assert.Error(t, checkSeasonRange(2021, 0, 2021, 3))
+	assert.Error(t, checkSeasonRange(2021, 1, 2021, 5))
+	assert.Error(t, checkSeasonRange(2021, 2, 2021, 1))
+}
+
+func Test_Discovery(t *testing.T) {
+	assert.Nil(t, discovery())
+
+	t.Setenv("IR_DISCOVERY", "true")
+	t.Setenv("IR_DISCOVERY_LICENSE_GROUPS", "3, 4,5")
+	t.Setenv("IR_DISCOVERY_TRACK_TYPES", "road")
+	t.Setenv("IR_DISCOVERY_OFFICIAL", "true")
+	criteria := discovery()
+	if assert.NotNil(t, criteria) {
+		assert.Equal(t, []int{3, 4, 5}, criteria.LicenseGroups)
+		assert.Equal(t, []string{"road"}, criteria.TrackTypes)
+		assert.Empty(t, criteria.CarClasses)
+		assert.True(t, *criteria.Official)
+		assert.Nil(t, criteria.FixedSetup)
+	}
+}
@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type PathItem map[string]*Operation // lowercase HTTP method -> operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+const BasicAuth = "basicAuth"
+
+func New(title, description, version string) *Document {
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				BasicAuth: {Type: "http", Scheme: "basic"},
+			},
+		},
+	}
+}
+
+// Endpoint describes a single route, its path parameters and the Go type of its JSON response body
+type Endpoint struct {
+	Method      string
+	Path        string // gorilla/mux style, e.g. /race/{subsessionID}
+	OperationID string
+	Summary     string
+	Auth        bool
+	Query       []string    // optional integer query parameters
+	StringQuery []string    // optional string query parameters
+	Response    interface{} // zero value of the response body type
+	Errors      []int       // status codes answered with an error body besides 500, like 400 for invalid input or 404 for unknown IDs
+}
+
+func (d *Document) Add(e Endpoint) {
+	op := &Operation{
+		OperationID: e.OperationID,
+		Summary:     e.Summary,
+		Responses: map[string]Response{
+			"200": {
+				Description: "OK",
+				Content:     map[string]MediaType{"application/json": {Schema: d.SchemaOf(e.Response)}},
+			},
+		},
+	}
+	for _, segment := range strings.Split(e.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "integer"},
+			})
+		}
+	}
+	for _, name := range e.Query {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: &Schema{Type: "integer"},
+		})
+	}
+	for _, name := range e.StringQuery {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: &Schema{Type: "string"},
+		})
+	}
+	if e.Auth {
+		op.Security = []map[string][]string{{BasicAuth: {}}}
+		op.Responses["401"] = Response{Description: "Unauthorized"}
+	}
+	errorSchema := d.SchemaOf(struct {
+		Error string `json:"error"`
+	}{})
+	op.Responses["500"] = Response{
+		Description: "Internal Server Error",
+		Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+	}
+	for _, code := range e.Errors {
+		op.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	item, ok := d.Paths[e.Path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[e.Path] = item
+	}
+	(*item)[strings.ToLower(e.Method)] = op
+}
+
+// SchemaOf returns the schema for the given value, named struct types are registered as components and referenced
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Ptr:
+		s := d.schemaOf(t.Elem())
+		if len(s.Ref) > 0 {
+			return &Schema{Ref: s.Ref, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if len(t.Name()) == 0 { // anonymous struct, inline it
+			return d.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			d.Components.Schemas[name] = &Schema{} // placeholder for recursive types
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %v", t))
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	noAdditional := false
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: &noAdditional,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 { // unexported
+			continue
+		}
+		name, omitempty := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		s.Properties[name] = d.schemaOf(f.Type)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if len(tag) == 0 {
+		return f.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if len(name) == 0 {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	if len(pkg) == 0 {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks a JSON response body against the schema documented for the given operation and status code
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	item, ok := d.Paths[path]
+	if !ok {
+		return fmt.Errorf("path [%s] is not documented", path)
+	}
+	op, ok := (*item)[strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("operation [%s %s] is not documented", method, path)
+	}
+	resp, ok := op.Responses[fmt.Sprintf("%d", status)]
+	if !ok {
+		return fmt.Errorf("status code [%d] of [%s %s] is not documented", status, method, path)
+	}
+	media, ok := resp.Content["application/json"]
+	if !ok {
+		return fmt.Errorf("no JSON content documented for [%s %s] with status code [%d]", method, path, status)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return d.validate(media.Schema, value, "$")
+}
+
+func (d *Document) validate(s *Schema, value interface{}, at string) error {
+	if len(s.Ref) > 0 {
+		ref, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema reference [%s]", at, s.Ref)
+		}
+		if value == nil && s.Nullable {
+			return nil
+		}
+		return d.validate(ref, value, at)
+	}
+	if value == nil {
+		if s.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", at)
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, value)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property [%s]", at, name)
+			}
+		}
+		for name, v := range obj {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: undocumented property [%s]", at, name)
+				}
+				continue
+			}
+			if err := d.validate(prop, v, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, value)
+		}
+		for idx, v := range arr {
+			if err := d.validate(s.Items, v, fmt.Sprintf("%s[%d]", at, idx)); err != nil {
+				return err
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, value)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, value)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s: expected date-time, got [%s]", at, str)
+			}
+		}
+	}
+	return nil
+}
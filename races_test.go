@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/JamesClonk/iRcollector/client"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type raceDatabase struct {
+	database.Database
+}
+
+func (db *raceDatabase) GetRaceStatsBySubsessionID(context.Context, int) (database.RaceStats, error) {
+	return database.RaceStats{SubsessionID: 123, Laps: 20, Cautions: 2, AvgQualiLaps: 3}, nil
+}
+
+func (db *raceDatabase) GetSimsessionsBySubsessionID(context.Context, int) ([]database.Simsession, error) {
+	return []database.Simsession{
+		{SubsessionID: 123, SimsessionNumber: 0, SimsessionType: 6, SimsessionTypeName: "Race", SimsessionName: "RACE"},
+		{SubsessionID: 123, SimsessionNumber: -1, SimsessionType: 4, SimsessionTypeName: "Lone Qualifying", SimsessionName: "QUALIFY"},
+	}, nil
+}
+
+func (db *raceDatabase) GetRaceResultsBySubsessionID(context.Context, int) ([]database.RaceResult, error) {
+	return []database.RaceResult{
+		{SubsessionID: 123, Driver: database.Driver{DriverID: 1, Name: `Jean "JJ" O'Neil \ Jr.`}, CPIAfter: 1.5},
+		{SubsessionID: 123, SimsessionNumber: -1, Driver: database.Driver{DriverID: 1, Name: `Jean "JJ" O'Neil \ Jr.`}, BestLaptime: database.Laptime(1199000)},
+	}, nil
+}
+
+func (db *raceDatabase) GetRaceLapsBySubsessionID(context.Context, int) ([]database.RaceLap, error) {
+	return []database.RaceLap{
+		{SubsessionID: 123, Driver: database.Driver{DriverID: 1, Name: "Jack"}, Lap: 1, Laptime: database.Laptime(1210000), Position: 1},
+		{SubsessionID: 123, Driver: database.Driver{DriverID: 1, Name: "Jack"}, Lap: 2, Laptime: database.Laptime(1250000), Position: 1, Pitted: true, Events: "pitted"},
+	}, nil
+}
+
+func Test_RaceEndpoint(t *testing.T) {
+	rec := serve(&raceDatabase{}, "GET", "/race/123")
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var race client.RaceResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &race)) {
+		assert.Equal(t, 123, race.Stats.SubsessionID)
+		assert.Equal(t, 2, race.Stats.Cautions)
+		assert.Equal(t, 3, race.Stats.AvgQualiLaps)
+		assert.Len(t, race.Simsessions, 2)
+		assert.Len(t, race.Results, 2)
+		assert.Equal(t, `Jean "JJ" O'Neil \ Jr.`, race.Results[0].Driver.Name)
+		assert.Equal(t, -1, race.Results[1].SimsessionNumber)
+	}
+
+	// invalid path parameters are the client's fault
+	rec = serve(&raceDatabase{}, "GET", "/race/abc")
+	assert.Equal(t, 400, rec.Code)
+}
+
+func Test_RaceLapsEndpoint(t *testing.T) {
+	server, c := testServer(&raceDatabase{})
+	defer server.Close()
+
+	laps, err := c.GetRaceLaps(123)
+	if assert.NoError(t, err) && assert.Len(t, laps.Laps, 2) {
+		assert.True(t, laps.Laps[1].Pitted)
+	}
+	_, err = client.New(server.URL, "user", "wrong").GetRace(123)
+	assert.Error(t, err)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/JamesClonk/iRcollector/log"
+)
+
+func writeJSON(rw http.ResponseWriter, code int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("could not marshal response: %v", err)
+		code = http.StatusInternalServerError
+		data = []byte(`{"error":"could not marshal response"}`)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	_, _ = rw.Write(data)
+}
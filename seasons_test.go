@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type seasonDatabase struct {
+	database.Database
+}
+
+func (db *seasonDatabase) GetSeasons(context.Context) ([]database.Season, error) {
+	return []database.Season{{SeriesID: 1, SeasonID: 2307, Year: 2019, Quarter: 2, StartDate: time.Date(2019, 3, 12, 0, 0, 0, 0, time.UTC)}}, nil
+}
+
+func (db *seasonDatabase) GetRaceWeekResultsBySeasonIDAndWeek(context.Context, int, int) ([]database.RaceWeekResult, error) {
+	return []database.RaceWeekResult{{RaceWeekID: 1, StartTime: time.Now(), SubsessionID: 123, Official: true, SizeOfField: 20}}, nil
+}
+
+func (db *seasonDatabase) GetTimeRankingsBySeasonIDAndWeek(context.Context, int, int) ([]database.TimeRanking, error) {
+	return []database.TimeRanking{{Driver: database.Driver{DriverID: 1, Name: "Jack"}, TimeTrial: database.Laptime(1234567)}}, nil
+}
+
+func (db *seasonDatabase) GetDriverSummariesBySeasonIDAndWeek(context.Context, int, int) ([]database.Summary, error) {
+	return []database.Summary{{Driver: database.Driver{DriverID: 1, Name: "Jack"}, AverageIncidentsPerLap: 0.25, NumberOfRaces: 3}}, nil
+}
+
+func (db *seasonDatabase) GetSeasonScheduleBySeasonID(_ context.Context, id int) (database.SeasonSchedule, error) {
+	return database.SeasonSchedule{
+		SeasonID: id, CarClassIDs: []int{74}, DropWeeks: 4, MaxWeeks: 12,
+		Weeks: []database.ScheduleWeek{
+			{SeasonID: id, RaceWeek: 0, StartDate: time.Date(2021, 12, 14, 0, 0, 0, 0, time.UTC), TrackID: 413, TrackName: "Hungaroring", TrackCategory: "road", RaceTime: 25},
+			{SeasonID: id, RaceWeek: 1, StartDate: time.Date(2021, 12, 21, 0, 0, 0, 0, time.UTC), TrackID: 999, TrackName: "Circuit Zandvoort", TrackConfig: "Grand Prix", TrackCategory: "road", RaceLaps: 14},
+		},
+	}, nil
+}
+
+func Test_SeasonEndpoints(t *testing.T) {
+	server, c := testServer(&seasonDatabase{})
+	defer server.Close()
+
+	seasons, err := c.GetSeasons()
+	if assert.NoError(t, err) && assert.Len(t, seasons.Seasons, 1) {
+		assert.Equal(t, 2307, seasons.Seasons[0].SeasonID)
+	}
+	week, err := c.GetWeek(2307, 3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2307, week.SeasonID)
+		assert.Equal(t, 3, week.Week)
+		assert.Len(t, week.Results, 1)
+		assert.Len(t, week.Rankings, 1)
+		assert.Len(t, week.Summaries, 1)
+	}
+	schedule, err := c.GetSchedule(2307)
+	if assert.NoError(t, err) && assert.Len(t, schedule.Schedule.Weeks, 2) {
+		assert.Equal(t, []int{74}, schedule.Schedule.CarClassIDs)
+		assert.Equal(t, "Circuit Zandvoort", schedule.Schedule.Weeks[1].TrackName)
+		assert.Equal(t, 14, schedule.Schedule.Weeks[1].RaceLaps)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/JamesClonk/iRcollector/client"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type seriesDatabase struct {
+	database.Database
+}
+
+func (db *seriesDatabase) GetSeries(context.Context) ([]database.Series, error) {
+	return []database.Series{{SeriesID: 1, SeriesName: `Formula "3.5"`, SeriesRegex: `Formula 3\.5`, Active: "true", APISeriesID: 358}}, nil
+}
+
+func (db *seriesDatabase) GetSeriesByID(_ context.Context, id int) (database.Series, error) {
+	return database.Series{SeriesID: id, SeriesName: `Formula "3.5"`, SeriesNameShort: "F3.5", SeriesRegex: `Formula 3\.5`, Active: "false"}, nil
+}
+
+func (db *seriesDatabase) UpsertSeries(_ context.Context, series database.Series) (database.Series, error) {
+	if series.SeriesID == 0 {
+		series.SeriesID = 9
+	}
+	return series, nil
+}
+
+func (db *seriesDatabase) DeactivateSeries(context.Context, int) error {
+	return nil
+}
+
+func Test_CreateSeriesValidation(t *testing.T) {
+	rec := serve(&seriesDatabase{}, "POST", "/series?name=Radical&regex=Radical(")
+
+	assert.Equal(t, 400, rec.Code)
+	var body client.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body)) {
+		assert.True(t, strings.HasPrefix(body.Error, "invalid series: regex [Radical(] does not compile"), body.Error)
+	}
+}
+
+func Test_SeriesEndpoints(t *testing.T) {
+	server, c := testServer(&seriesDatabase{})
+	defer server.Close()
+
+	series, err := c.GetSeries()
+	if assert.NoError(t, err) && assert.Len(t, series.Series, 1) {
+		assert.Equal(t, `Formula "3.5"`, series.Series[0].SeriesName)
+	}
+	created, err := c.CreateSeries(database.Series{SeriesName: "Radical", SeriesRegex: "Radical", Active: "false"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 9, created.Series.SeriesID)
+		assert.Equal(t, "Radical", created.Series.SeriesNameShort)
+	}
+	deactivated, err := c.DeactivateSeries(9)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "false", deactivated.Series.Active)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/JamesClonk/iRcollector/client"
+	"github.com/JamesClonk/iRcollector/openapi"
+)
+
+var endpoints = []openapi.Endpoint{
+	{Method: "GET", Path: "/health", OperationID: "getHealth", Summary: "Health check", Response: client.HealthResponse{}},
+	{Method: "GET", Path: "/series", OperationID: "getSeries", Summary: "List all series", Response: client.SeriesResponse{}},
+	{Method: "POST", Path: "/series/{seriesID}/backfill", OperationID: "backfillSeries", Summary: "Queue a job collecting all past seasons of a series within a year/quarter range", Auth: true, Query: []string{"from_year", "from_quarter", "to_year", "to_quarter"}, Response: client.TaskResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/seasons", OperationID: "getSeasons", Summary: "List all seasons", Auth: true, Response: client.SeasonsResponse{}},
+	{Method: "POST", Path: "/seasons", OperationID: "collectSeasons", Summary: "Queue a job collecting all current seasons", Auth: true, Response: client.TaskResponse{}},
+	{Method: "PUT", Path: "/seasons", OperationID: "collectSeasonsPut", Summary: "Same as POST /seasons, kept for older clients", Auth: true, Response: client.TaskResponse{}},
+	{Method: "POST", Path: "/season/{seasonID}", OperationID: "collectSeason", Summary: "Queue a job collecting all weeks of a season", Auth: true, Response: client.TaskResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "PUT", Path: "/season/{seasonID}", OperationID: "collectSeasonPut", Summary: "Same as POST /season/{seasonID}, kept for older clients", Auth: true, Response: client.TaskResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/season/{seasonID}/week/{week}", OperationID: "getWeek", Summary: "Raceweek results, time rankings and driver summaries", Auth: true, Response: client.WeekResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/season/{seasonID}/teams", OperationID: "getTeamStandings", Summary: "Team standings of a season with a raceweek by raceweek breakdown, according to the team rules of the series", Auth: true, Response: client.TeamStandingsResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/season/{seasonID}/schedule", OperationID: "getSchedule", Summary: "Schedule of a season with the track and race length of every raceweek, known before any race has run", Auth: true, Response: client.ScheduleResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "POST", Path: "/season/{seasonID}/week/{week}", OperationID: "collectWeek", Summary: "Queue a job collecting a raceweek", Auth: true, Response: client.TaskResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "PUT", Path: "/season/{seasonID}/week/{week}", OperationID: "collectWeekPut", Summary: "Same as POST /season/{seasonID}/week/{week}, kept for older clients", Auth: true, Response: client.TaskResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/race/{subsessionID}", OperationID: "getRace", Summary: "Race statistics and results of every simsession (qualifying, heats, feature race) of a subsession", Auth: true, Response: client.RaceResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/race/{subsessionID}/laps", OperationID: "getRaceLaps", Summary: "Lap by lap times, positions and flags of every driver in a subsession", Auth: true, Response: client.RaceLapsResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/drivers", OperationID: "searchDrivers", Summary: "Drivers with a current or former name containing the given name", Auth: true, StringQuery: []string{"name"}, Response: client.DriverSearchResponse{}},
+	{Method: "GET", Path: "/driver/{driverID}", OperationID: "getDriver", Summary: "Profile of a driver with its name history, and licenses, ratings and career stats once enriched", Auth: true, Response: client.DriverResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/driver/{driverID}/results", OperationID: "getDriverResults", Summary: "Feature race results of a driver, newest first", Auth: true, Query: []string{"season", "week", "series", "car_class", "limit", "offset"}, Response: client.DriverResultsResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/driver/{driverID}/summary", OperationID: "getDriverSummary", Summary: "Races, time rankings and time trials of a driver summed up per season", Auth: true, Query: []string{"season", "week", "series", "car_class", "limit", "offset"}, Response: client.DriverSummaryResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/driver/{driverID}/irating", OperationID: "getDriverIRating", Summary: "iRating of a driver before and after each feature race, oldest first", Auth: true, Query: []string{"season", "week", "series", "car_class", "limit", "offset"}, Response: client.DriverIRatingResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/ratings", OperationID: "getRatings", Summary: "iRating and safety rating trajectories of a comma separated list of drivers per license category, downsampled per race, day or week", Auth: true, Query: []string{"season", "week", "series", "car_class"}, StringQuery: []string{"drivers", "interval"}, Response: client.RatingsResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "POST", Path: "/series", OperationID: "createSeries", Summary: "Add a series to collect, its regex must compile and an active series must match a current season by api_series_id", Auth: true, Query: []string{"api_series_id"}, StringQuery: []string{"name", "short_name", "regex", "colorscheme", "active"}, Response: client.SeriesItemResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "PUT", Path: "/series/{seriesID}", OperationID: "updateSeries", Summary: "Change the given fields of a series, validated like on creation. Setting active approves or rejects a discovered series", Auth: true, Query: []string{"api_series_id"}, StringQuery: []string{"name", "short_name", "regex", "colorscheme", "active"}, Response: client.SeriesItemResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "DELETE", Path: "/series/{seriesID}", OperationID: "deactivateSeries", Summary: "Stop collecting a series, keeping everything collected so far", Auth: true, Response: client.SeriesItemResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/series/{seriesID}/team-rules", OperationID: "getTeamRules", Summary: "Rules of the team standings of a series, the defaults unless configured", Auth: true, Response: client.TeamRulesResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "PUT", Path: "/series/{seriesID}/team-rules", OperationID: "updateTeamRules", Summary: "Configure the top drivers counted per raceweek, the worst raceweeks dropped and the raceweeks needed to be classified", Auth: true, Query: []string{"top_drivers", "drop_weeks", "min_weeks"}, Response: client.TeamRulesResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/teams", OperationID: "getTeams", Summary: "List teams", Auth: true, Response: client.TeamsResponse{}},
+	{Method: "POST", Path: "/teams", OperationID: "createTeam", Summary: "Create a team with the given name", Auth: true, StringQuery: []string{"name"}, Response: client.TeamResponse{}, Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/teams/{teamID}", OperationID: "getTeam", Summary: "A team with all of its current and former memberships", Auth: true, Response: client.TeamResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "PUT", Path: "/teams/{teamID}", OperationID: "renameTeam", Summary: "Rename a team", Auth: true, StringQuery: []string{"name"}, Response: client.TeamResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "DELETE", Path: "/teams/{teamID}", OperationID: "deleteTeam", Summary: "Delete a team together with its memberships", Auth: true, Response: client.TeamResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "POST", Path: "/teams/{teamID}/members", OperationID: "addTeamMember", Summary: "Add a driver to a team from a date (default now) until a date (default open-ended), ending any open membership of the driver", Auth: true, Query: []string{"driver"}, StringQuery: []string{"from", "to"}, Response: client.TeamMembershipResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "PUT", Path: "/teams/{teamID}/members/{membershipID}", OperationID: "updateTeamMember", Summary: "Change the validity of a team membership, leaving out to makes it open-ended", Auth: true, StringQuery: []string{"from", "to"}, Response: client.TeamMembershipResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "DELETE", Path: "/teams/{teamID}/members/{membershipID}", OperationID: "removeTeamMember", Summary: "Delete a team membership", Auth: true, Response: client.TeamMembershipResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/jobs", OperationID: "getJobs", Summary: "List collection jobs", Auth: true, Response: client.JobsResponse{}},
+	{Method: "GET", Path: "/jobs/{jobID}", OperationID: "getJob", Summary: "Status, progress and errors of a collection job", Auth: true, Response: client.JobResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "DELETE", Path: "/jobs/{jobID}", OperationID: "cancelJob", Summary: "Cancel a pending or running job, delete a finished one", Auth: true, Response: client.JobResponse{}, Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+}
+
+var spec = newSpec()
+
+func newSpec() *openapi.Document {
+	doc := openapi.New("iRcollector", "Collects iRacing series, season and race data", "1.0.0")
+	for _, e := range endpoints {
+		doc.Add(e)
+	}
+	return doc
+}
+
+func showOpenAPI(rw http.ResponseWriter, req *http.Request) {
+	writeJSON(rw, http.StatusOK, spec)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JamesClonk/iRcollector/collector"
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// contract lists a request for every documented operation, and for some of their documented errors,
+// served by the database stub of its feature
+var contract = []struct {
+	method string
+	path   string
+	url    string
+	db     database.Database
+	status int
+}{
+	{"GET", "/health", "/health", nil, http.StatusOK},
+	{"GET", "/series", "/series", &seriesDatabase{}, http.StatusOK},
+	{"POST", "/series", "/series?name=Radical&regex=Radical&active=false", &seriesDatabase{}, http.StatusOK},
+	{"POST", "/series", "/series?name=Radical&regex=Radical(", &seriesDatabase{}, http.StatusBadRequest},
+	{"PUT", "/series/{seriesID}", "/series/9?short_name=F35", &seriesDatabase{}, http.StatusOK},
+	{"DELETE", "/series/{seriesID}", "/series/9", &seriesDatabase{}, http.StatusOK},
+	{"POST", "/series/{seriesID}/backfill", "/series/4/backfill?from_year=2020&from_quarter=1&to_year=2021&to_quarter=4", &jobDatabase{}, http.StatusOK},
+	{"POST", "/series/{seriesID}/backfill", "/series/4/backfill?from_year=2020", &jobDatabase{}, http.StatusBadRequest},
+	{"GET", "/series/{seriesID}/team-rules", "/series/9/team-rules", &teamDatabase{}, http.StatusOK},
+	{"PUT", "/series/{seriesID}/team-rules", "/series/9/team-rules?top_drivers=2&drop_weeks=1&min_weeks=4", &teamDatabase{}, http.StatusOK},
+	{"GET", "/seasons", "/seasons", &seasonDatabase{}, http.StatusOK},
+	{"POST", "/seasons", "/seasons", &jobDatabase{}, http.StatusOK},
+	{"PUT", "/seasons", "/seasons", &jobDatabase{}, http.StatusOK},
+	{"POST", "/season/{seasonID}", "/season/2307", &jobDatabase{}, http.StatusOK},
+	{"PUT", "/season/{seasonID}", "/season/2307", &jobDatabase{}, http.StatusOK},
+	{"GET", "/season/{seasonID}/week/{week}", "/season/2307/week/3", &seasonDatabase{}, http.StatusOK},
+	{"POST", "/season/{seasonID}/week/{week}", "/season/2307/week/3", &jobDatabase{}, http.StatusOK},
+	{"PUT", "/season/{seasonID}/week/{week}", "/season/2307/week/3", &jobDatabase{}, http.StatusOK},
+	{"GET", "/season/{seasonID}/teams", "/season/2307/teams", &teamDatabase{}, http.StatusOK},
+	{"GET", "/season/{seasonID}/schedule", "/season/2307/schedule", &seasonDatabase{}, http.StatusOK},
+	{"GET", "/race/{subsessionID}", "/race/123", &raceDatabase{}, http.StatusOK},
+	{"GET", "/race/{subsessionID}", "/race/abc", &raceDatabase{}, http.StatusBadRequest},
+	{"GET", "/race/{subsessionID}/laps", "/race/123/laps", &raceDatabase{}, http.StatusOK},
+	{"GET", "/drivers", "/drivers?name=jack", &driverDatabase{}, http.StatusOK},
+	{"GET", "/driver/{driverID}", "/driver/1", &driverDatabase{}, http.StatusOK},
+	{"GET", "/driver/{driverID}/results", "/driver/1/results?season=2307&week=3", &driverDatabase{}, http.StatusOK},
+	{"GET", "/driver/{driverID}/summary", "/driver/1/summary?season=2307", &driverDatabase{}, http.StatusOK},
+	{"GET", "/driver/{driverID}/irating", "/driver/1/irating", &driverDatabase{}, http.StatusOK},
+	{"GET", "/ratings", "/ratings?drivers=1,2&interval=week", &driverDatabase{}, http.StatusOK},
+	{"GET", "/teams", "/teams", &teamDatabase{}, http.StatusOK},
+	{"POST", "/teams", "/teams?name=Alpha", &teamDatabase{}, http.StatusOK},
+	{"GET", "/teams/{teamID}", "/teams/3", &teamDatabase{}, http.StatusOK},
+	{"PUT", "/teams/{teamID}", "/teams/3?name=Beta", &teamDatabase{}, http.StatusOK},
+	{"DELETE", "/teams/{teamID}", "/teams/3", &teamDatabase{}, http.StatusOK},
+	{"POST", "/teams/{teamID}/members", "/teams/3/members?driver=1&from=2021-06-01&to=2021-09-01", &teamDatabase{}, http.StatusOK},
+	{"PUT", "/teams/{teamID}/members/{membershipID}", "/teams/3/members/5?from=2021-06-01", &teamDatabase{}, http.StatusOK},
+	{"PUT", "/teams/{teamID}/members/{membershipID}", "/teams/4/members/5?from=2021-06-01", &teamDatabase{}, http.StatusNotFound},
+	{"DELETE", "/teams/{teamID}/members/{membershipID}", "/teams/3/members/5", &teamDatabase{}, http.StatusOK},
+	{"GET", "/jobs", "/jobs", &jobDatabase{}, http.StatusOK},
+	{"GET", "/jobs/{jobID}", "/jobs/7", &jobDatabase{}, http.StatusOK},
+	{"DELETE", "/jobs/{jobID}", "/jobs/7", &jobDatabase{}, http.StatusOK},
+}
+
+func Test_OpenAPI_Contract(t *testing.T) {
+	covered := make(map[string]bool)
+	for _, c := range contract {
+		rec := serve(c.db, c.method, c.url)
+		if assert.Equal(t, c.status, rec.Code, "%s %s: %s", c.method, c.url, rec.Body.String()) {
+			assert.NoError(t, spec.ValidateResponse(c.method, c.path, rec.Code, rec.Body.Bytes()), "%s %s", c.method, c.url)
+		}
+		covered[c.method+" "+c.path] = true
+	}
+
+	for _, e := range endpoints {
+		assert.True(t, covered[e.Method+" "+e.Path], "operation [%s %s] has no contract case", e.Method, e.Path)
+	}
+}
+
+func Test_OpenAPI_RoutesDocumented(t *testing.T) {
+	r := router(&collector.Collector{})
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || path == "/metrics" || path == "/openapi.json" {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		if path == "/health" {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			item, ok := spec.Paths[path]
+			if !assert.True(t, ok, "path [%s] missing from OpenAPI spec", path) {
+				continue
+			}
+			assert.Contains(t, *item, strings.ToLower(method), "operation [%s %s] missing from OpenAPI spec", method, path)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func Test_OpenAPI_Endpoint(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/openapi.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router(&collector.Collector{}).ServeHTTP(rec, req)
+
+	assert.Equal(t, 200, rec.Code)
+	var doc map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc)) {
+		assert.Equal(t, "3.0.3", doc["openapi"])
+		assert.Contains(t, doc["paths"], "/race/{subsessionID}")
+	}
+}
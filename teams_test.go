@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type teamDatabase struct {
+	database.Database
+}
+
+func (db *teamDatabase) GetTeams(context.Context) ([]database.Team, error) {
+	return []database.Team{{TeamID: 3, Name: "Alpha"}}, nil
+}
+
+func (db *teamDatabase) GetTeamByID(_ context.Context, id int) (database.Team, error) {
+	return database.Team{TeamID: id, Name: "Alpha"}, nil
+}
+
+func (db *teamDatabase) InsertTeam(_ context.Context, team database.Team) (database.Team, error) {
+	team.TeamID = 3
+	return team, nil
+}
+
+func (db *teamDatabase) UpdateTeam(context.Context, database.Team) error {
+	return nil
+}
+
+func (db *teamDatabase) DeleteTeam(context.Context, int) error {
+	return nil
+}
+
+func (db *teamDatabase) GetTeamMembershipsByTeamID(ctx context.Context, teamID int) ([]database.TeamMembership, error) {
+	membership, err := db.GetTeamMembershipByID(ctx, 5)
+	return []database.TeamMembership{membership}, err
+}
+
+func (db *teamDatabase) GetTeamMembershipByID(_ context.Context, id int) (database.TeamMembership, error) {
+	return database.TeamMembership{
+		MembershipID: id, TeamID: 3, TeamName: "Alpha", DriverID: 1, DriverName: "Jack",
+		ValidFrom: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (db *teamDatabase) InsertTeamMembership(_ context.Context, membership database.TeamMembership) (database.TeamMembership, error) {
+	membership.MembershipID = 5
+	return membership, nil
+}
+
+func (db *teamDatabase) UpdateTeamMembership(context.Context, database.TeamMembership) error {
+	return nil
+}
+
+func (db *teamDatabase) DeleteTeamMembership(context.Context, int) error {
+	return nil
+}
+
+func (db *teamDatabase) GetSeasonByID(_ context.Context, id int) (database.Season, error) {
+	return database.Season{SeasonID: id, SeriesID: 9}, nil
+}
+
+func (db *teamDatabase) GetTeamRulesBySeriesID(_ context.Context, id int) (database.TeamRules, error) {
+	return database.DefaultTeamRules(id), nil
+}
+
+func (db *teamDatabase) UpsertTeamRules(context.Context, database.TeamRules) error {
+	return nil
+}
+
+func (db *teamDatabase) GetTeamPointsBySeasonID(context.Context, int) ([]database.TeamPoints, error) {
+	return []database.TeamPoints{
+		{RaceWeek: 0, TeamID: 3, TeamName: "Alpha", DriverID: 1, DriverName: "Jack", ChampPoints: 120},
+		{RaceWeek: 1, TeamID: 3, TeamName: "Alpha", DriverID: 2, DriverName: "Jean", ChampPoints: 80},
+		{RaceWeek: 1, TeamID: 4, TeamName: "Beta", DriverID: 5, DriverName: "Jim", ChampPoints: 90},
+	}, nil
+}
+
+func Test_TeamEndpoints(t *testing.T) {
+	server, c := testServer(&teamDatabase{})
+	defer server.Close()
+
+	standings, err := c.GetTeamStandings(2307)
+	if assert.NoError(t, err) && assert.Len(t, standings.Standings, 2) {
+		assert.Equal(t, 9, standings.Rules.SeriesID)
+		assert.Equal(t, "Alpha", standings.Standings[0].Team.Name)
+		assert.Equal(t, 200, standings.Standings[0].Points)
+		assert.Len(t, standings.Standings[1].RaceWeeks, 2)
+	}
+	rules, err := c.UpdateTeamRules(database.TeamRules{SeriesID: 9, TopDrivers: 2, DropWeeks: 1, MinWeeks: 4})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, rules.Rules.MinWeeks)
+	}
+	_, err = c.UpdateTeamRules(database.TeamRules{SeriesID: 9, TopDrivers: 0})
+	assert.Error(t, err)
+	team, err := c.CreateTeam("Alpha")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, team.Team.TeamID)
+	}
+	team, err = c.GetTeam(3)
+	if assert.NoError(t, err) && assert.Len(t, team.Memberships, 1) {
+		assert.Equal(t, "Jack", team.Memberships[0].DriverName)
+		assert.Nil(t, team.Memberships[0].ValidTo)
+	}
+	validTo := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	membership, err := c.AddTeamMember(3, 1, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), &validTo)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 5, membership.Membership.MembershipID)
+		assert.True(t, validTo.Equal(*membership.Membership.ValidTo))
+	}
+	_, err = c.UpdateTeamMember(4, 5, time.Now(), nil)
+	assert.Error(t, err) // membership 5 is part of team 3
+	_, err = c.RemoveTeamMember(3, 5)
+	assert.NoError(t, err)
+}